@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	LockFileSuffix = ".lock"
+	// LockTakeoverSuffix is the suffix of the lock file of the run which takes over a lock of a run which is gone
+	LockTakeoverSuffix = ".takeover"
+	LockTimeout        = 30 * time.Second
+	lockRetryDelay     = 100 * time.Millisecond
+)
+
+// Snapshot is the result of one run when running in append mode
+type Snapshot struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Result    ResultFile `json:"result"`
+}
+
+// appendResult appends the data as a snapshot to the snapshots in fileName, the file is created when it does not exist
+func appendResult(fileName string, data ResultFile, timestamp time.Time, perm os.FileMode) error {
+	unlock, err := lockFile(fileName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var snapshots []Snapshot
+	content, err := os.ReadFile(fileName)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, &snapshots); err != nil {
+			return fmt.Errorf("'%s' is not a list of snapshots: %w", fileName, err)
+		}
+	}
+
+	file, err := json.MarshalIndent(append(snapshots, Snapshot{Timestamp: timestamp, Result: data}), "", " ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fileName, perm, func(w io.Writer) error {
+		_, err := w.Write(file)
+		return err
+	})
+}
+
+// lockFile creates a lock file next to fileName with the PID of this run and returns a func removing it again.
+// It waits for the lock file of another run to be removed, until LockTimeout is reached. A lock file of which the
+// process is gone, because the run crashed or was killed, is taken over, see removeStaleLock.
+func lockFile(fileName string) (func(), error) {
+	lockName := fileName + LockFileSuffix
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		if err := createLock(lockName); err == nil {
+			return func() { os.Remove(lockName) }, nil
+		} else if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		removed, err := removeStaleLock(lockName)
+		if err != nil {
+			return nil, err
+		}
+		if removed {
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("unable to lock '%s', remove '%s' if no other run is in progress", fileName, lockName)
+		}
+		time.Sleep(lockRetryDelay)
+	}
+}
+
+// createLock creates the lock file with the PID of this run, it fails with fs.ErrExist when it is already there
+func createLock(lockName string) error {
+	lock, err := os.OpenFile(lockName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0664)
+	if err != nil {
+		return err
+	}
+	_, err = lock.WriteString(strconv.Itoa(os.Getpid()))
+	lock.Close()
+	if err != nil {
+		os.Remove(lockName)
+	}
+	return err
+}
+
+// removeStaleLock removes the lock file when its process is gone and reports whether it did. Only one run at a time
+// removes a stale lock, which holds the takeover lock next to it while it checks the PID again, so a run which saw the
+// same stale PID can not remove the lock another run created in the meantime.
+func removeStaleLock(lockName string) (bool, error) {
+	if pid, ok := lockOwner(lockName); !ok || processExists(pid) {
+		return false, nil
+	}
+	takeoverName := lockName + LockTakeoverSuffix
+	if err := createLock(takeoverName); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			// another run is taking the lock over
+			return false, nil
+		}
+		return false, err
+	}
+	defer os.Remove(takeoverName)
+
+	pid, ok := lockOwner(lockName)
+	if !ok || processExists(pid) {
+		return false, nil
+	}
+	slog.Warn("taking over the lock of a run which is gone", "lock", lockName, "pid", pid)
+	if err := os.Remove(lockName); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	return true, nil
+}
+
+// lockOwner returns the PID in the lock file, ok is false when it can not be read, e.g. as it is being written
+func lockOwner(lockName string) (pid int, ok bool) {
+	content, err := os.ReadFile(lockName)
+	if err != nil {
+		return 0, false
+	}
+	pid, err = strconv.Atoi(strings.TrimSpace(string(content)))
+	return pid, err == nil && pid > 0
+}
+
+// processExists reports whether the process with the PID is running on this host.
+// On Windows a process can not be signalled, there it exists when it can be opened, which FindProcess does.
+func processExists(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		process.Release()
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	return !errors.Is(err, os.ErrProcessDone) && !errors.Is(err, syscall.ESRCH)
+}
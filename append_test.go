@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	IS "github.com/matryer/is"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppendResultFirstWrite(t *testing.T) {
+	is := IS.New(t)
+	fileName := filepath.Join(t.TempDir(), "results.json")
+	timestamp := time.Date(2021, 6, 1, 2, 0, 0, 0, time.UTC)
+
+	is.NoErr(appendResult(fileName, ResultFile{TotalCountSum: 3}, timestamp, DefaultOutputFileMode))
+
+	snapshots := readSnapshots(t, fileName)
+	is.Equal(1, len(snapshots))
+	is.True(snapshots[0].Timestamp.Equal(timestamp))
+	is.Equal(3, snapshots[0].Result.TotalCountSum)
+	_, err := os.Stat(fileName + LockFileSuffix)
+	is.True(os.IsNotExist(err)) // the lock file should be removed
+}
+
+func TestAppendResultAppends(t *testing.T) {
+	is := IS.New(t)
+	fileName := filepath.Join(t.TempDir(), "results.json")
+	first := time.Date(2021, 6, 1, 2, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+
+	is.NoErr(appendResult(fileName, ResultFile{TotalCountSum: 3}, first, DefaultOutputFileMode))
+	is.NoErr(appendResult(fileName, ResultFile{TotalCountSum: 5}, second, DefaultOutputFileMode))
+
+	snapshots := readSnapshots(t, fileName)
+	is.Equal(2, len(snapshots))
+	is.True(snapshots[0].Timestamp.Equal(first))
+	is.Equal(3, snapshots[0].Result.TotalCountSum)
+	is.True(snapshots[1].Timestamp.Equal(second))
+	is.Equal(5, snapshots[1].Result.TotalCountSum)
+}
+
+func TestAppendResultNotSnapshots(t *testing.T) {
+	is := IS.New(t)
+	fileName := filepath.Join(t.TempDir(), "results.json")
+	is.NoErr(writeResult(fileName, ResultFile{TotalCountSum: 3}, DefaultOutputFileMode))
+
+	err := appendResult(fileName, ResultFile{TotalCountSum: 5}, time.Now(), DefaultOutputFileMode)
+
+	is.True(err != nil) // a plain result file can not be appended to
+}
+
+func readSnapshots(t *testing.T, fileName string) []Snapshot {
+	t.Helper()
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshots []Snapshot
+	if err := json.Unmarshal(content, &snapshots); err != nil {
+		t.Fatal(err)
+	}
+	return snapshots
+}
+
+func TestLockFileTakesOverLockOfGoneProcess(t *testing.T) {
+	is := IS.New(t)
+	fileName := filepath.Join(t.TempDir(), "results.json")
+	gone := exec.Command("true")
+	is.NoErr(gone.Run()) // the PID of a process which has exited
+	is.NoErr(os.WriteFile(fileName+LockFileSuffix, []byte(strconv.Itoa(gone.Process.Pid)), 0664))
+
+	unlock, err := lockFile(fileName)
+
+	is.NoErr(err)
+	content, err := os.ReadFile(fileName + LockFileSuffix)
+	is.NoErr(err)
+	is.Equal(strconv.Itoa(os.Getpid()), string(content)) // the lock is held by this process
+	unlock()
+}
+
+func TestLockOwner(t *testing.T) {
+	is := IS.New(t)
+	lockName := filepath.Join(t.TempDir(), "results.json"+LockFileSuffix)
+	is.NoErr(os.WriteFile(lockName, []byte(""), 0664))
+
+	_, ok := lockOwner(lockName)
+	is.True(!ok) // a lock which PID is not written yet is not taken over
+
+	is.NoErr(os.WriteFile(lockName, []byte(strconv.Itoa(os.Getpid())), 0664))
+	pid, ok := lockOwner(lockName)
+	is.True(ok)
+	is.True(processExists(pid))
+}
+
+func TestLockFileTakeOverByOneRun(t *testing.T) {
+	is := IS.New(t)
+	fileName := filepath.Join(t.TempDir(), "results.json")
+	gone := exec.Command("true")
+	is.NoErr(gone.Run())
+	is.NoErr(os.WriteFile(fileName+LockFileSuffix, []byte(strconv.Itoa(gone.Process.Pid)), 0664))
+	var (
+		wg            sync.WaitGroup
+		mu            sync.Mutex
+		holding, peak int
+		lockErrs      []error
+	)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := lockFile(fileName)
+			mu.Lock()
+			if err != nil {
+				lockErrs = append(lockErrs, err)
+				mu.Unlock()
+				return
+			}
+			holding++
+			if holding > peak {
+				peak = holding
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			holding--
+			mu.Unlock()
+			unlock()
+		}()
+	}
+	wg.Wait()
+
+	is.Equal(0, len(lockErrs))
+	is.Equal(1, peak) // the runs which saw the stale lock hold the lock one at a time
+	_, err := os.Stat(fileName + LockFileSuffix + LockTakeoverSuffix)
+	is.True(os.IsNotExist(err))
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// ArchiveSeparator separates the name of the archive from the name of the file inside it
+	ArchiveSeparator = "!"
+
+	binarySniffLen = 8000
+	// MaxArchiveEntrySize is the size of the largest file inside an archive which is searched, or max_file_size when it
+	// is smaller, so an archive of a few kilobytes unpacking to gigabytes does not exhaust the memory
+	MaxArchiveEntrySize = 100 << 20
+)
+
+// searchArchives counts the matches of the search words in the text files inside the zip, tar and tar.gz archives in path.
+// The file names of the results are the name of the archive and the name of the file inside it joined by ArchiveSeparator.
+// An archive which can not be read is skipped with a warning.
+func searchArchives(path string, searchWords []string, opts grepOptions) ([]GrepResult, error) {
+	re, err := compileSearchWords(quoteSearchWords(searchWords, opts.FixedStrings), opts.CaseSensitive, opts.WordBoundary)
+	if err != nil {
+		return nil, err
+	}
+	archives, err := archiveFiles(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize := int64(MaxArchiveEntrySize)
+	if opts.MaxFileSize > 0 && opts.MaxFileSize < maxSize {
+		maxSize = opts.MaxFileSize
+	}
+	results := []GrepResult{}
+	for _, archive := range archives {
+		grs, err := searchArchive(filepath.Join(path, archive), re, maxSize)
+		if err != nil {
+			// a corrupt archive does not fail the repository, the other files are still searched
+			slog.Warn("skipping archive which can not be read", "archive", archive, "error", err)
+			continue
+		}
+		// the exclude_files globs are matched against the path inside the archive as well
+		for _, gr := range filterExcludeFiles(grs, opts.ExcludeFiles) {
+			gr.FileName = archive + ArchiveSeparator + gr.FileName
+			results = append(results, gr)
+		}
+	}
+	return attributeWords(results, searchWords, opts.CaseSensitive), nil
+}
+
+// archiveFiles returns the archives in path, relative to path, skipping the same dirs as grep does
+func archiveFiles(root string, opts grepOptions) ([]string, error) {
+	opts.IncludeGlobs = nil
+	files, err := searchedFiles(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, file := range files {
+		if isArchive(file) {
+			result = append(result, file)
+		}
+	}
+	return result, nil
+}
+
+func isArchive(name string) bool {
+	return strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".tar") || isTarGz(name)
+}
+
+func isTarGz(name string) bool {
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// splitArchiveName splits the name of a result in the name of the archive and the name of the file inside it, ok is
+// false for a file which is not inside an archive. A '!' is only a separator after the name of an archive, so an
+// ordinary file name like 'a!b.txt' is not split.
+func splitArchiveName(name string) (archive, inner string, ok bool) {
+	for i := strings.Index(name, ArchiveSeparator); i >= 0; {
+		if isArchive(name[:i]) {
+			return name[:i], name[i+len(ArchiveSeparator):], true
+		}
+		next := strings.Index(name[i+1:], ArchiveSeparator)
+		if next < 0 {
+			break
+		}
+		i += 1 + next
+	}
+	return "", "", false
+}
+
+// readMatchedFile reads the file of a result in basePath, which is read from its archive when it is inside one
+func readMatchedFile(basePath, fileName string) ([]byte, error) {
+	archive, inner, ok := splitArchiveName(fileName)
+	if !ok {
+		return os.ReadFile(filepath.Join(basePath, fileName))
+	}
+	var content []byte
+	found := false
+	err := eachArchiveEntry(filepath.Join(basePath, archive), func(name string, reader io.Reader) (bool, error) {
+		if name != inner {
+			return false, nil
+		}
+		var err error
+		content, found, err = readEntry(reader, MaxArchiveEntrySize)
+		return true, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s not found in archive %s", inner, archive)
+	}
+	return content, nil
+}
+
+// searchArchive counts the matches of re per text file inside the archive, the files larger than maxSize are skipped
+func searchArchive(fileName string, re *regexp.Regexp, maxSize int64) ([]GrepResult, error) {
+	var results []GrepResult
+	err := eachArchiveEntry(fileName, func(name string, reader io.Reader) (bool, error) {
+		content, ok, err := readEntry(reader, maxSize)
+		if err != nil {
+			return true, err
+		}
+		if !ok {
+			slog.Debug("skipping large file in archive", "archive", fileName, "file", name)
+			return false, nil
+		}
+		if isBinary(content) {
+			return false, nil
+		}
+		if gr, ok := matchContent(name, content, re); ok {
+			results = append(results, gr)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// eachArchiveEntry calls visit with the name and the content of every regular file inside the archive, until visit
+// returns true or an error
+func eachArchiveEntry(fileName string, visit func(name string, reader io.Reader) (bool, error)) error {
+	if strings.HasSuffix(fileName, ".zip") {
+		return eachZipEntry(fileName, visit)
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if isTarGz(fileName) {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	return eachTarEntry(reader, visit)
+}
+
+// readEntry reads a file inside an archive, ok is false when it is larger than maxSize. The size in the header of
+// the archive is not trusted, the file is read up to one byte more than maxSize.
+func readEntry(reader io.Reader, maxSize int64) (content []byte, ok bool, err error) {
+	content, err = io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, false, err
+	}
+	return content, int64(len(content)) <= maxSize, nil
+}
+
+func eachZipEntry(fileName string, visit func(name string, reader io.Reader) (bool, error)) error {
+	archive, err := zip.OpenReader(fileName)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		entry, err := f.Open()
+		if err != nil {
+			return err
+		}
+		done, err := visit(f.Name, entry)
+		entry.Close()
+		if done || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func eachTarEntry(reader io.Reader, visit func(name string, reader io.Reader) (bool, error)) error {
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if done, err := visit(header.Name, archive); done || err != nil {
+			return err
+		}
+	}
+}
+
+// matchContent counts the matches of re in content the same way as parseGrepOutput
+func matchContent(name string, content []byte, re *regexp.Regexp) (GrepResult, bool) {
+	matches := re.FindAll(content, -1)
+	if len(matches) == 0 {
+		return GrepResult{}, false
+	}
+	gr := GrepResult{FileName: name, Count: len(matches), Words: make(map[string]int)}
+	for _, match := range matches {
+		gr.Words[string(match)]++
+	}
+	return gr, true
+}
+
+// isBinary reports whether the content looks binary, which like grep is when it contains a NUL byte
+func isBinary(content []byte) bool {
+	if len(content) > binarySniffLen {
+		content = content[:binarySniffLen]
+	}
+	return bytes.IndexByte(content, 0) != -1
+}
+
+// compileSearchWords compiles the search words into one regexp matching any of them, case-insensitive unless caseSensitive
+// and only as whole words when wordBoundary.
+// Note that the words are compiled with the Go regexp syntax, which for plain words is the same as grep.
+func compileSearchWords(searchWords []string, caseSensitive, wordBoundary bool) (*regexp.Regexp, error) {
+	var alternatives []string
+	for _, word := range searchWords {
+		if wordBoundary {
+			alternatives = append(alternatives, `\b(?:`+word+`)\b`)
+		} else {
+			alternatives = append(alternatives, "(?:"+word+")")
+		}
+	}
+	return regexp.Compile(caseFlag(caseSensitive) + strings.Join(alternatives, "|"))
+}
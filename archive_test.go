@@ -0,0 +1,157 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var archiveEntries = map[string]string{
+	"docs/notes.txt": "a needle and another needle",
+	"empty.txt":      "nothing to see",
+	"image.bin":      "needle\x00\x01\x02",
+}
+
+func TestSearchArchives(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.Mkdir(filepath.Join(dir, "fixtures"), 0755))
+	writeZip(t, filepath.Join(dir, "fixtures", "fixture.zip"), archiveEntries)
+	writeTarGz(t, filepath.Join(dir, "fixture.tar.gz"), archiveEntries)
+	is.NoErr(os.Mkdir(filepath.Join(dir, "node_modules"), 0755))
+	writeZip(t, filepath.Join(dir, "node_modules", "excluded.zip"), archiveEntries)
+	result, err := searchArchives(dir, []string{"needle"}, grepOptions{ExcludeDirs: []string{"node_modules"}})
+	is.NoErr(err)
+	sortOnFileName(result)
+
+	is.Equal([]GrepResult{
+		{FileName: "fixture.tar.gz!docs/notes.txt", Count: 2, Words: map[string]int{"needle": 2}},
+		{FileName: "fixtures/fixture.zip!docs/notes.txt", Count: 2, Words: map[string]int{"needle": 2}},
+	}, result) // the binary entries and the excluded dir should be skipped
+}
+
+func TestSearchArchivesSkipsCorruptArchive(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "fixture.zip"), archiveEntries)
+	is.NoErr(os.WriteFile(filepath.Join(dir, "corrupt.zip"), []byte("needle, but not a zip"), 0644))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "corrupt.tar.gz"), []byte("needle, but not gzipped"), 0644))
+
+	result, err := searchArchives(dir, []string{"needle"}, grepOptions{})
+
+	is.NoErr(err)
+	is.Equal([]GrepResult{{FileName: "fixture.zip!docs/notes.txt", Count: 2, Words: map[string]int{"needle": 2}}}, result)
+}
+
+func TestAnalyzePathFiltersArchiveResults(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "fixture.zip"), map[string]string{
+		"docs/notes.txt": "a needle\n// approved needle\n",
+		"app.min.js":     "needle",
+	})
+	cfg := Config{
+		SearchWords:     []string{"needle"},
+		SearchArchives:  true,
+		ExcludePatterns: []string{"approved"},
+		ExcludeFiles:    []string{"*.min.js"},
+		IncludeMatches:  true,
+	}
+
+	app, err := analyzePath(context.Background(), Repository{Name: "repo"}, cfg, dir)
+
+	is.NoErr(err)
+	is.Equal([]GrepResult{{
+		FileName: "fixture.zip!docs/notes.txt",
+		Count:    1,
+		Words:    map[string]int{"needle": 1},
+		Matches:  []Match{{Line: 1, Column: 3, Text: "a needle", Word: "needle"}},
+	}}, app.GrepResults)
+}
+
+func TestSplitArchiveName(t *testing.T) {
+	is := IS.New(t)
+
+	archive, inner, ok := splitArchiveName("docs/fixture.tar.gz!notes/a!b.txt")
+	is.True(ok)
+	is.Equal("docs/fixture.tar.gz", archive)
+	is.Equal("notes/a!b.txt", inner)
+
+	archive, inner, ok = splitArchiveName("wow!docs.zip!notes.txt")
+	is.True(ok)
+	is.Equal("wow!docs.zip", archive)
+	is.Equal("notes.txt", inner)
+
+	_, _, ok = splitArchiveName("a!b.txt")
+	is.True(!ok)
+}
+
+func TestSearchArchiveSkipsLargeEntries(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	entries := map[string]string{"small.txt": "needle", "large.txt": "needle " + strings.Repeat("x", 100)}
+	writeZip(t, filepath.Join(dir, "fixture.zip"), entries)
+	writeTarGz(t, filepath.Join(dir, "fixture.tar.gz"), entries)
+	re, err := compileSearchWords([]string{"needle"}, false, false)
+	is.NoErr(err)
+
+	for _, archive := range []string{"fixture.zip", "fixture.tar.gz"} {
+		result, err := searchArchive(filepath.Join(dir, archive), re, 50)
+		is.NoErr(err)
+		is.Equal([]GrepResult{{FileName: "small.txt", Count: 1, Words: map[string]int{"needle": 1}}}, result)
+	}
+}
+
+func writeZip(t *testing.T, fileName string, entries map[string]string) {
+	t.Helper()
+	file, err := os.Create(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+	for name, content := range entries {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTarGz(t *testing.T, fileName string, entries map[string]string) {
+	t.Helper()
+	file, err := os.Create(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	archive := tar.NewWriter(gz)
+	for name, content := range entries {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	IS "github.com/matryer/is"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteResultFileMode(t *testing.T) {
+	is := IS.New(t)
+	fileName := filepath.Join(t.TempDir(), "results.json")
+
+	is.NoErr(writeResult(fileName, ResultFile{TotalCountSum: 3}, 0640))
+
+	info, err := os.Stat(fileName)
+	is.NoErr(err)
+	is.Equal(os.FileMode(0640), info.Mode().Perm())
+}
+
+func TestWriteFileAtomicFailureKeepsOriginal(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "results.json")
+	is.NoErr(os.WriteFile(fileName, []byte(`{"total_count_sum": 3}`), 0644))
+
+	err := writeFileAtomic(fileName, 0644, func(w io.Writer) error {
+		if _, err := w.Write([]byte(`{"total_cou`)); err != nil {
+			return err
+		}
+		return errors.New("disk full")
+	})
+
+	is.True(err != nil)
+	content, err := os.ReadFile(fileName)
+	is.NoErr(err)
+	is.Equal(`{"total_count_sum": 3}`, string(content)) // the original should be intact
+	entries, err := os.ReadDir(dir)
+	is.NoErr(err)
+	is.Equal(1, len(entries)) // the temp file should be removed
+}
+
+func TestParseFileMode(t *testing.T) {
+	is := IS.New(t)
+
+	mode, err := parseFileMode("0640")
+	is.NoErr(err)
+	is.Equal(os.FileMode(0640), mode)
+	mode, err = parseFileMode("")
+	is.NoErr(err)
+	is.Equal(DefaultOutputFileMode, mode)
+	_, err = parseFileMode("rw-r--r--")
+	is.True(err != nil)
+	_, err = parseFileMode("17777")
+	is.True(err != nil)
+}
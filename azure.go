@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const DefaultAzureDevOpsURL = "https://dev.azure.com"
+
+// azureDevOpsRepo is a repository in the response of the Azure DevOps API, only the used fields are decoded
+type azureDevOpsRepo struct {
+	Name       string `json:"name"`
+	RemoteURL  string `json:"remoteUrl"`
+	IsDisabled bool   `json:"isDisabled"`
+	IsFork     bool   `json:"isFork"`
+	Project    struct {
+		Name string `json:"name"`
+	} `json:"project"`
+}
+
+// listAzureDevOpsRepos lists the Git repositories of the project, or of the whole organization when the project is empty.
+// The repositories are named '<project>/<repository>' as repositories in different projects can have the same name.
+// A disabled repository can not be cloned, so it is reported as archived.
+func listAzureDevOpsRepos(ctx context.Context, client *http.Client, baseURL, organization, project, token string) ([]discoveredRepo, error) {
+	var headers map[string]string
+	if token != "" {
+		headers = map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(":"+token))}
+	}
+	reposURL := strings.TrimSuffix(baseURL, "/") + "/" + url.PathEscape(organization)
+	if project != "" {
+		reposURL += "/" + url.PathEscape(project)
+	}
+	reposURL += "/_apis/git/repositories?api-version=7.0"
+
+	var response struct {
+		Value []azureDevOpsRepo `json:"value"`
+	}
+	if _, err := getJSON(ctx, client, reposURL, headers, &response); err != nil {
+		return nil, err
+	}
+	var result []discoveredRepo
+	for _, repo := range response.Value {
+		result = append(result, discoveredRepo{
+			Name:     repo.Project.Name + "/" + repo.Name,
+			CloneURL: withoutUser(repo.RemoteURL),
+			Archived: repo.IsDisabled,
+			Fork:     repo.IsFork,
+		})
+	}
+	return result, nil
+}
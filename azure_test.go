@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	IS "github.com/matryer/is"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListAzureDevOpsRepos(t *testing.T) {
+	is := IS.New(t)
+	var authorization, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		path = r.URL.Path
+		fmt.Fprint(w, `{"count": 2, "value": [
+			{"name": "users", "remoteUrl": "https://contoso@dev.azure.com/contoso/Platform/_git/users", "project": {"name": "Platform"}},
+			{"name": "legacy", "remoteUrl": "https://contoso@dev.azure.com/contoso/Platform/_git/legacy", "isDisabled": true, "project": {"name": "Platform"}}
+		]}`)
+	}))
+	defer server.Close()
+
+	repos, err := listAzureDevOpsRepos(context.Background(), server.Client(), server.URL, "contoso", "Platform", "pat")
+
+	is.NoErr(err)
+	is.Equal("/contoso/Platform/_apis/git/repositories", path)
+	is.Equal("Basic "+base64.StdEncoding.EncodeToString([]byte(":pat")), authorization)
+	is.Equal([]discoveredRepo{
+		{Name: "Platform/users", CloneURL: "https://dev.azure.com/contoso/Platform/_git/users"},
+		{Name: "Platform/legacy", CloneURL: "https://dev.azure.com/contoso/Platform/_git/legacy", Archived: true},
+	}, repos)
+
+	_, err = listAzureDevOpsRepos(context.Background(), server.Client(), server.URL, "contoso", "", "pat")
+	is.NoErr(err)
+	is.Equal("/contoso/_apis/git/repositories", path) // without a project all repositories of the organization are listed
+}
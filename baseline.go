@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	// CommandBaseline searches the repositories of the config and saves their matches as the baseline
+	CommandBaseline  = "baseline"
+	BaselineFilePath = "baseline.json"
+)
+
+// Baseline are the known matches, counted per application, file and search word. A run with a baseline_file only
+// reports the matches which are not in the baseline.
+type Baseline struct {
+	Applications map[string]map[string]map[string]int `json:"applications"`
+}
+
+// newBaseline counts the matches of the applications which were searched, the failed applications are left out
+func newBaseline(apps []Application) Baseline {
+	baseline := Baseline{Applications: map[string]map[string]map[string]int{}}
+	for _, app := range apps {
+		if app.Status == StatusFailed {
+			continue
+		}
+		files := map[string]map[string]int{}
+		for _, gr := range app.GrepResults {
+			files[gr.FileName] = gr.Words
+		}
+		baseline.Applications[app.Name] = files
+	}
+	return baseline
+}
+
+func writeBaseline(fileName string, baseline Baseline, perm os.FileMode) error {
+	data, err := json.MarshalIndent(baseline, "", " ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fileName, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+func loadBaseline(fileName string) (Baseline, error) {
+	var baseline Baseline
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return baseline, err
+	}
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return baseline, fmt.Errorf("unable to parse baseline file '%s': %w", fileName, err)
+	}
+	return baseline, nil
+}
+
+// subtracting wraps analyze so the applications only contain the matches which are not in the baseline
+func (b Baseline) subtracting(analyze analyzeFunc) analyzeFunc {
+	return func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		app, err := analyze(ctx, r, cfg)
+		if err != nil {
+			return app, err
+		}
+		return b.subtract(app, cfg), nil
+	}
+}
+
+// subtract removes the counts of the baseline from the files of the application and its subdirs and sums the counts
+// again. A file is left out when all of its matches are in the baseline, the matches of the other files are kept as
+// they can not be told apart from the ones in the baseline.
+func (b Baseline) subtract(app Application, cfg Config) Application {
+	known := b.Applications[app.Name]
+	if len(known) > 0 {
+		var result []GrepResult
+		for _, gr := range app.GrepResults {
+			// the words are copied as the application can be shared with the state of the run
+			words := make(map[string]int)
+			for word, count := range gr.Words {
+				if known := known[gr.FileName][word]; known > 0 {
+					removed := known
+					if removed > count {
+						removed = count
+					}
+					gr.Count -= removed
+					count -= removed
+				}
+				if count > 0 {
+					words[word] = count
+				}
+			}
+			gr.Words = words
+			if gr.Truncated {
+				// the count was clamped to max_count_per_file before the baseline is subtracted, so it is counted
+				// again from the words which are left and clamped again
+				gr.Count, gr.Truncated = 0, false
+				for _, count := range words {
+					gr.Count += count
+				}
+				if gr.Count > cfg.MaxCountPerFile {
+					gr.Count, gr.Truncated = cfg.MaxCountPerFile, true
+				}
+			}
+			if gr.Count > 0 {
+				result = append(result, gr)
+			}
+		}
+		if app.GrepResults != nil && result == nil {
+			result = []GrepResult{}
+		}
+		app.GrepResults = result
+		app.CountSum = sumTotalCountForGrepResults(result)
+		app.WordCounts = sumWordCounts(result)
+		app.GroupCounts = cfg.groupCounts(app.WordCounts)
+		app.SeverityCounts = cfg.severityCounts(app.WordCounts)
+		if cfg.ScoreMode == ScoreModeDensity {
+			app.Density = density(app.CountSum, app.LinesScanned)
+		}
+	}
+	if len(app.Subdirs) > 0 {
+		subdirs := make([]Application, len(app.Subdirs))
+		for i, subdir := range app.Subdirs {
+			subdirs[i] = b.subtract(subdir, cfg)
+		}
+		app.Subdirs = subdirs
+	}
+	return app
+}
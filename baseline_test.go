@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBaselineSubtract(t *testing.T) {
+	is := IS.New(t)
+	baseline := Baseline{Applications: map[string]map[string]map[string]int{
+		"api": {"main.go": {"oldAPI": 2}, "util.go": {"oldAPI": 1, "legacy": 1}},
+	}}
+	words := map[string]int{"oldAPI": 3}
+	app := Application{Name: "api", Status: StatusOK, GrepResults: []GrepResult{
+		{FileName: "main.go", Count: 3, Words: words},
+		{FileName: "util.go", Count: 1, Words: map[string]int{"oldAPI": 1}},
+		{FileName: "new.go", Count: 1, Words: map[string]int{"legacy": 1}},
+	}, Subdirs: []Application{{Name: "api/web", GrepResults: []GrepResult{{FileName: "a.go", Count: 1, Words: map[string]int{"oldAPI": 1}}}}}}
+
+	result := baseline.subtract(app, Config{})
+
+	is.Equal([]GrepResult{
+		{FileName: "main.go", Count: 1, Words: map[string]int{"oldAPI": 1}},
+		{FileName: "new.go", Count: 1, Words: map[string]int{"legacy": 1}},
+	}, result.GrepResults)
+	is.Equal(2, result.CountSum)
+	is.Equal(map[string]int{"oldAPI": 1, "legacy": 1}, result.WordCounts)
+	is.Equal(1, result.Subdirs[0].GrepResults[0].Count) // not in the baseline
+	is.Equal(map[string]int{"oldAPI": 3}, words)        // the application is not changed
+}
+
+func TestBaselineSubtractClampedCounts(t *testing.T) {
+	is := IS.New(t)
+	baseline := Baseline{Applications: map[string]map[string]map[string]int{
+		"api": {"gen.go": {"a": 45}, "big.go": {"a": 10}},
+	}}
+	app := Application{Name: "api", Status: StatusOK, GrepResults: []GrepResult{
+		{FileName: "gen.go", Count: 10, Truncated: true, Words: map[string]int{"a": 50}},
+		{FileName: "big.go", Count: 10, Truncated: true, Words: map[string]int{"a": 30}},
+	}}
+
+	result := baseline.subtract(app, Config{MaxCountPerFile: 10})
+
+	is.Equal([]GrepResult{
+		{FileName: "gen.go", Count: 5, Words: map[string]int{"a": 5}}, // the 5 new matches are kept
+		{FileName: "big.go", Count: 10, Truncated: true, Words: map[string]int{"a": 20}},
+	}, result.GrepResults)
+	is.Equal(15, result.CountSum)
+}
+
+func TestCliBaseline(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "repo")
+	is.NoErr(os.MkdirAll(repo, 0755))
+	is.NoErr(os.WriteFile(filepath.Join(repo, "main.go"), []byte("oldAPI()\n"), 0644))
+	configPath := filepath.Join(dir, "config.json")
+	baselinePath := filepath.Join(dir, "baseline.json")
+	resultPath := filepath.Join(dir, "results.json")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": [{"pattern": "oldAPI", "severity": "critical"}], "baseline_file": "`+baselinePath+`", "repositories": [{"name": "repo", "path": "repo"}]}`), 0644))
+	var output bytes.Buffer
+
+	is.Equal(ExitCodeSuccess, cli([]string{"baseline", "-config", configPath, "-output", baselinePath}, analyzeRepo, &output))
+	is.Equal(ExitCodeSuccess, cli([]string{"run", "-config", configPath, "-output", resultPath}, analyzeRepo, &output))
+
+	is.NoErr(os.WriteFile(filepath.Join(repo, "main.go"), []byte("oldAPI()\noldAPI()\n"), 0644))
+	is.Equal(ExitCodeCriticalMatch, cli([]string{"run", "-config", configPath, "-output", resultPath}, analyzeRepo, &output))
+
+	content, err := os.ReadFile(resultPath)
+	is.NoErr(err)
+	var result ResultFile
+	is.NoErr(json.Unmarshal(content, &result))
+	is.Equal(1, result.TotalCountSum) // only the new match
+}
+
+func TestParseBaselineFlags(t *testing.T) {
+	is := IS.New(t)
+	var output bytes.Buffer
+
+	opts, err := parseBaselineFlags([]string{"-config", "team-a.yaml"}, &output)
+
+	is.NoErr(err)
+	is.Equal(options{ConfigPath: "team-a.yaml", ResultPath: BaselineFilePath, Format: FormatJSON, Baseline: true, LogLevel: "info", LogFormat: LogFormatText}, opts)
+}
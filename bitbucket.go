@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultBitbucketCloudURL = "https://api.bitbucket.org"
+	// BitbucketCloudTokenUsername is the user name Bitbucket Cloud requires to clone with an access token
+	BitbucketCloudTokenUsername = "x-token-auth"
+)
+
+// bitbucketRepo is a repository in the response of the Bitbucket Cloud and the Bitbucket Server API.
+// Cloud names the https clone link 'https' and marks forks with a parent, Server names it 'http' and marks forks with an origin.
+type bitbucketRepo struct {
+	Slug     string    `json:"slug"`
+	FullName string    `json:"full_name"`
+	Parent   *struct{} `json:"parent"`
+	Origin   *struct{} `json:"origin"`
+	Links    struct {
+		Clone []struct {
+			Name string `json:"name"`
+			Href string `json:"href"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+func (r bitbucketRepo) discovered() discoveredRepo {
+	d := discoveredRepo{Name: r.Slug, Fork: r.Parent != nil || r.Origin != nil}
+	if r.FullName != "" {
+		d.Name = r.FullName
+	}
+	for _, link := range r.Links.Clone {
+		if link.Name == "https" || link.Name == "http" {
+			d.CloneURL = withoutUser(link.Href)
+		}
+	}
+	return d
+}
+
+// withoutUser removes the user name Bitbucket puts in its clone links, the token is sent by git instead
+func withoutUser(cloneURL string) string {
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return cloneURL
+	}
+	u.User = nil
+	return u.String()
+}
+
+func bitbucketHeaders(token string) map[string]string {
+	if token == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+// listBitbucketWorkspaceRepos lists all repositories of a Bitbucket Cloud workspace, following the next page links
+func listBitbucketWorkspaceRepos(ctx context.Context, client *http.Client, apiURL, workspace, token string) ([]discoveredRepo, error) {
+	var result []discoveredRepo
+	next := strings.TrimSuffix(apiURL, "/") + "/2.0/repositories/" + url.PathEscape(workspace) + "?pagelen=100"
+	first := next
+	for next != "" {
+		var page struct {
+			Values []bitbucketRepo `json:"values"`
+			Next   string          `json:"next"`
+		}
+		if _, err := getJSON(ctx, client, next, bitbucketHeaders(token), &page); err != nil {
+			return nil, err
+		}
+		for _, repo := range page.Values {
+			result = append(result, repo.discovered())
+		}
+		next = page.Next
+		if err := checkNextPage(first, next); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// listBitbucketServerProjectRepos lists all repositories of a Bitbucket Server project, following nextPageStart
+func listBitbucketServerProjectRepos(ctx context.Context, client *http.Client, baseURL, project, token string) ([]discoveredRepo, error) {
+	var result []discoveredRepo
+	start := 0
+	for {
+		var page struct {
+			Values        []bitbucketRepo `json:"values"`
+			IsLastPage    bool            `json:"isLastPage"`
+			NextPageStart int             `json:"nextPageStart"`
+		}
+		pageURL := strings.TrimSuffix(baseURL, "/") + "/rest/api/1.0/projects/" + url.PathEscape(project) + "/repos?limit=100&start=" + strconv.Itoa(start)
+		if _, err := getJSON(ctx, client, pageURL, bitbucketHeaders(token), &page); err != nil {
+			return nil, err
+		}
+		for _, repo := range page.Values {
+			result = append(result, repo.discovered())
+		}
+		if page.IsLastPage || page.NextPageStart <= start {
+			return result, nil
+		}
+		start = page.NextPageStart
+	}
+}
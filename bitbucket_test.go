@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	IS "github.com/matryer/is"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListBitbucketWorkspaceRepos(t *testing.T) {
+	is := IS.New(t)
+	var authorization string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"values": [{"slug": "infra", "full_name": "acme/infra", "parent": {"full_name": "other/infra"},
+				"links": {"clone": [{"name": "https", "href": "https://jdoe@bitbucket.org/acme/infra.git"}]}}]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"values": [{"slug": "backend", "full_name": "acme/backend",
+			"links": {"clone": [{"name": "ssh", "href": "git@bitbucket.org:acme/backend.git"}, {"name": "https", "href": "https://jdoe@bitbucket.org/acme/backend.git"}]}}],
+			"next": "%s/2.0/repositories/acme?pagelen=100&page=2"}`, server.URL)
+	}))
+	defer server.Close()
+
+	repos, err := listBitbucketWorkspaceRepos(context.Background(), server.Client(), server.URL, "acme", "secret")
+
+	is.NoErr(err)
+	is.Equal("Bearer secret", authorization)
+	is.Equal([]discoveredRepo{
+		{Name: "acme/backend", CloneURL: "https://bitbucket.org/acme/backend.git"},
+		{Name: "acme/infra", CloneURL: "https://bitbucket.org/acme/infra.git", Fork: true},
+	}, repos)
+}
+
+func TestListBitbucketServerProjectRepos(t *testing.T) {
+	is := IS.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PLAT/repos" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("start") == "25" {
+			fmt.Fprint(w, `{"values": [{"slug": "orders", "links": {"clone": [{"name": "http", "href": "https://git.example.com/scm/plat/orders.git"}]}}], "isLastPage": true}`)
+			return
+		}
+		fmt.Fprint(w, `{"values": [{"slug": "users", "links": {"clone": [{"name": "http", "href": "https://jdoe@git.example.com/scm/plat/users.git"}]}}], "isLastPage": false, "nextPageStart": 25}`)
+	}))
+	defer server.Close()
+
+	repos, err := listBitbucketServerProjectRepos(context.Background(), server.Client(), server.URL, "PLAT", "")
+
+	is.NoErr(err)
+	is.Equal([]discoveredRepo{
+		{Name: "users", CloneURL: "https://git.example.com/scm/plat/users.git"},
+		{Name: "orders", CloneURL: "https://git.example.com/scm/plat/orders.git"},
+	}, repos)
+}
+
+func TestValidateConfigBitbucket(t *testing.T) {
+	is := IS.New(t)
+
+	is.True(validateConfig(Config{SearchWords: []string{"fell"}, BitbucketProject: "PLAT"}) != nil)
+	is.True(validateConfig(Config{SearchWords: []string{"fell"}, BitbucketProject: "PLAT", BitbucketWorkspace: "acme", BitbucketURL: "https://git.example.com"}) != nil)
+	is.NoErr(validateConfig(Config{SearchWords: []string{"fell"}, BitbucketProject: "PLAT", BitbucketURL: "https://git.example.com"}))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCacheMaxAge is how long a cached clone is kept without being used when cache_max_age is not set
+const DefaultCacheMaxAge = 30 * 24 * time.Hour
+
+// cachedClone clones the repository into the cache dir, or updates the clone a previous run left there with
+// 'git fetch' and 'git reset'. A clone which can not be updated is cloned again. The returned removeDir keeps the clone.
+func cachedClone(ctx context.Context, r Repository, cfg Config) (string, removeDir, error) {
+	dir := filepath.Join(cfg.CacheDir, cacheEntryName(r, cfg))
+	keep := func() {}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		err := updateClone(ctx, r, cfg, dir)
+		if err == nil {
+			return dir, keep, touchCacheEntry(dir)
+		}
+		if ctx.Err() != nil {
+			return "", nil, fmt.Errorf("unable to update the cached clone of %s: %w", r.Name, err)
+		}
+		slog.Warn("unable to update the cached clone, cloning it again", "repository", r.Name, "error", redactError(err))
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return "", nil, fmt.Errorf("unable to clean up cached clone '%s': %w", dir, err)
+	}
+	if err := os.MkdirAll(cfg.CacheDir, 0700); err != nil {
+		return "", nil, fmt.Errorf("unable to create cache dir '%s': %w", cfg.CacheDir, err)
+	}
+	if err := gitClone(ctx, r, dir, cfg); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", nil, err
+	}
+	return dir, keep, touchCacheEntry(dir)
+}
+
+// cacheEntryName is the name of the dir in the cache a repository is cloned into. The clone depth is part of the name
+// as a shallow clone can not be updated into a clone with more history.
+func cacheEntryName(r Repository, cfg Config) string {
+	return deterministicDirName(cloneKey(r) + "#" + strconv.Itoa(cloneDepth(r, cfg)))
+}
+
+// updateClone fetches the ref of the repository, its default branch when no ref is set, and resets the clone to it.
+// Files left behind by a previous run are removed.
+func updateClone(ctx context.Context, r Repository, cfg Config, path string) error {
+	for _, cmd := range updateCloneCommands(ctx, cfg.gitBinary(), path, cloneURL(r.Url, cfg.CloneProtocol), r.Ref, cloneDepth(r, cfg)) {
+		cmd.Env = gitAuthEnv(r, cfg)
+		logCommand(cmd.Args)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, redactCredentials(strings.TrimSpace(string(out))))
+		}
+	}
+	return nil
+}
+
+func updateCloneCommands(ctx context.Context, gitBinary, path, url, ref string, depth int) []*exec.Cmd {
+	git := func(args ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, gitBinary, append([]string{"-C", path}, args...)...)
+	}
+	target := ref
+	if target == "" {
+		target = "HEAD"
+	}
+	cmds := []*exec.Cmd{git("remote", "set-url", "origin", url)}
+	if depth > 0 {
+		cmds = append(cmds, git("fetch", "--quiet", "--force", "--depth", strconv.Itoa(depth), "origin", target))
+	} else {
+		// the remote branches are updated as well, changed_since compares with them
+		cmds = append(cmds, git("fetch", "--quiet", "--force", "--prune", "origin"), git("fetch", "--quiet", "--force", "origin", target))
+	}
+	return append(cmds,
+		git("reset", "--quiet", "--hard", "FETCH_HEAD"),
+		git("clean", "--quiet", "-ffdx"),
+	)
+}
+
+// touchCacheEntry marks the cached clone as used now, the clones which are not used for cache_max_age are evicted
+func touchCacheEntry(dir string) error {
+	now := time.Now()
+	if err := os.Chtimes(dir, now, now); err != nil {
+		return fmt.Errorf("unable to mark cached clone '%s' as used: %w", dir, err)
+	}
+	return nil
+}
+
+// evictCachedClones removes the clones in the cache dir which were last used before maxAge ago.
+// A cache dir which does not exist yet has nothing to evict.
+func evictCachedClones(cacheDir string, maxAge time.Duration, now time.Time) error {
+	entries, err := ioutil.ReadDir(cacheDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read cache dir '%s': %w", cacheDir, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "clone-") || now.Sub(entry.ModTime()) <= maxAge {
+			continue
+		}
+		path := filepath.Join(cacheDir, entry.Name())
+		slog.Info("evicting cached clone", "path", path)
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("unable to evict cached clone '%s': %w", path, err)
+		}
+	}
+	return nil
+}
+
+// cacheMaxAge parses the configured cache_max_age, DefaultCacheMaxAge is used when it is not set
+func cacheMaxAge(maxAge string) (time.Duration, error) {
+	if maxAge == "" {
+		return DefaultCacheMaxAge, nil
+	}
+	return time.ParseDuration(maxAge)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpdateCloneCommands(t *testing.T) {
+	is := IS.New(t)
+	argsOf := func(cmds []*exec.Cmd) [][]string {
+		var args [][]string
+		for _, cmd := range cmds {
+			args = append(args, cmd.Args)
+		}
+		return args
+	}
+
+	is.Equal([][]string{
+		{"git", "-C", "/cache/clone", "remote", "set-url", "origin", "https://github.com/akselleirv/introspect-backend.git"},
+		{"git", "-C", "/cache/clone", "fetch", "--quiet", "--force", "--depth", "1", "origin", "HEAD"},
+		{"git", "-C", "/cache/clone", "reset", "--quiet", "--hard", "FETCH_HEAD"},
+		{"git", "-C", "/cache/clone", "clean", "--quiet", "-ffdx"},
+	}, argsOf(updateCloneCommands(context.Background(), "git", "/cache/clone", "https://github.com/akselleirv/introspect-backend.git", "", 1)))
+
+	is.Equal([][]string{
+		{"git", "-C", "/cache/clone", "remote", "set-url", "origin", "https://github.com/akselleirv/introspect-backend.git"},
+		{"git", "-C", "/cache/clone", "fetch", "--quiet", "--force", "--prune", "origin"},
+		{"git", "-C", "/cache/clone", "fetch", "--quiet", "--force", "origin", "v2.0"},
+		{"git", "-C", "/cache/clone", "reset", "--quiet", "--hard", "FETCH_HEAD"},
+		{"git", "-C", "/cache/clone", "clean", "--quiet", "-ffdx"},
+	}, argsOf(updateCloneCommands(context.Background(), "git", "/cache/clone", "https://github.com/akselleirv/introspect-backend.git", "v2.0", 0)))
+}
+
+func TestCachedClone(t *testing.T) {
+	is := IS.New(t)
+	source := newTestRepo(t, map[string]string{"main.go": "fell"})
+	r := Repository{Name: "repo", Url: "file://" + source}
+	cfg := Config{CacheDir: t.TempDir()}
+
+	dir, removeDir, err := cachedClone(context.Background(), r, cfg)
+	is.NoErr(err)
+	removeDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, "left-over.txt"), []byte("fell"), 0644))
+
+	is.NoErr(os.WriteFile(filepath.Join(source, "new.go"), []byte("fell"), 0644))
+	for _, args := range [][]string{
+		{"add", "--all"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--message=new"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = source
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+
+	again, _, err := cachedClone(context.Background(), r, cfg)
+	is.NoErr(err)
+	is.Equal(dir, again) // the clone of the previous run is updated
+	_, err = os.Stat(filepath.Join(again, "new.go"))
+	is.NoErr(err)
+	_, err = os.Stat(filepath.Join(again, "left-over.txt"))
+	is.True(os.IsNotExist(err))
+}
+
+func TestCachedCloneBroken(t *testing.T) {
+	is := IS.New(t)
+	source := newTestRepo(t, map[string]string{"main.go": "fell"})
+	r := Repository{Name: "repo", Url: "file://" + source}
+	cfg := Config{CacheDir: t.TempDir()}
+	broken := filepath.Join(cfg.CacheDir, cacheEntryName(r, cfg))
+	is.NoErr(os.MkdirAll(filepath.Join(broken, ".git"), 0755))
+
+	dir, _, err := cachedClone(context.Background(), r, cfg)
+
+	is.NoErr(err)
+	is.Equal(broken, dir)
+	_, err = os.Stat(filepath.Join(dir, "main.go"))
+	is.NoErr(err) // the clone which could not be updated is cloned again
+}
+
+func TestCacheEntryName(t *testing.T) {
+	is := IS.New(t)
+	r := Repository{Name: "repo", Url: "https://github.com/akselleirv/introspect-backend.git"}
+
+	is.Equal(cacheEntryName(r, Config{}), cacheEntryName(r, Config{}))
+	is.True(cacheEntryName(r, Config{}) != cacheEntryName(r, Config{FullHistory: true}))
+	is.True(cacheEntryName(r, Config{}) != cacheEntryName(Repository{Name: "repo", Url: r.Url, Ref: "v2.0"}, Config{}))
+}
+
+func TestEvictCachedClones(t *testing.T) {
+	is := IS.New(t)
+	cacheDir := t.TempDir()
+	now := time.Now()
+	for name, lastUsed := range map[string]time.Time{
+		"clone-recent": now.Add(-time.Hour),
+		"clone-stale":  now.Add(-48 * time.Hour),
+		"other":        now.Add(-48 * time.Hour),
+	} {
+		dir := filepath.Join(cacheDir, name)
+		is.NoErr(os.Mkdir(dir, 0700))
+		is.NoErr(os.Chtimes(dir, lastUsed, lastUsed))
+	}
+
+	is.NoErr(evictCachedClones(cacheDir, 24*time.Hour, now))
+
+	entries, err := os.ReadDir(cacheDir)
+	is.NoErr(err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	is.Equal([]string{"clone-recent", "other"}, names)
+	is.NoErr(evictCachedClones(filepath.Join(cacheDir, "missing"), time.Hour, now))
+}
+
+func TestValidateConfigCacheMaxAge(t *testing.T) {
+	is := IS.New(t)
+
+	is.NoErr(validateConfig(Config{SearchWords: []string{"fell"}, CacheMaxAge: "168h"}))
+	is.True(validateConfig(Config{SearchWords: []string{"fell"}, CacheMaxAge: "a week"}) != nil)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// SearchWord is a search word in the config, either a string or an object which overrides case_sensitive,
+// word_boundary or regex for the word, e.g. {"pattern": "Foo", "case_sensitive": true}
+type SearchWord struct {
+	Pattern       string `json:"pattern"`
+	CaseSensitive *bool  `json:"case_sensitive"`
+	WordBoundary  *bool  `json:"word_boundary"`
+	Regex         *bool  `json:"regex"`
+	// Group is the name of the group the counts of the word are summed in, e.g. deprecated_apis
+	Group string `json:"group"`
+	// Severity is info, warning or critical, finding a critical word makes the run exit with ExitCodeCriticalMatch
+	Severity string `json:"severity"`
+}
+
+func (w *SearchWord) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &w.Pattern); err == nil {
+		return nil
+	}
+	type searchWord SearchWord
+	if err := json.Unmarshal(data, (*searchWord)(w)); err != nil {
+		return err
+	}
+	if w.Pattern == "" {
+		return errors.New("a search word object needs a pattern")
+	}
+	return nil
+}
+
+// UnmarshalJSON reads the search words, which can be strings or SearchWord objects, into SearchWords and the
+// objects into WordOptions
+func (cfg *Config) UnmarshalJSON(data []byte) error {
+	type config Config
+	aux := struct {
+		*config
+		SearchWords []SearchWord `json:"search_words"`
+	}{config: (*config)(cfg)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	cfg.SearchWords = nil
+	for _, word := range aux.SearchWords {
+		cfg.SearchWords = append(cfg.SearchWords, word.Pattern)
+		if word != (SearchWord{Pattern: word.Pattern}) {
+			if cfg.WordOptions == nil {
+				cfg.WordOptions = map[string]SearchWord{}
+			}
+			cfg.WordOptions[word.Pattern] = word
+		}
+	}
+	return nil
+}
+
+// searchWordGroup are search words which are matched with the same options
+type searchWordGroup struct {
+	Words         []string
+	CaseSensitive bool
+	WordBoundary  bool
+	// FixedStrings matches the search words literally instead of as regular expressions
+	FixedStrings bool
+	// RegexEngine is the regex_engine of the config, it is the same for all groups
+	RegexEngine string
+}
+
+// wordGroup returns the group of the search word, with the options of the config unless the word overrides them
+func (cfg Config) wordGroup(word string) searchWordGroup {
+	group := searchWordGroup{CaseSensitive: cfg.CaseSensitive, WordBoundary: cfg.WordBoundary, FixedStrings: !cfg.Regex, RegexEngine: cfg.RegexEngine}
+	options := cfg.WordOptions[word]
+	if options.CaseSensitive != nil {
+		group.CaseSensitive = *options.CaseSensitive
+	}
+	if options.WordBoundary != nil {
+		group.WordBoundary = *options.WordBoundary
+	}
+	if options.Regex != nil {
+		group.FixedStrings = !*options.Regex
+	}
+	return group
+}
+
+// searchWordGroups groups the search words which are matched with the same options, in the order of their first
+// search word. Without overrides there is one group with all search words.
+func (cfg Config) searchWordGroups() []searchWordGroup {
+	var groups []searchWordGroup
+	for _, word := range cfg.SearchWords {
+		wordGroup := cfg.wordGroup(word)
+		found := false
+		for i, group := range groups {
+			if group.CaseSensitive == wordGroup.CaseSensitive && group.WordBoundary == wordGroup.WordBoundary && group.FixedStrings == wordGroup.FixedStrings {
+				groups[i].Words = append(groups[i].Words, word)
+				found = true
+				break
+			}
+		}
+		if !found {
+			wordGroup.Words = []string{word}
+			groups = append(groups, wordGroup)
+		}
+	}
+	return groups
+}
+
+// options returns the grep options to search the group with
+func (group searchWordGroup) options(opts grepOptions) grepOptions {
+	opts.CaseSensitive = group.CaseSensitive
+	opts.WordBoundary = group.WordBoundary
+	opts.FixedStrings = group.FixedStrings
+	opts.RegexEngine = group.RegexEngine
+	return opts
+}
+
+// searchGroups searches every group of search words, grep can only match all search words with the same options,
+// and merges the results
+func searchGroups(groups []searchWordGroup, search func(group searchWordGroup) ([]GrepResult, error)) ([]GrepResult, error) {
+	var result []GrepResult
+	for i, group := range groups {
+		grs, err := search(group)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			result = grs
+		} else {
+			result = mergeResults(result, grs)
+		}
+	}
+	return result, nil
+}
+
+// mergeResults adds the counts of the results of the same files together, the files which are only in other are
+// appended
+func mergeResults(grs, other []GrepResult) []GrepResult {
+	index := make(map[string]int, len(grs))
+	for i, gr := range grs {
+		index[gr.FileName] = i
+	}
+	for _, gr := range other {
+		i, ok := index[gr.FileName]
+		if !ok {
+			index[gr.FileName] = len(grs)
+			grs = append(grs, gr)
+			continue
+		}
+		grs[i].Count += gr.Count
+		grs[i].Truncated = grs[i].Truncated || gr.Truncated
+		if len(gr.Words) > 0 && grs[i].Words == nil {
+			grs[i].Words = make(map[string]int)
+		}
+		for word, count := range gr.Words {
+			grs[i].Words[word] += count
+		}
+	}
+	return grs
+}
+
+// quoteSearchWords escapes the regexp metacharacters of the search words when they are fixed strings
+func quoteSearchWords(searchWords []string, fixedStrings bool) []string {
+	if !fixedStrings {
+		return searchWords
+	}
+	quoted := make([]string, len(searchWords))
+	for i, word := range searchWords {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+	return quoted
+}
+
+// compileSearchWordGroups compiles the search words of all groups into one regexp matching any of them, each with the
+// options of its group
+func compileSearchWordGroups(groups []searchWordGroup) (*regexp.Regexp, error) {
+	var alternatives []string
+	for _, group := range groups {
+		for _, word := range quoteSearchWords(group.Words, group.FixedStrings) {
+			alternative := "(?:" + word + ")"
+			if !group.CaseSensitive {
+				alternative = "(?i:" + word + ")"
+			}
+			if group.WordBoundary {
+				alternative = `\b` + alternative + `\b`
+			}
+			alternatives = append(alternatives, alternative)
+		}
+	}
+	return regexp.Compile(strings.Join(alternatives, "|"))
+}
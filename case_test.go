@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnmarshalSearchWords(t *testing.T) {
+	is := IS.New(t)
+	var cfg Config
+
+	err := json.Unmarshal([]byte(`{"search_words": ["fell", {"pattern": "Foo", "case_sensitive": true}, {"pattern": "bar"}], "case_sensitive": false}`), &cfg)
+
+	is.NoErr(err)
+	is.Equal([]string{"fell", "Foo", "bar"}, cfg.SearchWords)
+	caseSensitive := true
+	is.Equal(map[string]SearchWord{"Foo": {Pattern: "Foo", CaseSensitive: &caseSensitive}}, cfg.WordOptions)
+	is.True(json.Unmarshal([]byte(`{"search_words": [{"case_sensitive": true}]}`), &cfg) != nil)
+}
+
+func TestSearchWordGroups(t *testing.T) {
+	is := IS.New(t)
+	yes, no := true, false
+	cfg := Config{SearchWords: []string{"fell", "Foo", "bar"}, Regex: true, WordOptions: map[string]SearchWord{"Foo": {CaseSensitive: &yes}, "bar": {CaseSensitive: &no}}}
+
+	is.Equal([]searchWordGroup{{Words: []string{"fell", "bar"}}, {Words: []string{"Foo"}, CaseSensitive: true}}, cfg.searchWordGroups())
+	cfg.CaseSensitive = true
+	is.Equal([]searchWordGroup{{Words: []string{"fell", "Foo"}, CaseSensitive: true}, {Words: []string{"bar"}}}, cfg.searchWordGroups())
+	is.Equal([]searchWordGroup{{Words: []string{"fell"}}}, Config{SearchWords: []string{"fell"}, Regex: true}.searchWordGroups())
+}
+
+func TestSearchWordGroupsRegex(t *testing.T) {
+	is := IS.New(t)
+	yes := true
+	cfg := Config{SearchWords: []string{"foo.bar()", "use[rs]*"}, WordOptions: map[string]SearchWord{"use[rs]*": {Regex: &yes}}}
+
+	is.Equal([]searchWordGroup{{Words: []string{"foo.bar()"}, FixedStrings: true}, {Words: []string{"use[rs]*"}}}, cfg.searchWordGroups())
+}
+
+func TestAnalyzePathFixedStrings(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("foo.bar() fooXbar() $ref users\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	yes := true
+	for _, backend := range []string{SearchBackendGrep, SearchBackendNative} {
+		t.Run(backend, func(t *testing.T) {
+			is := IS.New(t)
+			cfg := Config{
+				SearchWords:   []string{"foo.bar()", "$ref", "use[rs]*"},
+				WordOptions:   map[string]SearchWord{"use[rs]*": {Pattern: "use[rs]*", Regex: &yes}},
+				SearchBackend: backend,
+			}
+
+			app, err := analyzePath(context.Background(), Repository{Name: "repo"}, cfg, dir)
+
+			is.NoErr(err)
+			is.Equal(map[string]int{"foo.bar()": 1, "$ref": 1, "use[rs]*": 1}, app.WordCounts)
+		})
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	is := IS.New(t)
+	grs := []GrepResult{{FileName: "a.go", Count: 2, Words: map[string]int{"fell": 2}}, {FileName: "b.go", Count: 1, Words: map[string]int{"fell": 1}}}
+	other := []GrepResult{{FileName: "b.go", Count: 3, Words: map[string]int{"Foo": 3}}, {FileName: "c.go", Count: 1, Words: map[string]int{"Foo": 1}}}
+
+	is.Equal([]GrepResult{
+		{FileName: "a.go", Count: 2, Words: map[string]int{"fell": 2}},
+		{FileName: "b.go", Count: 4, Words: map[string]int{"fell": 1, "Foo": 3}},
+		{FileName: "c.go", Count: 1, Words: map[string]int{"Foo": 1}},
+	}, mergeResults(grs, other))
+}
+
+func TestAnalyzePathCaseSensitiveWords(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("Foo foo FOO\nfell FELL\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	caseSensitive := true
+	for _, backend := range []string{SearchBackendGrep, SearchBackendNative} {
+		t.Run(backend, func(t *testing.T) {
+			is := IS.New(t)
+			cfg := Config{
+				SearchWords:    []string{"fell", "Foo"},
+				WordOptions:    map[string]SearchWord{"Foo": {Pattern: "Foo", CaseSensitive: &caseSensitive}},
+				SearchBackend:  backend,
+				IncludeMatches: true,
+			}
+
+			app, err := analyzePath(context.Background(), Repository{Name: "repo"}, cfg, dir)
+
+			is.NoErr(err)
+			is.Equal(map[string]int{"fell": 2, "Foo": 1}, app.WordCounts)
+			is.Equal(3, app.CountSum)
+			is.Equal(3, len(app.GrepResults[0].Matches))
+		})
+	}
+}
+
+func TestAnalyzePathWordBoundary(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "auth.go"), []byte("auth author oauth auth_token AUTH\nuse user\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	no := false
+	for _, backend := range []string{SearchBackendGrep, SearchBackendNative} {
+		t.Run(backend, func(t *testing.T) {
+			is := IS.New(t)
+			cfg := Config{
+				SearchWords:   []string{"auth", "use"},
+				WordBoundary:  true,
+				WordOptions:   map[string]SearchWord{"use": {Pattern: "use", WordBoundary: &no}},
+				SearchBackend: backend,
+			}
+
+			app, err := analyzePath(context.Background(), Repository{Name: "repo"}, cfg, dir)
+
+			is.NoErr(err)
+			is.Equal(map[string]int{"auth": 2, "use": 2}, app.WordCounts)
+		})
+	}
+}
+
+func TestCompileSearchWordGroups(t *testing.T) {
+	is := IS.New(t)
+
+	re, err := compileSearchWordGroups([]searchWordGroup{{Words: []string{"auth"}, WordBoundary: true}, {Words: []string{"Use"}, CaseSensitive: true}})
+
+	is.NoErr(err)
+	is.Equal([]string{"AUTH", "Use"}, re.FindAllString("AUTH author Use use oauth", -1))
+}
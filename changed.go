@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// changedFiles returns the files changed between the ref and HEAD in the repo at path, deleted files are left out
+func changedFiles(ctx context.Context, gitBinary, path, ref string) ([]string, error) {
+	diffCmd := gitDiffCommand(ctx, gitBinary, path, ref)
+	logCommand(diffCmd.Args)
+	out, err := diffCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list files changed since '%s': %w", ref, err)
+	}
+	return parseNameOnly(string(out)), nil
+}
+
+func gitDiffCommand(ctx context.Context, gitBinary, path, ref string) *exec.Cmd {
+	return exec.CommandContext(ctx, gitBinary, "-C", path, "diff", "--name-only", "--diff-filter=d", ref+"...HEAD")
+}
+
+// parseNameOnly parses the output of 'git diff --name-only' which is one file per line
+func parseNameOnly(out string) []string {
+	var result []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// filterExcludedDirs removes the files which are inside one of the excluded dirs.
+// grep does not apply --exclude-dir to the parent dirs of the files given to it, so they are filtered here instead.
+func filterExcludedDirs(files, excludeDirs []string) []string {
+	var result []string
+	for _, file := range files {
+		if !inExcludedDir(file, excludeDirs) {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// inExcludedDir reports whether one of the parent dirs of the file is excluded
+func inExcludedDir(file string, excludeDirs []string) bool {
+	dir := path.Dir(file)
+	for dir != "." && dir != "/" {
+		if excludedDir(excludeDirs, dir) {
+			return true
+		}
+		dir = path.Dir(dir)
+	}
+	return false
+}
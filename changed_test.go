@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"testing"
+)
+
+func TestGitDiffCommand(t *testing.T) {
+	is := IS.New(t)
+
+	cmd := gitDiffCommand(context.Background(), DefaultGitBinary, "/tmp/clone", "origin/main")
+
+	is.Equal([]string{"git", "-C", "/tmp/clone", "diff", "--name-only", "--diff-filter=d", "origin/main...HEAD"}, cmd.Args)
+}
+
+func TestParseNameOnly(t *testing.T) {
+	is := IS.New(t)
+
+	files := parseNameOnly("main.go\ninternal/handlers/user.go\n\n")
+
+	is.Equal([]string{"main.go", "internal/handlers/user.go"}, files)
+}
+
+func TestFilterExcludedDirs(t *testing.T) {
+	is := IS.New(t)
+
+	files := filterExcludedDirs([]string{"main.go", "node_modules/lib/index.js", "web/build/app.js", "web/src/app.js"}, []string{"node_modules", "build"})
+
+	is.Equal([]string{"main.go", "web/src/app.js"}, files)
+}
+
+func TestGrepRestrictedToPaths(t *testing.T) {
+	is := IS.New(t)
+
+	result, err := grep(context.Background(), "./testdata", []string{"fell"}, grepOptions{Paths: []string{"testdata_1.txt"}})
+
+	is.NoErr(err)
+	is.Equal(1, len(result))
+	is.Equal("testdata_1.txt", result[0].FileName)
+	is.Equal(2, result[0].Count)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CommandRun searches the repositories of the config, it is the command when none is given
+const CommandRun = "run"
+
+// cli runs the command given by the command line arguments, without the program name, and returns the exit code.
+// The command is the first argument unless it is a flag, so 'run' can be left out.
+func cli(args []string, analyze analyzeFunc, output io.Writer) int {
+	command := CommandRun
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	switch command {
+	case CommandRun:
+		opts, err := parseRunFlags(args, output)
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitCodeSuccess
+		}
+		if err != nil {
+			return ExitCodeConfigError
+		}
+		if err := setupLogging(opts, output); err != nil {
+			return ExitCodeConfigError
+		}
+		opts.Stdout = os.Stdout
+		if !opts.Quiet {
+			opts.Progress = os.Stderr
+		}
+		return run(opts, analyze)
+	case CommandDiff:
+		return runDiff(args, output)
+	case CommandMerge:
+		return runMerge(args, output)
+	case CommandBaseline:
+		opts, err := parseBaselineFlags(args, output)
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitCodeSuccess
+		}
+		if err != nil {
+			return ExitCodeConfigError
+		}
+		if err := setupLogging(opts, output); err != nil {
+			return ExitCodeConfigError
+		}
+		if !opts.Quiet {
+			opts.Progress = os.Stderr
+		}
+		return run(opts, analyze)
+	default:
+		fmt.Fprintf(output, "unknown command '%s', the commands are: %s, %s, %s, %s\n", command, CommandRun, CommandBaseline, CommandDiff, CommandMerge)
+		return ExitCodeConfigError
+	}
+}
+
+// parseRunFlags parses the flags of the run command, the parse errors and the usage are written to output
+func parseRunFlags(args []string, output io.Writer) (options, error) {
+	opts := options{Top: DefaultTop}
+	flags := flag.NewFlagSet(CommandRun, flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&opts.ConfigPath, "config", "", "config file, JSON or YAML (.yaml, .yml), defaults to the first of "+strings.Join(ConfigFilePaths, ", ")+" which exists")
+	opts.ResultPath = ResultFilePath
+	outputSet := false
+	flags.Func("output", "file the result is saved to (default "+ResultFilePath+"), can be given more than once to save the result to several files in the format of their extension", func(value string) error {
+		if outputSet {
+			opts.ExtraOutputs = append(opts.ExtraOutputs, value)
+		} else {
+			opts.ResultPath, outputSet = value, true
+		}
+		return nil
+	})
+	flags.StringVar(&opts.Format, "format", FormatJSON, "format of the result file, "+FormatJSON+", "+FormatNDJSON+" ("+FormatJSONL+"), "+FormatSARIF+", "+FormatJUnit+", "+FormatCSV+", "+FormatHTML+", "+FormatMarkdown+", "+FormatXLSX+" or "+FormatTemplate)
+	flags.StringVar(&opts.TemplatePath, "template", "", "Go text/template file the result is rendered with in the "+FormatTemplate+" format")
+	flags.StringVar(&opts.Dir, "dir", "", "search this dir instead of the repositories in the config")
+	flags.StringVar(&opts.CacheDir, "cache-dir", "", "keep the clones in this dir and update them on the next run instead of cloning again, overrides cache_dir of the config")
+	flags.BoolVar(&opts.WithLines, "with-lines", false, "save the line number and the text of the line of every match, the same as include_matches of the config")
+	flags.StringVar(&opts.CompareTo, "compare-to", "", "previous result file, the files which count increased since are listed in new_matches")
+	flags.Func("fail-if-total-above", "exit with code 6 when the total count sum is above this number", func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return errors.New("must be a number of at least 0")
+		}
+		opts.FailIfTotalAbove = &n
+		return nil
+	})
+	flags.BoolVar(&opts.FailIfAnyMatch, "fail-if-any-match", false, "exit with code 6 when any search word is found")
+	flags.Func("top", fmt.Sprintf("number of applications in the summary table and the table of the %s format, 0 for all of them (default %d)", FormatMarkdown, DefaultTop), func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return errors.New("must be a number of at least 0")
+		}
+		opts.Top = n
+		return nil
+	})
+	flags.IntVar(&opts.MaxConcurrency, "max-concurrency", 0, "search at most this many repositories at the same time, overrides max_concurrency of the config")
+	addLogFlags(flags, &opts)
+	err := parseFlags(flags, args, &opts, output)
+	return opts, err
+}
+
+// parseBaselineFlags parses the flags of the baseline command, the parse errors and the usage are written to output
+func parseBaselineFlags(args []string, output io.Writer) (options, error) {
+	opts := options{Format: FormatJSON, Baseline: true}
+	flags := flag.NewFlagSet(CommandBaseline, flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&opts.ConfigPath, "config", "", "config file, JSON or YAML (.yaml, .yml), defaults to the first of "+strings.Join(ConfigFilePaths, ", ")+" which exists")
+	flags.StringVar(&opts.ResultPath, "output", BaselineFilePath, "file the baseline is saved to")
+	flags.StringVar(&opts.Dir, "dir", "", "search this dir instead of the repositories in the config")
+	flags.StringVar(&opts.CacheDir, "cache-dir", "", "keep the clones in this dir and update them on the next run instead of cloning again, overrides cache_dir of the config")
+	flags.IntVar(&opts.MaxConcurrency, "max-concurrency", 0, "search at most this many repositories at the same time, overrides max_concurrency of the config")
+	addLogFlags(flags, &opts)
+	err := parseFlags(flags, args, &opts, output)
+	return opts, err
+}
+
+// addLogFlags registers the flags of the log, which are set up by setupLogging
+func addLogFlags(flags *flag.FlagSet, opts *options) {
+	flags.StringVar(&opts.LogLevel, "log-level", "info", "lowest level which is logged, debug, info, warn or error")
+	flags.StringVar(&opts.LogFormat, "log-format", LogFormatText, "format of the log on stderr, "+LogFormatText+" or "+LogFormatJSON)
+	flags.BoolVar(&opts.Quiet, "quiet", false, "only log warnings and errors, e.g. not the commands which are run, and do not show the progress")
+}
+
+// setupLogging makes the logger of the log flags the default logger, an invalid flag is written to output
+func setupLogging(opts options, output io.Writer) error {
+	level, err := logLevel(opts.LogLevel, opts.Quiet)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		return err
+	}
+	logger, err := newLogger(os.Stderr, level, opts.LogFormat)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// parseFlags parses the flags into opts, which are registered on flags, and finds the config file when none is given
+func parseFlags(flags *flag.FlagSet, args []string, opts *options, output io.Writer) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		err := fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+		fmt.Fprintln(output, err)
+		return err
+	}
+	if opts.ConfigPath == "" {
+		opts.ConfigPath = findConfigFile()
+	}
+	return nil
+}
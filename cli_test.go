@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRunFlags(t *testing.T) {
+	is := IS.New(t)
+	var output bytes.Buffer
+
+	opts, err := parseRunFlags([]string{"-config", "team-a.yaml", "--output", "team-a.json", "-format", FormatNDJSON, "-cache-dir", "/var/cache/count-fell", "-with-lines"}, &output)
+
+	is.NoErr(err)
+	is.Equal(options{ConfigPath: "team-a.yaml", ResultPath: "team-a.json", Format: FormatNDJSON, CacheDir: "/var/cache/count-fell", WithLines: true, Top: DefaultTop, LogLevel: "info", LogFormat: LogFormatText}, opts)
+}
+
+func TestParseRunFlagsDefaults(t *testing.T) {
+	is := IS.New(t)
+	var output bytes.Buffer
+
+	opts, err := parseRunFlags(nil, &output)
+
+	is.NoErr(err)
+	is.Equal(ResultFilePath, opts.ResultPath)
+	is.Equal(FormatJSON, opts.Format)
+	is.True(opts.ConfigPath != "")
+	is.Equal(DefaultTop, opts.Top)
+}
+
+func TestParseRunFlagsOutputs(t *testing.T) {
+	is := IS.New(t)
+	var output bytes.Buffer
+
+	opts, err := parseRunFlags([]string{"-output", "results.json", "-output", "report.html", "-output", "summary.md"}, &output)
+
+	is.NoErr(err)
+	is.Equal("results.json", opts.ResultPath)
+	is.Equal([]string{"report.html", "summary.md"}, opts.ExtraOutputs)
+}
+
+func TestParseRunFlagsThresholds(t *testing.T) {
+	is := IS.New(t)
+	var output bytes.Buffer
+
+	opts, err := parseRunFlags([]string{"-fail-if-total-above", "10", "-fail-if-any-match"}, &output)
+
+	is.NoErr(err)
+	is.Equal(10, *opts.FailIfTotalAbove)
+	is.True(opts.FailIfAnyMatch)
+	_, err = parseRunFlags([]string{"-fail-if-total-above", "-1"}, &output)
+	is.True(err != nil)
+	_, err = parseRunFlags([]string{"-top", "-1"}, &output)
+	is.True(err != nil)
+}
+
+func TestExceedsThreshold(t *testing.T) {
+	is := IS.New(t)
+	ten := 10
+
+	is.True(!exceedsThreshold(options{}, 100))
+	is.True(!exceedsThreshold(options{FailIfTotalAbove: &ten}, 10))
+	is.True(exceedsThreshold(options{FailIfTotalAbove: &ten}, 11))
+	is.True(!exceedsThreshold(options{FailIfAnyMatch: true}, 0))
+	is.True(exceedsThreshold(options{FailIfAnyMatch: true}, 1))
+}
+
+func TestCli(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	resultPath := filepath.Join(dir, "other-results.json")
+	if err := os.WriteFile(configPath, []byte(`{"search_words": ["fell"], "repositories": [{"name": "a", "url": "a.git"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	analyze := func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		return Application{Name: r.Name, Status: StatusOK}, nil
+	}
+	testCases := []struct {
+		name     string
+		args     []string
+		exitCode int
+	}{
+		{"run", []string{"run", "-config", configPath, "-output", resultPath}, ExitCodeSuccess},
+		{"run without command", []string{"-config", configPath, "-output", resultPath}, ExitCodeSuccess},
+		{"help", []string{"run", "-h"}, ExitCodeSuccess},
+		{"unknown flag", []string{"run", "-unknown"}, ExitCodeConfigError},
+		{"unexpected argument", []string{"run", "-config", configPath, "extra"}, ExitCodeConfigError},
+		{"unknown command", []string{"serve"}, ExitCodeConfigError},
+		{"unknown log level", []string{"run", "-config", configPath, "-log-level", "verbose"}, ExitCodeConfigError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := IS.New(t)
+			var output bytes.Buffer
+
+			is.Equal(tc.exitCode, cli(tc.args, analyze, &output))
+		})
+	}
+	if _, err := os.Stat(resultPath); err != nil {
+		t.Fatal("the result is not saved to -output: ", err)
+	}
+}
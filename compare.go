@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// NewMatch is a file which has more matches than in the result it is compared to
+type NewMatch struct {
+	Application   string `json:"application"`
+	FileName      string `json:"file_name"`
+	PreviousCount int    `json:"previous_count"`
+	Count         int    `json:"count"`
+	// Words is the increase of the count per search word
+	Words map[string]int `json:"words,omitempty"`
+}
+
+// readResultFile reads a result file, from a result file written in append mode the latest snapshot is read
+func readResultFile(fileName string) (ResultFile, error) {
+	var result ResultFile
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return result, err
+	}
+	var snapshots []Snapshot
+	if err := json.Unmarshal(content, &snapshots); err == nil {
+		if len(snapshots) == 0 {
+			return result, fmt.Errorf("'%s' has no snapshots", fileName)
+		}
+		return snapshots[len(snapshots)-1].Result, nil
+	}
+	if err := json.Unmarshal(content, &result); err != nil {
+		if isNDJSON(content) {
+			return result, fmt.Errorf("'%s' is in the %s format, only a result file in the %s format can be read", fileName, FormatNDJSON, FormatJSON)
+		}
+		return result, fmt.Errorf("'%s' is not a result file: %w", fileName, err)
+	}
+	return result, nil
+}
+
+// isNDJSON reports whether the content is more than one JSON value, like the lines of the ndjson format
+func isNDJSON(content []byte) bool {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	var first json.RawMessage
+	return decoder.Decode(&first) == nil && decoder.More()
+}
+
+// validateCompareTo checks that the previous result has the results per file which are compared, which a result
+// written with summary_only does not have
+func validateCompareTo(fileName string, previous ResultFile) error {
+	for _, app := range previous.Applications {
+		if app.CountSum > 0 && len(app.GrepResults) == 0 {
+			return fmt.Errorf("'%s' has no results per file to compare to, it was written with summary_only", fileName)
+		}
+	}
+	return nil
+}
+
+// compareResults lists the files which count increased since the previous result, sorted on application and file
+// name. A file which is not in the previous result has a previous count of 0, the failed applications are left out.
+func compareResults(previous, current ResultFile) []NewMatch {
+	previousFiles := make(map[string]map[string]GrepResult)
+	for _, app := range previous.Applications {
+		files := make(map[string]GrepResult)
+		for _, gr := range app.GrepResults {
+			files[gr.FileName] = gr
+		}
+		previousFiles[app.Name] = files
+	}
+
+	var result []NewMatch
+	for _, app := range current.Applications {
+		if app.Status == StatusFailed {
+			continue
+		}
+		for _, gr := range app.GrepResults {
+			before := previousFiles[app.Name][gr.FileName]
+			if gr.Count <= before.Count {
+				continue
+			}
+			match := NewMatch{Application: app.Name, FileName: gr.FileName, PreviousCount: before.Count, Count: gr.Count}
+			for word, count := range gr.Words {
+				if increase := count - before.Words[word]; increase > 0 {
+					if match.Words == nil {
+						match.Words = make(map[string]int)
+					}
+					match.Words[word] = increase
+				}
+			}
+			result = append(result, match)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Application != result[j].Application {
+			return result[i].Application < result[j].Application
+		}
+		return result[i].FileName < result[j].FileName
+	})
+	return result
+}
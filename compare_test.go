@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompareResults(t *testing.T) {
+	is := IS.New(t)
+	previous := ResultFile{Applications: []Application{
+		{Name: "api", GrepResults: []GrepResult{
+			{FileName: "main.go", Count: 2, Words: map[string]int{"oldAPI": 2}},
+			{FileName: "util.go", Count: 3, Words: map[string]int{"oldAPI": 3}},
+		}},
+	}}
+	current := ResultFile{Applications: []Application{
+		{Name: "web", Status: StatusOK, GrepResults: []GrepResult{{FileName: "index.js", Count: 1, Words: map[string]int{"legacy": 1}}}},
+		{Name: "api", Status: StatusOK, GrepResults: []GrepResult{
+			{FileName: "util.go", Count: 1, Words: map[string]int{"oldAPI": 1}},
+			{FileName: "main.go", Count: 4, Words: map[string]int{"oldAPI": 2, "legacy": 2}},
+		}},
+		{Name: "broken", Status: StatusFailed},
+	}}
+
+	is.Equal([]NewMatch{
+		{Application: "api", FileName: "main.go", PreviousCount: 2, Count: 4, Words: map[string]int{"legacy": 2}},
+		{Application: "web", FileName: "index.js", Count: 1, Words: map[string]int{"legacy": 1}},
+	}, compareResults(previous, current))
+}
+
+func TestReadResultFile(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	resultPath := filepath.Join(dir, "results.json")
+	appendPath := filepath.Join(dir, "snapshots.json")
+	is.NoErr(writeResult(resultPath, ResultFile{TotalCountSum: 3}, 0644))
+	is.NoErr(appendResult(appendPath, ResultFile{TotalCountSum: 1}, time.Now(), 0644))
+	is.NoErr(appendResult(appendPath, ResultFile{TotalCountSum: 2}, time.Now(), 0644))
+
+	result, err := readResultFile(resultPath)
+	is.NoErr(err)
+	is.Equal(3, result.TotalCountSum)
+
+	result, err = readResultFile(appendPath)
+	is.NoErr(err)
+	is.Equal(2, result.TotalCountSum) // the latest snapshot
+
+	is.NoErr(os.WriteFile(resultPath, []byte("[]"), 0644))
+	_, err = readResultFile(resultPath)
+	is.True(err != nil)
+}
+
+func TestRunCompareTo(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	previousPath := filepath.Join(dir, "previous.json")
+	resultPath := filepath.Join(dir, "results.json")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": ["fell"]}`), 0644))
+	is.NoErr(writeResult(previousPath, ResultFile{Applications: []Application{
+		{Name: "testdata", GrepResults: []GrepResult{{FileName: "testdata_1.txt", Count: 2, Words: map[string]int{"fell": 2}}}},
+	}}, 0644))
+
+	is.Equal(ExitCodeSuccess, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON, Dir: "./testdata", CompareTo: previousPath}, nil))
+
+	content, err := os.ReadFile(resultPath)
+	is.NoErr(err)
+	var result ResultFile
+	is.NoErr(json.Unmarshal(content, &result))
+	for _, match := range result.NewMatches {
+		is.True(match.FileName != "testdata_1.txt") // the count did not increase
+	}
+	is.True(len(result.NewMatches) > 0)
+}
+
+func TestRunCompareToWithoutFileResults(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": ["fell"]}`), 0644))
+	summaryPath := filepath.Join(dir, "summary.json")
+	is.NoErr(writeResult(summaryPath, ResultFile{Applications: []Application{{Name: "testdata", CountSum: 2}}}, 0644))
+	ndjsonPath := filepath.Join(dir, "previous.ndjson")
+	is.NoErr(os.WriteFile(ndjsonPath, []byte("{\"name\": \"testdata\", \"count_sum\": 2}\n{\"total_count_sum\": 2}\n"), 0644))
+
+	for _, previousPath := range []string{summaryPath, ndjsonPath} {
+		resultPath := filepath.Join(dir, "results.json")
+		is.Equal(ExitCodeConfigError, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON, Dir: "./testdata", CompareTo: previousPath}, nil))
+	}
+	_, err := readResultFile(ndjsonPath)
+	is.Equal("'"+ndjsonPath+"' is in the ndjson format, only a result file in the json format can be read", err.Error())
+}
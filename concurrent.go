@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+// grepConcurrent splits the search of path on its top level entries and greps them with at most concurrency grep processes at a time.
+// The result is the same as when grepping the whole path at once.
+func grepConcurrent(ctx context.Context, path string, searchWords []string, opts grepOptions, concurrency int) ([]GrepResult, error) {
+	if concurrency <= 1 || len(opts.Paths) > 0 {
+		return grep(ctx, path, searchWords, opts)
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		results  = []GrepResult{}
+		sem      = make(chan struct{}, concurrency)
+	)
+	for _, entry := range entries {
+		// symlinks given on the command line are followed by grep, which a recursive search of path would not do
+		if entry.Type()&os.ModeSymlink != 0 && !opts.FollowSymlinks {
+			continue
+		}
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			entryOpts := opts
+			entryOpts.Paths = []string{name}
+			grs, err := grep(ctx, path, searchWords, entryOpts)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			results = append(results, grs...)
+		}(entry.Name())
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// eachConcurrent calls do for every index below n, with at most concurrency calls at a time from a fixed pool of
+// workers. After the first error no more calls are started, the context of the running calls is cancelled and the
+// error is returned.
+func eachConcurrent(ctx context.Context, n, concurrency int, do func(ctx context.Context, i int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for i := 0; i < n; i++ {
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := do(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return parent.Err()
+}
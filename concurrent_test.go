@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	IS "github.com/matryer/is"
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGrepConcurrentMatchesSequential(t *testing.T) {
+	is := IS.New(t)
+	sequential, err := grep(context.Background(), "./testdata", []string{"fell", "needle"}, grepOptions{})
+	is.NoErr(err)
+	sortOnFileName(sequential)
+
+	for _, concurrency := range []int{0, 1, 2, 8} {
+		result, err := grepConcurrent(context.Background(), "./testdata", []string{"fell", "needle"}, grepOptions{}, concurrency)
+		is.NoErr(err)
+		sortOnFileName(result)
+
+		is.Equal(sequential, result) // concurrency: concurrency
+	}
+}
+
+func sortOnFileName(grs []GrepResult) {
+	sort.Slice(grs, func(i, j int) bool {
+		return grs[i].FileName < grs[j].FileName
+	})
+}
+
+func TestSearchNativeConcurrentMatchesSequential(t *testing.T) {
+	is := IS.New(t)
+	sequential, err := searchNative(context.Background(), "./testdata", []string{"fell", "needle"}, grepOptions{}, 1)
+	is.NoErr(err)
+
+	result, err := searchNative(context.Background(), "./testdata", []string{"fell", "needle"}, grepOptions{}, 4)
+	is.NoErr(err)
+
+	is.Equal(sequential, result) // the results are in the order of the files
+}
+
+func TestEachConcurrentStopsAtFirstError(t *testing.T) {
+	is := IS.New(t)
+	failed := errors.New("failed")
+	var calls atomic.Int32
+
+	err := eachConcurrent(context.Background(), 1000, 2, func(ctx context.Context, i int) error {
+		calls.Add(1)
+		if i == 0 {
+			return failed
+		}
+		<-ctx.Done() // the other running call is cancelled
+		return ctx.Err()
+	})
+
+	is.Equal(failed, err)
+	is.True(calls.Load() <= 3) // no more calls are started after the error
+}
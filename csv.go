@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const FormatCSV = "csv"
+
+// csvHeader is the first row of a result file in the csv format
+var csvHeader = []string{"application", "file_name", "word", "count"}
+
+// csvRows returns one row per application, file and search word. A file without counts per search word, like a file
+// counted by a matcher_command, is one row with an empty word.
+func csvRows(rf ResultFile) [][]string {
+	rows := [][]string{csvHeader}
+	for _, app := range rf.Applications {
+		for _, gr := range app.GrepResults {
+			if len(gr.Words) == 0 {
+				rows = append(rows, []string{csvCell(app.Name), csvCell(gr.FileName), "", strconv.Itoa(gr.Count)})
+				continue
+			}
+			words := make([]string, 0, len(gr.Words))
+			for word := range gr.Words {
+				words = append(words, word)
+			}
+			sort.Strings(words)
+			for _, word := range words {
+				rows = append(rows, []string{csvCell(app.Name), csvCell(gr.FileName), csvCell(word), strconv.Itoa(gr.Words[word])})
+			}
+		}
+	}
+	return rows
+}
+
+// csvCell prefixes text starting with '=', '+', '-' or '@' with a quote, so a spreadsheet opening the file does not
+// evaluate a file name or a search word as a formula
+func csvCell(text string) string {
+	if text != "" && strings.ContainsRune("=+-@", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+func writeCSV(fileName string, rf ResultFile, perm os.FileMode) error {
+	return writeFileAtomic(fileName, perm, func(w io.Writer) error {
+		return csv.NewWriter(w).WriteAll(csvRows(rf))
+	})
+}
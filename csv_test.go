@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/csv"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCSVRows(t *testing.T) {
+	is := IS.New(t)
+	rf := ResultFile{Applications: []Application{
+		{Name: "a", GrepResults: []GrepResult{{FileName: "main.go", Count: 3, Words: map[string]int{"todo": 1, "fell": 2}}}},
+		{Name: "b", GrepResults: []GrepResult{{FileName: "matched.txt", Count: 4}}},
+	}}
+
+	is.Equal([][]string{
+		csvHeader,
+		{"a", "main.go", "fell", "2"},
+		{"a", "main.go", "todo", "1"},
+		{"b", "matched.txt", "", "4"},
+	}, csvRows(rf))
+}
+
+func TestCSVRowsEscapeFormulas(t *testing.T) {
+	is := IS.New(t)
+	rf := ResultFile{Applications: []Application{
+		{Name: "@app", GrepResults: []GrepResult{{FileName: "=cmd|' /C calc'!A0", Count: 1, Words: map[string]int{"-fell": 1}}}},
+		{Name: "b", GrepResults: []GrepResult{{FileName: "+1.txt", Count: 2}}},
+	}}
+
+	is.Equal([][]string{
+		csvHeader,
+		{"'@app", "'=cmd|' /C calc'!A0", "'-fell", "1"},
+		{"b", "'+1.txt", "", "2"},
+	}, csvRows(rf))
+}
+
+func TestRunCSV(t *testing.T) {
+	is := IS.New(t)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": ["fell"]}`), 0644))
+	resultPath := filepath.Join(t.TempDir(), "results.csv")
+
+	is.Equal(ExitCodeSuccess, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatCSV, Dir: "./testdata"}, nil))
+
+	file, err := os.Open(resultPath)
+	is.NoErr(err)
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	is.NoErr(err)
+	is.Equal(csvHeader, rows[0])
+	is.True(len(rows) > 1)
+	is.True(validateFormat(FormatCSV, Config{AppendMode: true}) != nil)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	// CommandDiff compares two result files
+	CommandDiff  = "diff"
+	DiffFilePath = "diff.json"
+
+	DiffStatusAdded   = "added"
+	DiffStatusRemoved = "removed"
+	DiffStatusChanged = "changed"
+)
+
+// ResultDiff are the applications and files which count changed between two result files
+type ResultDiff struct {
+	PreviousTotalCountSum int               `json:"previous_total_count_sum"`
+	TotalCountSum         int               `json:"total_count_sum"`
+	Applications          []ApplicationDiff `json:"applications"`
+}
+
+// ApplicationDiff is an application which was added, removed or which count changed
+type ApplicationDiff struct {
+	Name          string     `json:"name"`
+	Status        string     `json:"status"`
+	PreviousCount int        `json:"previous_count"`
+	Count         int        `json:"count"`
+	Files         []FileDiff `json:"files,omitempty"`
+}
+
+// FileDiff is a file with matches which was added, removed or which count changed
+type FileDiff struct {
+	FileName      string `json:"file_name"`
+	Status        string `json:"status"`
+	PreviousCount int    `json:"previous_count"`
+	Count         int    `json:"count"`
+}
+
+// runDiff compares the two result files given as arguments, saves the diff to -output and writes a summary of the
+// changes to output
+func runDiff(args []string, output io.Writer) int {
+	flags := flag.NewFlagSet(CommandDiff, flag.ContinueOnError)
+	flags.SetOutput(output)
+	resultPath := flags.String("output", DiffFilePath, "file the diff is saved to")
+	flags.Usage = func() {
+		fmt.Fprintf(output, "usage: %s [-output <path>] <old result> <new result>\n", CommandDiff)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitCodeSuccess
+		}
+		return ExitCodeConfigError
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return ExitCodeConfigError
+	}
+	previous, err := readResultFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(output, err)
+		return ExitCodeConfigError
+	}
+	current, err := readResultFile(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintln(output, err)
+		return ExitCodeConfigError
+	}
+
+	diff := diffResults(previous, current)
+	printDiff(output, diff)
+	data, err := json.MarshalIndent(diff, "", " ")
+	if err != nil {
+		fmt.Fprintln(output, err)
+		return ExitCodeFailure
+	}
+	err = writeFileAtomic(*resultPath, DefaultOutputFileMode, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if err != nil {
+		fmt.Fprintln(output, "unable to save diff: ", err)
+		return ExitCodeFailure
+	}
+	return ExitCodeSuccess
+}
+
+// diffResults lists the applications and files which were added, removed or which count changed, sorted on name
+func diffResults(previous, current ResultFile) ResultDiff {
+	diff := ResultDiff{PreviousTotalCountSum: previous.TotalCountSum, TotalCountSum: current.TotalCountSum, Applications: []ApplicationDiff{}}
+	previousApps := applicationsByName(previous)
+	currentApps := applicationsByName(current)
+	var names []string
+	for _, app := range append(append([]Application{}, previous.Applications...), current.Applications...) {
+		names = append(names, app.Name)
+	}
+	for _, name := range sortedUnique(names) {
+		before, hadBefore := previousApps[name]
+		after, hasAfter := currentApps[name]
+		app := ApplicationDiff{Name: name, Status: diffStatus(hadBefore, hasAfter), PreviousCount: before.CountSum, Count: after.CountSum}
+
+		previousFiles := grepResultsByName(before)
+		currentFiles := grepResultsByName(after)
+		var fileNames []string
+		for _, gr := range append(append([]GrepResult{}, before.GrepResults...), after.GrepResults...) {
+			fileNames = append(fileNames, gr.FileName)
+		}
+		for _, fileName := range sortedUnique(fileNames) {
+			beforeFile, hadFile := previousFiles[fileName]
+			afterFile, hasFile := currentFiles[fileName]
+			if hadFile && hasFile && beforeFile.Count == afterFile.Count {
+				continue
+			}
+			app.Files = append(app.Files, FileDiff{FileName: fileName, Status: diffStatus(hadFile, hasFile), PreviousCount: beforeFile.Count, Count: afterFile.Count})
+		}
+		if app.Status == DiffStatusChanged && app.PreviousCount == app.Count && len(app.Files) == 0 {
+			continue
+		}
+		diff.Applications = append(diff.Applications, app)
+	}
+	return diff
+}
+
+func diffStatus(before, after bool) string {
+	switch {
+	case !before:
+		return DiffStatusAdded
+	case !after:
+		return DiffStatusRemoved
+	default:
+		return DiffStatusChanged
+	}
+}
+
+func applicationsByName(rf ResultFile) map[string]Application {
+	result := make(map[string]Application)
+	for _, app := range rf.Applications {
+		result[app.Name] = app
+	}
+	return result
+}
+
+func grepResultsByName(app Application) map[string]GrepResult {
+	result := make(map[string]GrepResult)
+	for _, gr := range app.GrepResults {
+		result[gr.FileName] = gr
+	}
+	return result
+}
+
+// sortedUnique returns the values sorted without duplicates
+func sortedUnique(values []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// printDiff writes one line per changed application and file, added ones are prefixed with '+' and removed ones with '-'
+func printDiff(output io.Writer, diff ResultDiff) {
+	fmt.Fprintf(output, "total count sum: %d -> %d\n", diff.PreviousTotalCountSum, diff.TotalCountSum)
+	for _, app := range diff.Applications {
+		fmt.Fprintf(output, "%s %s: %d -> %d\n", diffMarker(app.Status), app.Name, app.PreviousCount, app.Count)
+		for _, file := range app.Files {
+			fmt.Fprintf(output, "  %s %s: %d -> %d\n", diffMarker(file.Status), file.FileName, file.PreviousCount, file.Count)
+		}
+	}
+}
+
+func diffMarker(status string) string {
+	switch status {
+	case DiffStatusAdded:
+		return "+"
+	case DiffStatusRemoved:
+		return "-"
+	default:
+		return "~"
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffResults(t *testing.T) {
+	is := IS.New(t)
+	previous := ResultFile{TotalCountSum: 9, Applications: []Application{
+		{Name: "api", CountSum: 5, GrepResults: []GrepResult{{FileName: "main.go", Count: 2}, {FileName: "util.go", Count: 3}}},
+		{Name: "same", CountSum: 1, GrepResults: []GrepResult{{FileName: "a.go", Count: 1}}},
+		{Name: "old", CountSum: 3, GrepResults: []GrepResult{{FileName: "b.go", Count: 3}}},
+	}}
+	current := ResultFile{TotalCountSum: 8, Applications: []Application{
+		{Name: "web", CountSum: 1, GrepResults: []GrepResult{{FileName: "index.js", Count: 1}}},
+		{Name: "same", CountSum: 1, GrepResults: []GrepResult{{FileName: "a.go", Count: 1}}},
+		{Name: "api", CountSum: 6, GrepResults: []GrepResult{{FileName: "main.go", Count: 2}, {FileName: "new.go", Count: 4}}},
+	}}
+
+	is.Equal(ResultDiff{PreviousTotalCountSum: 9, TotalCountSum: 8, Applications: []ApplicationDiff{
+		{Name: "api", Status: DiffStatusChanged, PreviousCount: 5, Count: 6, Files: []FileDiff{
+			{FileName: "new.go", Status: DiffStatusAdded, Count: 4},
+			{FileName: "util.go", Status: DiffStatusRemoved, PreviousCount: 3},
+		}},
+		{Name: "old", Status: DiffStatusRemoved, PreviousCount: 3, Files: []FileDiff{{FileName: "b.go", Status: DiffStatusRemoved, PreviousCount: 3}}},
+		{Name: "web", Status: DiffStatusAdded, Count: 1, Files: []FileDiff{{FileName: "index.js", Status: DiffStatusAdded, Count: 1}}},
+	}}, diffResults(previous, current))
+}
+
+func TestCliDiff(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.json")
+	newPath := filepath.Join(dir, "new.json")
+	diffPath := filepath.Join(dir, "diff.json")
+	is.NoErr(writeResult(oldPath, ResultFile{TotalCountSum: 1, Applications: []Application{{Name: "api", CountSum: 1, GrepResults: []GrepResult{{FileName: "main.go", Count: 1}}}}}, 0644))
+	is.NoErr(writeResult(newPath, ResultFile{TotalCountSum: 2, Applications: []Application{{Name: "api", CountSum: 2, GrepResults: []GrepResult{{FileName: "main.go", Count: 2}}}}}, 0644))
+	var output bytes.Buffer
+
+	is.Equal(ExitCodeSuccess, cli([]string{"diff", "-output", diffPath, oldPath, newPath}, nil, &output))
+
+	is.True(strings.Contains(output.String(), "~ api: 1 -> 2"))
+	content, err := os.ReadFile(diffPath)
+	is.NoErr(err)
+	var diff ResultDiff
+	is.NoErr(json.Unmarshal(content, &diff))
+	is.Equal(1, len(diff.Applications))
+	is.Equal(ExitCodeConfigError, cli([]string{"diff", oldPath}, nil, &output))
+	is.Equal(ExitCodeConfigError, cli([]string{"diff", oldPath, filepath.Join(dir, "missing.json")}, nil, &output))
+}
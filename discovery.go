@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// DiscoveryTimeout is the timeout of every request to the API of a discovery provider
+const DiscoveryTimeout = 30 * time.Second
+
+// discoveredRepo is a repository listed by the API of a discovery provider
+type discoveredRepo struct {
+	Name     string
+	CloneURL string
+	Topics   []string
+	Archived bool
+	Fork     bool
+	// TokenEnv is the token_env the repository is cloned with, the global one is used when empty
+	TokenEnv string
+	// TokenUsername is the user name the token is sent with, see Repository
+	TokenUsername string
+}
+
+// discoverRepositories lists the repositories of the configured discovery providers
+func discoverRepositories(ctx context.Context, cfg Config) ([]discoveredRepo, error) {
+	client := &http.Client{Timeout: DiscoveryTimeout}
+	var result []discoveredRepo
+	if cfg.GitHubOrg != "" {
+		repos, err := listGitHubOrgRepos(ctx, client, cfg.gitHubAPIURL(), cfg.GitHubOrg, tokenFromEnv(cfg.TokenEnv))
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the repositories of GitHub organization '%s': %w", cfg.GitHubOrg, err)
+		}
+		result = append(result, filterGitHubRepos(repos, cfg.GitHubTopic, cfg.GitHubNameFilter)...)
+	}
+	if cfg.GitLabGroup != "" {
+		token := tokenFromEnv(firstNonEmpty(cfg.GitLabTokenEnv, cfg.TokenEnv))
+		repos, err := listGitLabGroupProjects(ctx, client, cfg.gitLabURL(), cfg.GitLabGroup, token)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the projects of GitLab group '%s': %w", cfg.GitLabGroup, err)
+		}
+		for _, repo := range repos {
+			repo.TokenEnv = cfg.GitLabTokenEnv
+			result = append(result, repo)
+		}
+	}
+	if cfg.BitbucketWorkspace != "" || cfg.BitbucketProject != "" {
+		token := tokenFromEnv(firstNonEmpty(cfg.BitbucketTokenEnv, cfg.TokenEnv))
+		var repos []discoveredRepo
+		var err error
+		if cfg.BitbucketWorkspace != "" {
+			repos, err = listBitbucketWorkspaceRepos(ctx, client, cfg.bitbucketURL(), cfg.BitbucketWorkspace, token)
+		} else {
+			repos, err = listBitbucketServerProjectRepos(ctx, client, cfg.bitbucketURL(), cfg.BitbucketProject, token)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the Bitbucket repositories: %w", err)
+		}
+		for _, repo := range repos {
+			repo.TokenEnv = cfg.BitbucketTokenEnv
+			if cfg.BitbucketWorkspace != "" {
+				repo.TokenUsername = BitbucketCloudTokenUsername
+			}
+			result = append(result, repo)
+		}
+	}
+	if cfg.AzureDevOpsOrg != "" {
+		token := tokenFromEnv(firstNonEmpty(cfg.AzureDevOpsTokenEnv, cfg.TokenEnv))
+		repos, err := listAzureDevOpsRepos(ctx, client, cfg.azureDevOpsURL(), cfg.AzureDevOpsOrg, cfg.AzureDevOpsProject, token)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the Azure DevOps repositories of '%s': %w", cfg.AzureDevOpsOrg, err)
+		}
+		for _, repo := range repos {
+			repo.TokenEnv = cfg.AzureDevOpsTokenEnv
+			result = append(result, repo)
+		}
+	}
+	if cfg.GiteaOrg != "" || cfg.GiteaUser != "" {
+		token := tokenFromEnv(firstNonEmpty(cfg.GiteaTokenEnv, cfg.TokenEnv))
+		repos, err := listGiteaRepos(ctx, client, cfg.GiteaURL, cfg.GiteaOrg, cfg.GiteaUser, token)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the Gitea repositories of '%s': %w", firstNonEmpty(cfg.GiteaOrg, cfg.GiteaUser), err)
+		}
+		for _, repo := range repos {
+			repo.TokenEnv = cfg.GiteaTokenEnv
+			result = append(result, repo)
+		}
+	}
+	return filterDiscovered(result, cfg), nil
+}
+
+// filterDiscovered leaves out the archived and forked repositories when skip_archived and skip_forks are set, and the
+// repositories which name matches none of the discovery_include globs or one of the discovery_exclude globs
+func filterDiscovered(repos []discoveredRepo, cfg Config) []discoveredRepo {
+	var result []discoveredRepo
+	for _, repo := range repos {
+		if (cfg.SkipArchived && repo.Archived) || (cfg.SkipForks && repo.Fork) {
+			continue
+		}
+		if len(cfg.DiscoveryInclude) > 0 && !matchAnyGlob(cfg.DiscoveryInclude, repo.Name) {
+			continue
+		}
+		if matchAnyGlob(cfg.DiscoveryExclude, repo.Name) {
+			continue
+		}
+		result = append(result, repo)
+	}
+	return result
+}
+
+// mergeDiscovered appends the discovered repositories to the repositories, except the ones with the url or the name
+// of a repository which is already there, so a repository in the config keeps its settings
+func mergeDiscovered(repos []Repository, discovered []discoveredRepo) []Repository {
+	seen := map[string]bool{}
+	for _, repo := range repos {
+		seen[repo.Url] = true
+		seen[repo.Name] = true
+	}
+	for _, d := range discovered {
+		if seen[d.CloneURL] || seen[d.Name] {
+			continue
+		}
+		seen[d.CloneURL] = true
+		seen[d.Name] = true
+		repos = append(repos, Repository{Name: d.Name, Url: d.CloneURL, TokenEnv: d.TokenEnv, TokenUsername: d.TokenUsername})
+	}
+	return repos
+}
+
+// getJSON gets the url and decodes the JSON response into v, the headers of the response are returned for the pagination
+func getJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, v interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s responded with status %s", url, resp.Status)
+	}
+	return resp.Header, json.NewDecoder(resp.Body).Decode(v)
+}
+
+var nextLinkPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextLink returns the url of the next page in the Link header of a response, empty on the last page
+func nextLink(header http.Header) string {
+	if match := nextLinkPattern.FindStringSubmatch(header.Get("Link")); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// checkNextPage returns an error when the url of the next page does not have the scheme and host of the first page,
+// the token is sent with every page so it must not be sent to another host a response links to
+func checkNextPage(first, next string) error {
+	if next == "" {
+		return nil
+	}
+	firstURL, err := url.Parse(first)
+	if err != nil {
+		return err
+	}
+	nextURL, err := url.Parse(next)
+	if err != nil {
+		return fmt.Errorf("invalid next page url: %w", err)
+	}
+	if nextURL.Scheme != firstURL.Scheme || nextURL.Host != firstURL.Host {
+		return fmt.Errorf("next page url %s is not on %s://%s", redactCredentials(next), firstURL.Scheme, firstURL.Host)
+	}
+	return nil
+}
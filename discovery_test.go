@@ -0,0 +1,42 @@
+package main
+
+import (
+	IS "github.com/matryer/is"
+	"testing"
+)
+
+func TestFilterDiscovered(t *testing.T) {
+	is := IS.New(t)
+	repos := []discoveredRepo{
+		{Name: "platform/service-users"},
+		{Name: "platform/service-legacy", Archived: true},
+		{Name: "platform/service-orders-fork", Fork: true},
+		{Name: "platform/website"},
+		{Name: "sandbox/service-demo"},
+	}
+
+	is.Equal(repos, filterDiscovered(repos, Config{}))
+	is.Equal([]discoveredRepo{repos[0], repos[3], repos[4]}, filterDiscovered(repos, Config{SkipArchived: true, SkipForks: true}))
+	is.Equal([]discoveredRepo{repos[0], repos[2]}, filterDiscovered(repos, Config{
+		SkipArchived:     true,
+		DiscoveryInclude: []string{"service-*"},
+		DiscoveryExclude: []string{"sandbox/**"},
+	}))
+}
+
+func TestValidateConfigDiscoveryFilters(t *testing.T) {
+	is := IS.New(t)
+
+	is.NoErr(validateConfig(Config{SearchWords: []string{"fell"}, DiscoveryInclude: []string{"service-*"}, DiscoveryExclude: []string{"sandbox/**"}}))
+	is.True(validateConfig(Config{SearchWords: []string{"fell"}, DiscoveryExclude: []string{"[sandbox"}}) != nil)
+}
+
+func TestCheckNextPage(t *testing.T) {
+	is := IS.New(t)
+	first := "https://api.github.com/orgs/akselleirv/repos?per_page=100"
+
+	is.NoErr(checkNextPage(first, ""))
+	is.NoErr(checkNextPage(first, "https://api.github.com/orgs/akselleirv/repos?per_page=100&page=2"))
+	is.True(checkNextPage(first, "https://attacker.example.com/orgs/akselleirv/repos?page=2") != nil)
+	is.True(checkNextPage(first, "http://api.github.com/orgs/akselleirv/repos?page=2") != nil)
+}
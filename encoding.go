@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// filterUTF8 removes the results for files which content is not valid UTF-8 and returns how many of the searched
+// files were skipped, whether they matched or not. The text files in path are checked, binary files are not counted
+// unless they are searched as well. The files inside archives are only checked when they matched. Each skipped file
+// is only logged at the debug level.
+func filterUTF8(basePath string, grs []GrepResult, opts grepOptions) ([]GrepResult, int, error) {
+	files, err := searchedFiles(basePath, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	skipped := make(map[string]bool)
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(basePath, file))
+		if err != nil {
+			return nil, 0, err
+		}
+		if !utf8.Valid(content) && (opts.SearchBinary || !isBinary(content)) {
+			slog.Debug("skipping non UTF-8 file", "file", file)
+			skipped[file] = true
+		}
+	}
+
+	result := []GrepResult{}
+	for _, gr := range grs {
+		if _, _, inArchive := splitArchiveName(gr.FileName); inArchive && !skipped[gr.FileName] {
+			if content, err := readMatchedFile(basePath, gr.FileName); err != nil || !utf8.Valid(content) {
+				slog.Debug("skipping non UTF-8 file", "file", gr.FileName)
+				skipped[gr.FileName] = true
+			}
+		}
+		if !skipped[gr.FileName] {
+			result = append(result, gr)
+		}
+	}
+	return result, len(skipped), nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	IS "github.com/matryer/is"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilterUTF8(t *testing.T) {
+	is := IS.New(t)
+	grs := []GrepResult{
+		{FileName: "valid.txt", Count: 2},
+		{FileName: "invalid.txt", Count: 2},
+	}
+
+	result, skipped, err := filterUTF8("./testdata/encoding", grs, grepOptions{})
+
+	is.NoErr(err)
+	is.Equal(1, skipped)
+	is.Equal(1, len(result))
+	is.Equal("valid.txt", result[0].FileName)
+}
+
+func TestFilterUTF8CountsFilesWithoutMatches(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, "valid.txt"), []byte("needle"), 0644))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "latin1.txt"), []byte("h\xf8ystakk"), 0644))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "image.bin"), []byte("\x00\xff"), 0644))
+
+	result, skipped, err := filterUTF8(dir, []GrepResult{{FileName: "valid.txt", Count: 1}}, grepOptions{})
+
+	is.NoErr(err)
+	is.Equal(1, skipped) // latin1.txt has no matches but is skipped all the same, the binary file is not searched
+	is.Equal([]GrepResult{{FileName: "valid.txt", Count: 1}}, result)
+}
+
+func TestAnalyzePathLogsSkippedFilesOnce(t *testing.T) {
+	is := IS.New(t)
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	app, err := analyzePath(context.Background(), Repository{Name: "encoding"}, Config{SearchWords: []string{"needle"}, UTF8Only: true}, "./testdata/encoding")
+
+	is.NoErr(err)
+	is.Equal(1, app.FilesSkipped)
+	is.Equal(1, strings.Count(logs.String(), "skipped non UTF-8 files"))
+	is.True(!strings.Contains(logs.String(), "invalid.txt")) // the skipped files are only logged at the debug level
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// compileExcludePatterns compiles the exclude_patterns of the config
+func compileExcludePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern '%s': %w", pattern, err)
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
+
+// excludedLine reports whether the line matches one of the exclude patterns
+func excludedLine(line []byte, excludes []*regexp.Regexp) bool {
+	for _, exclude := range excludes {
+		if exclude.Match(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterExcludedLines removes the matches on the lines which match one of the exclude patterns from the counts of the
+// results. grep only reports the matched text, so the files with matches are searched again to find those lines.
+// The results which have no matches left are removed.
+func filterExcludedLines(basePath string, grs []GrepResult, groups []searchWordGroup, excludes []*regexp.Regexp) ([]GrepResult, error) {
+	if len(excludes) == 0 {
+		return grs, nil
+	}
+	patterns := make([]*regexp.Regexp, len(groups))
+	for i, group := range groups {
+		re, err := compileSearchWordGroups([]searchWordGroup{group})
+		if err != nil {
+			return nil, err
+		}
+		patterns[i] = re
+	}
+
+	var result []GrepResult
+	for _, gr := range grs {
+		content, err := readMatchedFile(basePath, gr.FileName)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range bytes.Split(content, []byte{'\n'}) {
+			if !excludedLine(line, excludes) {
+				continue
+			}
+			for i, group := range groups {
+				excluded := []GrepResult{{Words: map[string]int{}}}
+				for _, match := range patterns[i].FindAll(line, -1) {
+					if len(match) > 0 {
+						excluded[0].Words[string(match)]++
+					}
+				}
+				for word, count := range attributeWords(excluded, group.Words, group.CaseSensitive)[0].Words {
+					gr.Count -= count
+					if gr.Words[word] -= count; gr.Words[word] <= 0 {
+						delete(gr.Words, word)
+					}
+				}
+			}
+		}
+		if gr.Count > 0 {
+			result = append(result, gr)
+		}
+	}
+	return result, nil
+}
+
+// filterExcludedMatches removes the matches on the lines which match one of the exclude patterns
+func filterExcludedMatches(grs []GrepResult, excludes []*regexp.Regexp) {
+	if len(excludes) == 0 {
+		return
+	}
+	for i := range grs {
+		var matches []Match
+		for _, match := range grs[i].Matches {
+			if !excludedLine([]byte(match.Text), excludes) {
+				matches = append(matches, match)
+			}
+		}
+		grs[i].Matches = matches
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzePathExcludePatterns(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("oldAPI() // grepper-approved\noldAPI() oldAPI()\nlegacy()\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "fixture.go"), []byte("oldAPI() // grepper-approved\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, backend := range []string{SearchBackendGrep, SearchBackendNative} {
+		t.Run(backend, func(t *testing.T) {
+			is := IS.New(t)
+			cfg := Config{
+				SearchWords:     []string{"oldAPI", "legacy"},
+				ExcludePatterns: []string{"// grepper-approved"},
+				SearchBackend:   backend,
+				IncludeMatches:  true,
+			}
+
+			app, err := analyzePath(context.Background(), Repository{Name: "repo"}, cfg, dir)
+
+			is.NoErr(err)
+			is.Equal(1, len(app.GrepResults))
+			is.Equal(3, app.CountSum)
+			is.Equal(map[string]int{"oldAPI": 2, "legacy": 1}, app.WordCounts)
+			is.Equal(3, len(app.GrepResults[0].Matches))
+		})
+	}
+}
+
+func TestValidateConfigExcludePatterns(t *testing.T) {
+	is := IS.New(t)
+
+	is.True(validateConfig(Config{SearchWords: []string{"fell"}, ExcludePatterns: []string{"("}}) != nil)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// giteaRepo is a repository in the response of the Gitea and Forgejo API, only the used fields are decoded
+type giteaRepo struct {
+	FullName string   `json:"full_name"`
+	CloneURL string   `json:"clone_url"`
+	Topics   []string `json:"topics"`
+	Archived bool     `json:"archived"`
+	Fork     bool     `json:"fork"`
+}
+
+// listGiteaRepos lists all repositories of the organization, or of the user when the organization is empty,
+// following the pages of the response
+func listGiteaRepos(ctx context.Context, client *http.Client, baseURL, org, user, token string) ([]discoveredRepo, error) {
+	var headers map[string]string
+	if token != "" {
+		headers = map[string]string{"Authorization": "token " + token}
+	}
+	next := strings.TrimSuffix(baseURL, "/") + "/api/v1/"
+	if org != "" {
+		next += "orgs/" + url.PathEscape(org)
+	} else {
+		next += "users/" + url.PathEscape(user)
+	}
+	next += "/repos?limit=50"
+
+	var result []discoveredRepo
+	first := next
+	for next != "" {
+		var page []giteaRepo
+		header, err := getJSON(ctx, client, next, headers, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range page {
+			result = append(result, discoveredRepo{
+				Name:     repo.FullName,
+				CloneURL: repo.CloneURL,
+				Topics:   repo.Topics,
+				Archived: repo.Archived,
+				Fork:     repo.Fork,
+			})
+		}
+		next = nextLink(header)
+		if err := checkNextPage(first, next); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
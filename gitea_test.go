@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	IS "github.com/matryer/is"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListGiteaRepos(t *testing.T) {
+	is := IS.New(t)
+	var authorization string
+	var paths []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		paths = append(paths, r.URL.Path)
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"full_name": "tools/old-ci", "clone_url": "https://git.example.com/tools/old-ci.git", "archived": true}]`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s?limit=50&page=2>; rel="next"`, server.URL, r.URL.Path))
+		fmt.Fprint(w, `[{"full_name": "tools/deploy", "clone_url": "https://git.example.com/tools/deploy.git", "topics": ["ops"]}]`)
+	}))
+	defer server.Close()
+
+	repos, err := listGiteaRepos(context.Background(), server.Client(), server.URL, "tools", "", "secret")
+
+	is.NoErr(err)
+	is.Equal("token secret", authorization)
+	is.Equal([]string{"/api/v1/orgs/tools/repos", "/api/v1/orgs/tools/repos"}, paths)
+	is.Equal([]discoveredRepo{
+		{Name: "tools/deploy", CloneURL: "https://git.example.com/tools/deploy.git", Topics: []string{"ops"}},
+		{Name: "tools/old-ci", CloneURL: "https://git.example.com/tools/old-ci.git", Archived: true},
+	}, repos)
+
+	paths = nil
+	_, err = listGiteaRepos(context.Background(), server.Client(), server.URL, "", "jdoe", "")
+	is.NoErr(err)
+	is.Equal("/api/v1/users/jdoe/repos", paths[0])
+}
+
+func TestValidateConfigGitea(t *testing.T) {
+	is := IS.New(t)
+
+	is.True(validateConfig(Config{SearchWords: []string{"fell"}, GiteaOrg: "tools"}) != nil)
+	is.True(validateConfig(Config{SearchWords: []string{"fell"}, GiteaURL: "https://git.example.com", GiteaOrg: "tools", GiteaUser: "jdoe"}) != nil)
+	is.NoErr(validateConfig(Config{SearchWords: []string{"fell"}, GiteaURL: "https://git.example.com", GiteaUser: "jdoe"}))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+const DefaultGitHubAPIURL = "https://api.github.com"
+
+// gitHubRepo is a repository in the response of the GitHub API, only the used fields are decoded
+type gitHubRepo struct {
+	Name     string   `json:"name"`
+	CloneURL string   `json:"clone_url"`
+	Topics   []string `json:"topics"`
+	Archived bool     `json:"archived"`
+	Fork     bool     `json:"fork"`
+}
+
+// listGitHubOrgRepos lists all repositories of the organization, following the pages of the response
+func listGitHubOrgRepos(ctx context.Context, client *http.Client, apiURL, org, token string) ([]discoveredRepo, error) {
+	headers := map[string]string{"Accept": "application/vnd.github+json"}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	var result []discoveredRepo
+	next := strings.TrimSuffix(apiURL, "/") + "/orgs/" + url.PathEscape(org) + "/repos?per_page=100"
+	first := next
+	for next != "" {
+		var page []gitHubRepo
+		header, err := getJSON(ctx, client, next, headers, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range page {
+			result = append(result, discoveredRepo{
+				Name:     repo.Name,
+				CloneURL: repo.CloneURL,
+				Topics:   repo.Topics,
+				Archived: repo.Archived,
+				Fork:     repo.Fork,
+			})
+		}
+		next = nextLink(header)
+		if err := checkNextPage(first, next); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// filterGitHubRepos keeps the repositories with the topic whose name matches the name filter, a glob like 'service-*'.
+// An empty topic or name filter keeps all repositories.
+func filterGitHubRepos(repos []discoveredRepo, topic, nameFilter string) []discoveredRepo {
+	var result []discoveredRepo
+	for _, repo := range repos {
+		if topic != "" && !containsString(repo.Topics, topic) {
+			continue
+		}
+		if nameFilter != "" {
+			if ok, err := path.Match(nameFilter, repo.Name); err != nil || !ok {
+				continue
+			}
+		}
+		result = append(result, repo)
+	}
+	return result
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenFromEnv returns the token in the environment variable, empty when the variable is not set
+func tokenFromEnv(name string) string {
+	if name == "" {
+		return ""
+	}
+	return os.Getenv(name)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	IS "github.com/matryer/is"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListGitHubOrgRepos(t *testing.T) {
+	is := IS.New(t)
+	var authorization string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if r.URL.Path != "/orgs/akselleirv/repos" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"name": "introspect-infra", "clone_url": "https://github.com/akselleirv/introspect-infra.git", "archived": true}]`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/akselleirv/repos?per_page=100&page=2>; rel="next", <%s/orgs/akselleirv/repos?per_page=100&page=2>; rel="last"`, server.URL, server.URL))
+		fmt.Fprint(w, `[
+			{"name": "introspect-backend", "clone_url": "https://github.com/akselleirv/introspect-backend.git", "topics": ["go", "service"]},
+			{"name": "introspect-frontend", "clone_url": "https://github.com/akselleirv/introspect-frontend.git", "topics": ["web"], "fork": true}
+		]`)
+	}))
+	defer server.Close()
+
+	repos, err := listGitHubOrgRepos(context.Background(), server.Client(), server.URL, "akselleirv", "ghp_secret")
+
+	is.NoErr(err)
+	is.Equal("Bearer ghp_secret", authorization)
+	is.Equal([]discoveredRepo{
+		{Name: "introspect-backend", CloneURL: "https://github.com/akselleirv/introspect-backend.git", Topics: []string{"go", "service"}},
+		{Name: "introspect-frontend", CloneURL: "https://github.com/akselleirv/introspect-frontend.git", Topics: []string{"web"}, Fork: true},
+		{Name: "introspect-infra", CloneURL: "https://github.com/akselleirv/introspect-infra.git", Archived: true},
+	}, repos)
+}
+
+func TestListGitHubOrgReposError(t *testing.T) {
+	is := IS.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := listGitHubOrgRepos(context.Background(), server.Client(), server.URL, "akselleirv", "")
+
+	is.True(err != nil)
+}
+
+func TestListGitHubOrgReposNextPageOnOtherHost(t *testing.T) {
+	is := IS.New(t)
+	var leaked string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = r.Header.Get("Authorization")
+		fmt.Fprint(w, `[]`)
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/akselleirv/repos?page=2>; rel="next"`, other.URL))
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	_, err := listGitHubOrgRepos(context.Background(), server.Client(), server.URL, "akselleirv", "ghp_secret")
+
+	is.True(err != nil)
+	is.Equal("", leaked)
+}
+
+func TestFilterGitHubRepos(t *testing.T) {
+	is := IS.New(t)
+	repos := []discoveredRepo{
+		{Name: "service-users", Topics: []string{"go"}},
+		{Name: "service-orders", Topics: []string{"java"}},
+		{Name: "website", Topics: []string{"go"}},
+	}
+
+	is.Equal(3, len(filterGitHubRepos(repos, "", "")))
+	is.Equal([]discoveredRepo{repos[0], repos[2]}, filterGitHubRepos(repos, "go", ""))
+	is.Equal([]discoveredRepo{repos[0]}, filterGitHubRepos(repos, "go", "service-*"))
+}
+
+func TestMergeDiscovered(t *testing.T) {
+	is := IS.New(t)
+	repos := []Repository{{Name: "backend", Url: "https://github.com/akselleirv/introspect-backend.git", ExcludeDirs: []string{"vendor"}}}
+	discovered := []discoveredRepo{
+		{Name: "introspect-backend", CloneURL: "https://github.com/akselleirv/introspect-backend.git"},
+		{Name: "introspect-frontend", CloneURL: "https://github.com/akselleirv/introspect-frontend.git"},
+	}
+
+	merged := mergeDiscovered(repos, discovered)
+
+	is.Equal([]Repository{
+		{Name: "backend", Url: "https://github.com/akselleirv/introspect-backend.git", ExcludeDirs: []string{"vendor"}},
+		{Name: "introspect-frontend", Url: "https://github.com/akselleirv/introspect-frontend.git"},
+	}, merged)
+}
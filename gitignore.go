@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// ignoredFiles are the files and dirs of a git repository which are ignored by its .gitignore files, relative to the
+// searched path. Tracked files which are ignored, e.g. build artifacts committed by accident, are ignored as well.
+type ignoredFiles struct {
+	files map[string]bool
+	dirs  map[string]bool
+}
+
+// listIgnoredFiles lists the ignored files of the git repository at path, untracked ignored dirs are listed as a whole
+func listIgnoredFiles(ctx context.Context, gitBinary, path string) (ignoredFiles, error) {
+	cmd := gitIgnoredCommand(ctx, gitBinary, path)
+	logCommand(cmd.Args)
+	out, err := cmd.Output()
+	if err != nil {
+		return ignoredFiles{}, fmt.Errorf("unable to list the files ignored by .gitignore: %w", err)
+	}
+	return parseIgnoredFiles(string(out)), nil
+}
+
+func gitIgnoredCommand(ctx context.Context, gitBinary, path string) *exec.Cmd {
+	return exec.CommandContext(ctx, gitBinary, "-C", path, "ls-files", "-z", "--cached", "--others", "--ignored", "--exclude-standard", "--directory")
+}
+
+// parseIgnoredFiles parses the NUL separated output of gitIgnoredCommand, in which dirs end with a '/'
+func parseIgnoredFiles(out string) ignoredFiles {
+	result := ignoredFiles{files: make(map[string]bool), dirs: make(map[string]bool)}
+	for _, name := range strings.Split(out, "\x00") {
+		switch {
+		case name == "":
+		case strings.HasSuffix(name, "/"):
+			result.dirs[strings.TrimSuffix(name, "/")] = true
+		default:
+			result.files[name] = true
+		}
+	}
+	return result
+}
+
+// ignored reports whether the file, or one of its parent dirs, is ignored
+func (i ignoredFiles) ignored(file string) bool {
+	if i.files[file] {
+		return true
+	}
+	for dir := path.Dir(file); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if i.dirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// filterIgnoredFiles removes the results which file is ignored, a file inside an archive is ignored with its archive
+func filterIgnoredFiles(grs []GrepResult, ignored ignoredFiles) []GrepResult {
+	result := []GrepResult{}
+	for _, gr := range grs {
+		fileName := gr.FileName
+		if archive, _, ok := splitArchiveName(fileName); ok {
+			fileName = archive
+		}
+		if !ignored.ignored(fileName) {
+			result = append(result, gr)
+		}
+	}
+	return result
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"testing"
+)
+
+func TestParseIgnoredFiles(t *testing.T) {
+	is := IS.New(t)
+
+	ignored := parseIgnoredFiles("node_modules/\x00x.log\x00build/out.js\x00")
+
+	is.True(ignored.ignored("node_modules/lib/index.js"))
+	is.True(ignored.ignored("x.log"))
+	is.True(ignored.ignored("build/out.js"))
+	is.True(!ignored.ignored("build/src.js"))
+	is.True(!ignored.ignored("src/x.log"))
+}
+
+func TestAnalyzePathRespectGitignore(t *testing.T) {
+	is := IS.New(t)
+	repo := newTestRepo(t, map[string]string{
+		".gitignore":  "dist/\n*.log\n",
+		"main.go":     "oldAPI",
+		"debug.log":   "oldAPI",
+		"dist/app.js": "oldAPI oldAPI",
+	})
+	cfg := Config{SearchWords: []string{"oldAPI"}}
+
+	app, err := analyzePath(context.Background(), Repository{Name: "repo"}, cfg, repo)
+	is.NoErr(err)
+	is.Equal(4, app.CountSum)
+
+	cfg.RespectGitignore = true
+	app, err = analyzePath(context.Background(), Repository{Name: "repo"}, cfg, repo)
+
+	is.NoErr(err)
+	is.Equal([]GrepResult{{FileName: "main.go", Count: 1, Words: map[string]int{"oldAPI": 1}}}, app.GrepResults)
+}
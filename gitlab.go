@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const DefaultGitLabURL = "https://gitlab.com"
+
+// gitLabProject is a project in the response of the GitLab API, only the used fields are decoded
+type gitLabProject struct {
+	PathWithNamespace string    `json:"path_with_namespace"`
+	HTTPURLToRepo     string    `json:"http_url_to_repo"`
+	Topics            []string  `json:"topics"`
+	Archived          bool      `json:"archived"`
+	ForkedFromProject *struct{} `json:"forked_from_project"`
+}
+
+// listGitLabGroupProjects lists all projects of the group and of its subgroups, following the pages of the response.
+// The projects are named after their path, e.g. 'platform/backend/users', as projects in different subgroups can have the same name.
+func listGitLabGroupProjects(ctx context.Context, client *http.Client, baseURL, group, token string) ([]discoveredRepo, error) {
+	headers := map[string]string{}
+	if token != "" {
+		headers["PRIVATE-TOKEN"] = token
+	}
+	var result []discoveredRepo
+	next := strings.TrimSuffix(baseURL, "/") + "/api/v4/groups/" + url.PathEscape(group) + "/projects?include_subgroups=true&per_page=100"
+	first := next
+	for next != "" {
+		var page []gitLabProject
+		header, err := getJSON(ctx, client, next, headers, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range page {
+			result = append(result, discoveredRepo{
+				Name:     project.PathWithNamespace,
+				CloneURL: project.HTTPURLToRepo,
+				Topics:   project.Topics,
+				Archived: project.Archived,
+				Fork:     project.ForkedFromProject != nil,
+			})
+		}
+		next = nextLink(header)
+		if err := checkNextPage(first, next); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	IS "github.com/matryer/is"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListGitLabGroupProjects(t *testing.T) {
+	is := IS.New(t)
+	var token, subgroups string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("PRIVATE-TOKEN")
+		subgroups = r.URL.Query().Get("include_subgroups")
+		if r.URL.EscapedPath() != "/gitlab/api/v4/groups/platform%2Fbackend/projects" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"path_with_namespace": "platform/backend/payments/ledger", "http_url_to_repo": "https://git.example.com/platform/backend/payments/ledger.git", "archived": true}]`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/gitlab/api/v4/groups/platform%%2Fbackend/projects?include_subgroups=true&per_page=100&page=2>; rel="next"`, server.URL))
+		fmt.Fprint(w, `[
+			{"path_with_namespace": "platform/backend/users", "http_url_to_repo": "https://git.example.com/platform/backend/users.git", "topics": ["go"]},
+			{"path_with_namespace": "platform/backend/orders", "http_url_to_repo": "https://git.example.com/platform/backend/orders.git", "forked_from_project": {"id": 7}}
+		]`)
+	}))
+	defer server.Close()
+
+	repos, err := listGitLabGroupProjects(context.Background(), server.Client(), server.URL+"/gitlab/", "platform/backend", "glpat-secret")
+
+	is.NoErr(err)
+	is.Equal("glpat-secret", token)
+	is.Equal("true", subgroups) // the projects of the subgroups are listed as well
+	is.Equal([]discoveredRepo{
+		{Name: "platform/backend/users", CloneURL: "https://git.example.com/platform/backend/users.git", Topics: []string{"go"}},
+		{Name: "platform/backend/orders", CloneURL: "https://git.example.com/platform/backend/orders.git", Fork: true},
+		{Name: "platform/backend/payments/ledger", CloneURL: "https://git.example.com/platform/backend/payments/ledger.git", Archived: true},
+	}, repos)
+}
+
+func TestDiscoverRepositoriesGitLab(t *testing.T) {
+	is := IS.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"path_with_namespace": "platform/users", "http_url_to_repo": "https://git.example.com/platform/users.git"}]`)
+	}))
+	defer server.Close()
+
+	repos, err := discoverRepositories(context.Background(), Config{GitLabGroup: "platform", GitLabURL: server.URL, GitLabTokenEnv: "GITLAB_TOKEN"})
+
+	is.NoErr(err)
+	is.Equal([]discoveredRepo{{Name: "platform/users", CloneURL: "https://git.example.com/platform/users.git", TokenEnv: "GITLAB_TOKEN"}}, repos)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// matchGlob reports whether the slash separated name matches the pattern.
+// A '**' segment matches any number of directories, the other segments are matched with path.Match.
+// A pattern without any '/' is matched against the base name only, the same way grep's --include works.
+func matchGlob(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, err := path.Match(pattern, path.Base(name))
+		return err == nil && ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchAnyGlob reports whether the name matches at least one of the patterns
+func matchAnyGlob(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedDir reports whether the slash separated dir, relative to the repo, matches one of the exclude dir patterns.
+// A pattern without any '/' is matched against the base name of the dir, like grep's --exclude-dir,
+// otherwise it is matched against the whole relative path, so 'pkg/generated' only excludes that dir.
+func excludedDir(patterns []string, dir string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(strings.TrimSuffix(pattern, "/"), dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedExcludeDirs returns the exclude dir patterns containing a '/', which grep's --exclude-dir can not match
+func nestedExcludeDirs(patterns []string) []string {
+	var result []string
+	for _, pattern := range patterns {
+		if strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+			result = append(result, pattern)
+		}
+	}
+	return result
+}
+
+// grepIncludes translates the include globs to grep --include base name patterns.
+// grep only matches on the base name, so nil is returned when one of the globs can not be narrowed down,
+// the exact matching is then left to filterIncludeGlobs.
+func grepIncludes(globs []string) []string {
+	var result []string
+	for _, glob := range globs {
+		base := path.Base(glob)
+		if strings.Contains(base, "**") || base == "." || base == "/" {
+			return nil
+		}
+		result = append(result, base)
+	}
+	return result
+}
+
+// includeGlobs returns the include globs of the config and the repository, with a glob for each of their include
+// extensions. A file is searched when it matches at least one of them.
+func includeGlobs(cfg Config, r Repository) []string {
+	var result []string
+	result = append(result, cfg.IncludeGlobs...)
+	result = append(result, r.IncludeGlobs...)
+	for _, extension := range append(append([]string{}, cfg.IncludeExtensions...), r.IncludeExtensions...) {
+		result = append(result, "*."+strings.TrimPrefix(extension, "."))
+	}
+	return result
+}
+
+// validateExtensions checks that the include extensions are plain extensions such as go or .tf
+func validateExtensions(extensions []string) error {
+	for _, extension := range extensions {
+		if strings.TrimPrefix(extension, ".") == "" || strings.ContainsAny(extension, "/*?[") {
+			return fmt.Errorf("invalid include extension '%s', must be an extension such as go or .tf", extension)
+		}
+	}
+	return nil
+}
+
+// filterExcludeDirs removes the results which file is inside one of the excluded dirs
+func filterExcludeDirs(grs []GrepResult, excludeDirs []string) []GrepResult {
+	if len(nestedExcludeDirs(excludeDirs)) == 0 {
+		return grs
+	}
+	result := []GrepResult{}
+	for _, gr := range grs {
+		if !inExcludedDir(gr.FileName, excludeDirs) {
+			result = append(result, gr)
+		}
+	}
+	return result
+}
+
+// filterExcludeFiles removes the results which file name matches one of the exclude file globs
+func filterExcludeFiles(grs []GrepResult, globs []string) []GrepResult {
+	if len(globs) == 0 {
+		return grs
+	}
+	result := []GrepResult{}
+	for _, gr := range grs {
+		if !matchAnyGlob(globs, gr.FileName) {
+			result = append(result, gr)
+		}
+	}
+	return result
+}
+
+// filterIncludeGlobs keeps the results which file name matches at least one of the globs
+func filterIncludeGlobs(grs []GrepResult, globs []string) []GrepResult {
+	if len(globs) == 0 {
+		return grs
+	}
+	result := []GrepResult{}
+	for _, gr := range grs {
+		if matchAnyGlob(globs, gr.FileName) {
+			result = append(result, gr)
+		}
+	}
+	return result
+}
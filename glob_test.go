@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	is := IS.New(t)
+	testCases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"**/handlers/*.go", "handlers/user.go", true},
+		{"**/handlers/*.go", "internal/api/handlers/user.go", true},
+		{"**/handlers/*.go", "internal/api/handlers/v2/user.go", false},
+		{"**/handlers/*.go", "internal/api/handlers/user_test.txt", false},
+		{"internal/**", "internal/api/handlers/user.go", true},
+		{"internal/**", "cmd/main.go", false},
+		{"*.go", "internal/api/handlers/user.go", true},
+		{"*.go", "README.md", false},
+	}
+
+	for _, tc := range testCases {
+		is.Equal(tc.match, matchGlob(tc.pattern, tc.name)) // pattern: tc.pattern, name: tc.name
+	}
+}
+
+func TestGrepIncludes(t *testing.T) {
+	is := IS.New(t)
+
+	is.Equal([]string{"*.go", "*.txt"}, grepIncludes([]string{"**/handlers/*.go", "*.txt"}))
+	is.Equal(0, len(grepIncludes([]string{"**/handlers/*.go", "internal/**"})))
+}
+
+func TestGrepWithIncludeGlobs(t *testing.T) {
+	is := IS.New(t)
+
+	result, err := grep(context.Background(), "./testdata", []string{"fell"}, grepOptions{IncludeGlobs: []string{"**/*_1.txt"}})
+
+	is.NoErr(err)
+	is.Equal(1, len(result))
+	is.Equal("testdata_1.txt", result[0].FileName)
+	is.Equal(2, result[0].Count)
+}
+
+func TestIncludeGlobs(t *testing.T) {
+	is := IS.New(t)
+	cfg := Config{IncludeGlobs: []string{"**/handlers/*.go"}, IncludeExtensions: []string{"tf"}}
+
+	is.Equal([]string{"**/handlers/*.go", "cmd/*", "*.tf", "*.go"}, includeGlobs(cfg, Repository{IncludeGlobs: []string{"cmd/*"}, IncludeExtensions: []string{".go"}}))
+	is.Equal(0, len(includeGlobs(Config{}, Repository{})))
+	is.NoErr(validateExtensions([]string{"go", ".tf"}))
+	is.True(validateExtensions([]string{"*.go"}) != nil)
+	is.True(validateExtensions([]string{"."}) != nil)
+}
+
+func TestAnalyzePathIncludeExtensions(t *testing.T) {
+	is := IS.New(t)
+	cfg := Config{SearchWords: []string{"fell"}}
+
+	app, err := analyzePath(context.Background(), Repository{Name: "testdata", IncludeExtensions: []string{"md"}}, cfg, "./testdata")
+
+	is.NoErr(err)
+	is.Equal(0, app.CountSum)
+
+	app, err = analyzePath(context.Background(), Repository{Name: "testdata", IncludeExtensions: []string{"md"}}, Config{SearchWords: []string{"fell"}, IncludeExtensions: []string{"txt"}}, "./testdata")
+
+	is.NoErr(err)
+	is.True(app.CountSum > 0)
+}
+
+func TestExcludedDir(t *testing.T) {
+	is := IS.New(t)
+	testCases := []struct {
+		pattern string
+		dir     string
+		match   bool
+	}{
+		{"node_modules", "node_modules", true},
+		{"node_modules", "web/node_modules", true},
+		{"node_modules", "web/node_modules_old", false},
+		{"pkg/generated", "pkg/generated", true},
+		{"pkg/generated", "internal/pkg/generated", false},
+		{"pkg/generated/", "pkg/generated", true},
+		{"**/generated", "internal/pkg/generated", true},
+		{"*_test", "pkg/api_test", true},
+		{"*_test", "pkg/api", false},
+	}
+
+	for _, tc := range testCases {
+		is.Equal(tc.match, excludedDir([]string{tc.pattern}, tc.dir)) // pattern: tc.pattern, dir: tc.dir
+	}
+}
+
+func TestExcludeDirPatterns(t *testing.T) {
+	dir := newTestRepo(t, map[string]string{
+		"main.go":                       "fell",
+		"node_modules/lib/index.js":     "fell",
+		"pkg/generated/api.go":          "fell",
+		"internal/pkg/generated/api.go": "fell",
+		"pkg/api_test/api.go":           "fell",
+	})
+	testCases := []struct {
+		name        string
+		excludeDirs []string
+		files       []string
+	}{
+		{"exact", []string{"node_modules"}, []string{"internal/pkg/generated/api.go", "main.go", "pkg/api_test/api.go", "pkg/generated/api.go"}},
+		{"nested path", []string{"pkg/generated"}, []string{"internal/pkg/generated/api.go", "main.go", "node_modules/lib/index.js", "pkg/api_test/api.go"}},
+		{"wildcard", []string{"*_test", "node_*"}, []string{"internal/pkg/generated/api.go", "main.go", "pkg/generated/api.go"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := IS.New(t)
+			opts := grepOptions{ExcludeDirs: tc.excludeDirs}
+
+			files, err := searchedFiles(dir, opts)
+			is.NoErr(err)
+			is.Equal(tc.files, files)
+
+			result, err := grep(context.Background(), dir, []string{"fell"}, opts)
+			is.NoErr(err)
+			sortOnFileName(result)
+			var grepped []string
+			for _, gr := range result {
+				grepped = append(grepped, gr.FileName)
+			}
+			is.Equal(tc.files, grepped)
+		})
+	}
+}
+
+func TestAnalyzePathExcludeFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "types_gen.go", "api/service.pb.go", "api/handler.go", "package-lock.json"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("oldAPI\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, backend := range []string{SearchBackendGrep, SearchBackendNative} {
+		t.Run(backend, func(t *testing.T) {
+			is := IS.New(t)
+			cfg := Config{SearchWords: []string{"oldAPI"}, ExcludeFiles: []string{"*_gen.go", "api/*.pb.go"}, SearchBackend: backend}
+
+			app, err := analyzePath(context.Background(), Repository{Name: "repo", ExcludeFiles: []string{"package-lock.json"}}, cfg, dir)
+
+			is.NoErr(err)
+			sortOnFileName(app.GrepResults)
+			is.Equal([]GrepResult{
+				{FileName: "api/handler.go", Count: 1, Words: map[string]int{"oldAPI": 1}},
+				{FileName: "main.go", Count: 1, Words: map[string]int{"oldAPI": 1}},
+			}, app.GrepResults)
+		})
+	}
+}
+
+func TestGrepExcludeStr(t *testing.T) {
+	is := IS.New(t)
+
+	is.Equal([]string{"--exclude=*_gen.go"}, grepExcludeStr([]string{"*_gen.go", "api/*.pb.go"}))
+}
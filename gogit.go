@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	GitClientBinary = "git"
+	GitClientGoGit  = "go-git"
+)
+
+// validateGitClient checks that the client is one of the supported git clients, empty means the git binary
+func validateGitClient(client string) error {
+	switch client {
+	case "", GitClientBinary, GitClientGoGit:
+		return nil
+	}
+	return fmt.Errorf("unknown git_client '%s', must be %s or %s", client, GitClientBinary, GitClientGoGit)
+}
+
+// goGitClone clones the repository into dir with go-git, which does not need the git binary. A ref is cloned as a
+// branch or else as a tag, a commit SHA is checked out in a clone of the whole history as go-git can not fetch it alone.
+func goGitClone(ctx context.Context, r Repository, dir string, cfg Config) error {
+	auth, err := goGitAuth(r, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to clone %s: %w", r.Name, err)
+	}
+	opts := &git.CloneOptions{URL: cloneURL(r.Url, cfg.CloneProtocol), Auth: auth}
+	if isCommitSHA(r.Ref) {
+		return goGitCloneCommit(ctx, r, dir, opts)
+	}
+	if depth := cloneDepth(r, cfg); depth > 0 {
+		opts.Depth, opts.SingleBranch = depth, true
+	}
+	slog.Info("cloning with go-git", "repository", r.Name, "url", redactCredentials(opts.URL), "ref", r.Ref)
+	if r.Ref == "" {
+		_, err = git.PlainCloneContext(ctx, dir, false, opts)
+		return goGitCloneError(r, err)
+	}
+	for _, name := range []plumbing.ReferenceName{plumbing.NewBranchReferenceName(r.Ref), plumbing.NewTagReferenceName(r.Ref)} {
+		opts.ReferenceName = name
+		_, err = git.PlainCloneContext(ctx, dir, false, opts)
+		if !isRefNotFound(err) {
+			break
+		}
+		if err := emptyDir(dir); err != nil {
+			return err
+		}
+	}
+	return goGitCloneError(r, err)
+}
+
+// goGitCloneCommit clones the whole history of the repository into dir and checks out the commit of its ref
+func goGitCloneCommit(ctx context.Context, r Repository, dir string, opts *git.CloneOptions) error {
+	slog.Info("cloning with go-git", "repository", r.Name, "url", redactCredentials(opts.URL), "ref", r.Ref)
+	repo, err := git.PlainCloneContext(ctx, dir, false, opts)
+	if err != nil {
+		return goGitCloneError(r, err)
+	}
+	_, checkoutSpan := startSpan(ctx, "checkout", "repository", r.Name, "ref", r.Ref)
+	worktree, err := repo.Worktree()
+	if err == nil {
+		err = worktree.Checkout(&git.CheckoutOptions{Hash: plumbing.NewHash(r.Ref)})
+	}
+	checkoutSpan.end(err)
+	if err != nil {
+		return fmt.Errorf("unable to check out %s of %s: %w", r.Ref, r.Name, err)
+	}
+	return nil
+}
+
+func goGitCloneError(r Repository, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("unable to clone %s with go-git: %w", r.Name, redactError(err))
+}
+
+// isRefNotFound reports whether the clone failed because the remote has no reference with the name
+func isRefNotFound(err error) bool {
+	var noMatch git.NoMatchingRefSpecError
+	return errors.Is(err, plumbing.ErrReferenceNotFound) || errors.As(err, &noMatch)
+}
+
+// emptyDir removes what a failed clone left in dir, but not dir itself, which cloneDir may have created
+func emptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// goGitAuth returns how go-git authenticates to the repository, the same auth gitAuthEnv configures for the git binary:
+// the token as basic auth for https urls and the ssh key for ssh urls, the host key is not checked. nil uses the
+// defaults of go-git, which is the ssh agent for ssh urls.
+func goGitAuth(r Repository, cfg Config) (transport.AuthMethod, error) {
+	url := cloneURL(r.Url, cfg.CloneProtocol)
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url '%s': %w", redactCredentials(url), err)
+	}
+	switch endpoint.Protocol {
+	case "https":
+		tokenEnv := firstNonEmpty(r.TokenEnv, cfg.TokenEnv)
+		if tokenEnv == "" {
+			return nil, nil
+		}
+		token := os.Getenv(tokenEnv)
+		if token == "" {
+			slog.Warn("the token environment variable is not set", "repository", r.Name, "token_env", tokenEnv)
+			return nil, nil
+		}
+		return &http.BasicAuth{Username: firstNonEmpty(r.TokenUsername, TokenUsername), Password: token}, nil
+	case "ssh":
+		sshKeyPath := firstNonEmpty(r.SSHKeyPath, cfg.SSHKeyPath)
+		if sshKeyPath == "" {
+			return nil, nil
+		}
+		keys, err := ssh.NewPublicKeysFromFile(firstNonEmpty(endpoint.User, "git"), sshKeyPath, "")
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ssh key '%s': %w", sshKeyPath, err)
+		}
+		keys.HostKeyCallback = gossh.InsecureIgnoreHostKey()
+		return keys, nil
+	}
+	return nil, nil
+}
+
+// goGitHeadCommit returns the SHA of the commit checked out in the repository at path, which is empty when the
+// repository has no commits. path may be a dir inside the repository.
+func goGitHeadCommit(path string) (string, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve the commit of the clone: %w", err)
+	}
+	head, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve the commit of the clone: %w", err)
+	}
+	return head.Hash().String(), nil
+}
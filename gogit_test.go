@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	IS "github.com/matryer/is"
+)
+
+// newTestRepoWithHistory returns a test repository with a commit before its head commit, tagged v1, and the SHA of that commit
+func newTestRepoWithHistory(t *testing.T) (string, string) {
+	t.Helper()
+	dir := newTestRepo(t, map[string]string{"words.txt": "fell"})
+	first, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "more.txt"), []byte("fell fell"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"tag", "v1"},
+		{"add", "--all"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--message=more"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+		}
+	}
+	return dir, strings.TrimSpace(string(first))
+}
+
+func TestAnalyzeRepoWithGoGit(t *testing.T) {
+	is := IS.New(t)
+	dir, first := newTestRepoWithHistory(t)
+	head, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	is.NoErr(err)
+	cfg := Config{SearchWords: []string{"fell"}, GitClient: GitClientGoGit}
+
+	app, err := analyzeRepo(context.Background(), Repository{Name: "words", Url: dir}, cfg)
+	is.NoErr(err)
+	is.Equal(strings.TrimSpace(string(head)), app.Commit)
+	is.Equal(3, app.CountSum)
+
+	app, err = analyzeRepo(context.Background(), Repository{Name: "words", Url: dir, Ref: "v1"}, cfg) // a tag
+	is.NoErr(err)
+	is.Equal(first, app.Commit)
+	is.Equal(1, app.CountSum)
+
+	app, err = analyzeRepo(context.Background(), Repository{Name: "words", Url: dir, Ref: first}, cfg) // a commit SHA
+	is.NoErr(err)
+	is.Equal(first, app.Commit)
+	is.Equal(1, app.CountSum)
+}
+
+func TestGoGitCloneMissingRef(t *testing.T) {
+	is := IS.New(t)
+	dir, _ := newTestRepoWithHistory(t)
+	clone := t.TempDir()
+
+	err := goGitClone(context.Background(), Repository{Name: "words", Url: dir, Ref: "missing"}, clone, Config{})
+
+	is.True(err != nil)
+	entries, _ := os.ReadDir(clone)
+	is.Equal(0, len(entries)) // the failed attempts are cleaned up
+}
+
+func TestGoGitAuthPerRepository(t *testing.T) {
+	is := IS.New(t)
+	t.Setenv("GITHUB_TOKEN", "ghp_global")
+	t.Setenv("TEAM_TOKEN", "ghp_team")
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519")
+	is.NoErr(exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", keyPath).Run())
+	cfg := Config{TokenEnv: "GITHUB_TOKEN", SSHKeyPath: "/keys/global"}
+
+	auth, err := goGitAuth(Repository{Url: "https://github.com/team/repo.git", TokenEnv: "TEAM_TOKEN", TokenUsername: "team"}, cfg)
+	is.NoErr(err)
+	is.Equal(&http.BasicAuth{Username: "team", Password: "ghp_team"}, auth)
+
+	auth, err = goGitAuth(Repository{Url: "https://github.com/team/repo.git"}, cfg)
+	is.NoErr(err)
+	is.Equal(&http.BasicAuth{Username: TokenUsername, Password: "ghp_global"}, auth)
+
+	auth, err = goGitAuth(Repository{Url: "git@github.com:team/repo.git", SSHKeyPath: keyPath}, cfg)
+	is.NoErr(err)
+	keys, ok := auth.(*ssh.PublicKeys)
+	is.True(ok) // no token for ssh urls
+	is.Equal("git", keys.User)
+
+	_, err = goGitAuth(Repository{Url: "git@github.com:team/repo.git"}, cfg)
+	is.True(err != nil) // the global key does not exist
+
+	auth, err = goGitAuth(Repository{Url: "https://github.com/team/repo.git"}, Config{CloneProtocol: CloneProtocolSSH, SSHKeyPath: keyPath})
+	is.NoErr(err)
+	_, ok = auth.(*ssh.PublicKeys)
+	is.True(ok) // the url is rewritten before the auth is chosen
+}
+
+func TestCheckBinariesWithGoGit(t *testing.T) {
+	is := IS.New(t)
+	missingGit := "git-binary-which-does-not-exist"
+	remote := []Repository{{Name: "remote", Url: "https://example.com/remote.git"}}
+
+	is.NoErr(checkBinaries(Config{GitBinary: missingGit, GitClient: GitClientGoGit, Repositories: remote}))
+	is.True(checkBinaries(Config{GitBinary: missingGit, GitClient: GitClientGoGit, Repositories: remote, CacheDir: "/tmp/cache"}) != nil)
+	is.True(checkBinaries(Config{GitBinary: missingGit, GitClient: GitClientGoGit, Repositories: remote, StateFile: "state.json"}) != nil)
+}
+
+func TestValidateGitClient(t *testing.T) {
+	is := IS.New(t)
+
+	is.NoErr(validateGitClient(""))
+	is.NoErr(validateGitClient(GitClientBinary))
+	is.NoErr(validateGitClient(GitClientGoGit))
+	is.True(validateGitClient("libgit2") != nil)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	GrepperIgnoreFile = ".grepperignore"
+)
+
+// readGrepperIgnore reads the .grepperignore file in path, which the owners of a repository can commit to exclude
+// dirs and files from the search. Every line is a glob, a glob ending with a '/' excludes dirs like exclude_dirs and
+// the other globs exclude files like exclude_files. Blank lines and lines starting with '#' are skipped.
+// A missing file excludes nothing.
+func readGrepperIgnore(dir string) (excludeDirs, excludeFiles []string, err error) {
+	content, err := os.ReadFile(filepath.Join(dir, GrepperIgnoreFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		glob := strings.TrimSuffix(line, "/")
+		if _, err := path.Match(glob, ""); err != nil || glob == "" {
+			return nil, nil, fmt.Errorf("invalid glob '%s' on line %d of %s", line, n, GrepperIgnoreFile)
+		}
+		if strings.HasSuffix(line, "/") {
+			excludeDirs = append(excludeDirs, glob)
+		} else {
+			excludeFiles = append(excludeFiles, glob)
+		}
+	}
+	return excludeDirs, excludeFiles, scanner.Err()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadGrepperIgnore(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, GrepperIgnoreFile), []byte("# owned by the payments team\nfixtures/\n\n*_gen.go\nlegacy/*.sql\n"), 0644))
+
+	excludeDirs, excludeFiles, err := readGrepperIgnore(dir)
+
+	is.NoErr(err)
+	is.Equal([]string{"fixtures"}, excludeDirs)
+	is.Equal([]string{"*_gen.go", "legacy/*.sql"}, excludeFiles)
+
+	excludeDirs, excludeFiles, err = readGrepperIgnore(t.TempDir())
+	is.NoErr(err)
+	is.Equal(0, len(excludeDirs)+len(excludeFiles))
+
+	is.NoErr(os.WriteFile(filepath.Join(dir, GrepperIgnoreFile), []byte("[\n"), 0644))
+	_, _, err = readGrepperIgnore(dir)
+	is.True(err != nil)
+}
+
+func TestAnalyzePathGrepperIgnore(t *testing.T) {
+	is := IS.New(t)
+	repo := newTestRepo(t, map[string]string{
+		GrepperIgnoreFile:       "fixtures/\n*_gen.go\n",
+		"main.go":               "oldAPI",
+		"types_gen.go":          "oldAPI",
+		"fixtures/old.json":     "oldAPI",
+		"pkg/fixtures/old.json": "oldAPI",
+	})
+	cfg := Config{SearchWords: []string{"oldAPI"}}
+
+	app, err := analyzePath(context.Background(), Repository{Name: "repo"}, cfg, repo)
+	is.NoErr(err)
+	is.Equal(1, app.CountSum)
+
+	no := false
+	cfg.UseGrepperIgnore = &no
+	app, err = analyzePath(context.Background(), Repository{Name: "repo"}, cfg, repo)
+	is.NoErr(err)
+	is.Equal(4, app.CountSum)
+}
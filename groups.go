@@ -0,0 +1,42 @@
+package main
+
+// groupCounts sums the word counts per group of the search words, search words without a group are left out
+func (cfg Config) groupCounts(wordCounts map[string]int) map[string]int {
+	return cfg.sumWordCountsBy(wordCounts, func(word SearchWord) string { return word.Group })
+}
+
+// calculateGroupTotals sums the group counts of all applications per group
+func calculateGroupTotals(rf ResultFile) map[string]int {
+	return sumApplicationCounts(rf, func(app Application) map[string]int { return app.GroupCounts })
+}
+
+// sumWordCountsBy sums the word counts per key of the options of their search word, search words with an empty key
+// are left out. It returns nil when nothing is summed.
+func (cfg Config) sumWordCountsBy(wordCounts map[string]int, key func(word SearchWord) string) map[string]int {
+	var result map[string]int
+	for word, count := range wordCounts {
+		k := key(cfg.WordOptions[word])
+		if k == "" {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]int)
+		}
+		result[k] += count
+	}
+	return result
+}
+
+// sumApplicationCounts sums the counts of all applications per key, it returns nil when nothing is summed
+func sumApplicationCounts(rf ResultFile, counts func(app Application) map[string]int) map[string]int {
+	var result map[string]int
+	for _, app := range rf.Applications {
+		for k, count := range counts(app) {
+			if result == nil {
+				result = make(map[string]int)
+			}
+			result[k] += count
+		}
+	}
+	return result
+}
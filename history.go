@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// MaxHistoryCommits bounds the number of commits searched for the history of a repository, as every commit is searched separately
+const MaxHistoryCommits = 100
+
+// HistoryConfig enables searching the last commits of a repository
+type HistoryConfig struct {
+	Commits int `json:"commits"`
+}
+
+// HistoryEntry is the count sum of the search words at one commit
+type HistoryEntry struct {
+	Commit string `json:"commit"`
+	Count  int    `json:"count"`
+}
+
+func validateHistory(r Repository) error {
+	if r.History == nil {
+		return nil
+	}
+	if r.History.Commits < 1 || r.History.Commits > MaxHistoryCommits {
+		return fmt.Errorf("history commits of repository '%s' must be between 1 and %d", r.Name, MaxHistoryCommits)
+	}
+	return nil
+}
+
+// repoHistory counts the matches of the search words at each of the last commits of the repo at path, newest first
+func repoHistory(ctx context.Context, gitBinary, path string, commits int, group searchWordGroup, opts grepOptions) ([]HistoryEntry, error) {
+	revListCmd := exec.CommandContext(ctx, gitBinary, "-C", path, "rev-list", fmt.Sprintf("--max-count=%d", commits), "HEAD")
+	logCommand(revListCmd.Args)
+	out, err := revListCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list commits: %w", err)
+	}
+
+	return assembleHistory(parseNameOnly(string(out)), func(commit string) (string, error) {
+		grepCmd := gitGrepCommand(ctx, gitBinary, path, commit, group, opts)
+		logCommand(grepCmd.Args)
+		out, err := grepCmd.Output()
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) && exitError.ExitCode() == GrepErrorCodeNoMatches {
+			return "", nil
+		}
+		return string(out), err
+	})
+}
+
+// assembleHistory builds the history of the commits from the output of 'git grep' at each commit
+func assembleHistory(commits []string, gitGrep func(commit string) (string, error)) ([]HistoryEntry, error) {
+	var result []HistoryEntry
+	for _, commit := range commits {
+		out, err := gitGrep(commit)
+		if err != nil {
+			return nil, fmt.Errorf("unable to git grep commit %s: %w", commit, err)
+		}
+		result = append(result, HistoryEntry{Commit: commit, Count: countGitGrepOutput(out)})
+	}
+	return result, nil
+}
+
+// gitGrepCommand builds a 'git grep' command searching the search words at the commit the same way as grep does,
+// skipping the dirs, files and binary files of the options
+func gitGrepCommand(ctx context.Context, gitBinary, path, commit string, group searchWordGroup, opts grepOptions) *exec.Cmd {
+	args := []string{"-C", path, "grep", "--only-matching"}
+	if opts.SearchBinary {
+		args = append(args, "--text")
+	} else {
+		args = append(args, "-I")
+	}
+	if !group.CaseSensitive {
+		args = append(args, "--ignore-case")
+	}
+	if group.WordBoundary {
+		args = append(args, "--word-regexp")
+	}
+	if group.FixedStrings {
+		args = append(args, "--fixed-strings")
+	}
+	args = append(args, grepRegexEngineArgs(group.RegexEngine, group.FixedStrings)...)
+	for _, word := range group.Words {
+		args = append(args, "-e", word)
+	}
+	args = append(args, commit, "--")
+	for _, dir := range opts.ExcludeDirs {
+		dir = strings.TrimSuffix(dir, "/")
+		if strings.Contains(dir, "/") {
+			args = append(args, ":(exclude,glob)"+dir+"/**")
+		} else {
+			args = append(args, ":(exclude,glob)**/"+dir+"/**")
+		}
+	}
+	for _, file := range opts.ExcludeFiles {
+		if strings.Contains(file, "/") {
+			args = append(args, ":(exclude,glob)"+file)
+		} else {
+			args = append(args, ":(exclude,glob)**/"+file)
+		}
+	}
+	return exec.CommandContext(ctx, gitBinary, args...)
+}
+
+// countGitGrepOutput counts the matches in the output of 'git grep --only-matching', which is one match per line
+func countGitGrepOutput(out string) int {
+	return len(parseNameOnly(out))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	IS "github.com/matryer/is"
+	"testing"
+)
+
+func TestGitGrepCommand(t *testing.T) {
+	is := IS.New(t)
+
+	cmd := gitGrepCommand(context.Background(), DefaultGitBinary, "/tmp/clone", "3f2a1b", searchWordGroup{Words: []string{"cmd", "use"}}, grepOptions{ExcludeDirs: []string{"node_modules", "pkg/generated"}, ExcludeFiles: []string{"*.pb.go", "api/gen.go"}})
+
+	is.Equal([]string{
+		"git", "-C", "/tmp/clone", "grep", "--only-matching", "-I", "--ignore-case", "-e", "cmd", "-e", "use",
+		"3f2a1b", "--", ":(exclude,glob)**/node_modules/**", ":(exclude,glob)pkg/generated/**",
+		":(exclude,glob)**/*.pb.go", ":(exclude,glob)api/gen.go",
+	}, cmd.Args)
+}
+
+func TestAssembleHistory(t *testing.T) {
+	is := IS.New(t)
+	outputs := map[string]string{
+		"c3": "c3:main.go:cmd\nc3:main.go:use\nc3:README.md:USE\n",
+		"c2": "c2:main.go:cmd\n",
+		"c1": "",
+	}
+
+	history, err := assembleHistory([]string{"c3", "c2", "c1"}, func(commit string) (string, error) {
+		return outputs[commit], nil
+	})
+
+	is.NoErr(err)
+	is.Equal([]HistoryEntry{{Commit: "c3", Count: 3}, {Commit: "c2", Count: 1}, {Commit: "c1", Count: 0}}, history)
+}
+
+func TestAssembleHistoryError(t *testing.T) {
+	is := IS.New(t)
+
+	_, err := assembleHistory([]string{"c1"}, func(commit string) (string, error) {
+		return "", errors.New("bad object")
+	})
+
+	is.True(err != nil)
+}
+
+func TestValidateHistory(t *testing.T) {
+	is := IS.New(t)
+
+	is.NoErr(validateHistory(Repository{Name: "repo"}))
+	is.NoErr(validateHistory(Repository{Name: "repo", History: &HistoryConfig{Commits: 10}}))
+	is.True(validateHistory(Repository{Name: "repo", History: &HistoryConfig{Commits: 0}}) != nil)
+	is.True(validateHistory(Repository{Name: "repo", History: &HistoryConfig{Commits: MaxHistoryCommits + 1}}) != nil)
+}
+
+func TestRepoHistory(t *testing.T) {
+	is := IS.New(t)
+	repo := newTestRepo(t, map[string]string{"words.txt": "fell FELL", "node_modules/lib.js": "fell"})
+
+	history, err := repoHistory(context.Background(), DefaultGitBinary, repo, 5, searchWordGroup{Words: []string{"fell"}}, grepOptions{ExcludeDirs: []string{"node_modules"}})
+
+	is.NoErr(err)
+	is.Equal(1, len(history)) // the test repo has one commit
+	is.Equal(2, history[0].Count)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"os"
+	"sort"
+)
+
+const FormatHTML = "html"
+
+// htmlReport is the data of the html template
+type htmlReport struct {
+	Result ResultFile
+	// Words are the word totals, largest first
+	Words []htmlBar
+}
+
+// htmlBar is one bar of a bar chart, Percent is relative to the largest bar
+type htmlBar struct {
+	Label   string
+	Count   int
+	Percent int
+}
+
+// htmlBars returns a bar per count, largest first
+func htmlBars(counts map[string]int) []htmlBar {
+	var bars []htmlBar
+	largest := 0
+	for label, count := range counts {
+		bars = append(bars, htmlBar{Label: label, Count: count})
+		if count > largest {
+			largest = count
+		}
+	}
+	sort.Slice(bars, func(i, j int) bool {
+		if bars[i].Count != bars[j].Count {
+			return bars[i].Count > bars[j].Count
+		}
+		return bars[i].Label < bars[j].Label
+	})
+	for i := range bars {
+		if largest > 0 {
+			bars[i].Percent = bars[i].Count * 100 / largest
+		}
+	}
+	return bars
+}
+
+// htmlTemplate is a single page without external resources, so the file can be shared as it is
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>count-fell report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: 0.4em; text-align: left; vertical-align: top; }
+th { cursor: pointer; background: #f4f4f4; user-select: none; }
+td.number { text-align: right; }
+.bar { display: flex; align-items: center; margin: 0.2em 0; }
+.bar .label { width: 15em; overflow: hidden; text-overflow: ellipsis; }
+.bar .fill { background: #4a7bd0; height: 1em; margin-right: 0.5em; }
+.failed { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>count-fell report</h1>
+<p>{{.Result.TotalApplications}} applications, {{.Result.FailedApplications}} failed, total count sum {{.Result.TotalCountSum}}</p>
+{{- if .Words}}
+<h2>Search words</h2>
+{{- range .Words}}
+<div class="bar"><span class="label">{{.Label}}</span><span class="fill" style="width: {{.Percent}}%"></span><span>{{.Count}}</span></div>
+{{- end}}
+{{- end}}
+<h2>Applications</h2>
+<table id="applications">
+<thead><tr><th data-type="text">Application</th><th data-type="text">Status</th><th data-type="number">Count sum</th><th data-type="number">Files</th></tr></thead>
+<tbody>
+{{- range .Result.Applications}}
+<tr>
+<td>{{if .GrepResults}}<details><summary>{{.Name}}</summary><ul>{{range .GrepResults}}<li>{{.FileName}}: {{.Count}}</li>{{end}}</ul></details>{{else}}{{.Name}}{{end}}</td>
+<td{{if .Error}} class="failed" title="{{.Error}}"{{end}}>{{.Status}}</td>
+<td class="number">{{.CountSum}}</td>
+<td class="number">{{len .GrepResults}}</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+document.querySelectorAll("#applications th").forEach(function (th, column) {
+  var ascending = false;
+  th.addEventListener("click", function () {
+    ascending = !ascending;
+    var tbody = document.querySelector("#applications tbody");
+    var rows = Array.prototype.slice.call(tbody.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[column].innerText, y = b.cells[column].innerText;
+      var order = th.dataset.type === "number" ? Number(x) - Number(y) : x.localeCompare(y);
+      return ascending ? order : -order;
+    });
+    rows.forEach(function (row) { tbody.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+func writeHTML(fileName string, rf ResultFile, perm os.FileMode) error {
+	return writeFileAtomic(fileName, perm, func(w io.Writer) error {
+		return htmlTemplate.Execute(w, htmlReport{Result: rf, Words: htmlBars(rf.WordTotals)})
+	})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHTMLBars(t *testing.T) {
+	is := IS.New(t)
+
+	bars := htmlBars(map[string]int{"todo": 1, "fell": 4, "hack": 1})
+
+	is.Equal([]htmlBar{{Label: "fell", Count: 4, Percent: 100}, {Label: "hack", Count: 1, Percent: 25}, {Label: "todo", Count: 1, Percent: 25}}, bars)
+}
+
+func TestWriteHTML(t *testing.T) {
+	is := IS.New(t)
+	resultPath := filepath.Join(t.TempDir(), "report.html")
+	rf := ResultFile{
+		TotalApplications: 2,
+		TotalCountSum:     3,
+		WordTotals:        map[string]int{"fell": 3},
+		Applications: []Application{
+			{Name: "a<b>", Status: StatusOK, CountSum: 3, GrepResults: []GrepResult{{FileName: "main.go", Count: 3}}},
+			{Name: "c", Status: StatusFailed, Error: "unable to clone"},
+		},
+	}
+
+	is.NoErr(writeHTML(resultPath, rf, DefaultOutputFileMode))
+
+	content, err := os.ReadFile(resultPath)
+	is.NoErr(err)
+	html := string(content)
+	is.True(strings.Contains(html, "<summary>a&lt;b&gt;</summary>"))
+	is.True(strings.Contains(html, "<li>main.go: 3</li>"))
+	is.True(strings.Contains(html, `title="unable to clone"`))
+	is.True(strings.Contains(html, "width: 100%"))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const FormatJUnit = "junit"
+
+// junitTestSuites is a result file in the JUnit XML format, which the test summaries of Jenkins and GitLab show
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// applicationViolations returns why the application fails: a critical search word is found, or its count sum
+// exceeds -fail-if-total-above or -fail-if-any-match
+func applicationViolations(app Application, opts options) []string {
+	var violations []string
+	if count := app.SeverityCounts[SeverityCritical]; count > 0 {
+		violations = append(violations, fmt.Sprintf("%d critical matches were found", count))
+	}
+	if opts.FailIfAnyMatch && app.CountSum > 0 {
+		violations = append(violations, fmt.Sprintf("%d matches were found", app.CountSum))
+	}
+	if opts.FailIfTotalAbove != nil && app.CountSum > *opts.FailIfTotalAbove {
+		violations = append(violations, fmt.Sprintf("the count sum %d is above %d", app.CountSum, *opts.FailIfTotalAbove))
+	}
+	return violations
+}
+
+// buildJUnit converts the result to JUnit with every application as a test case. An application which could not be
+// searched is an error and an application which violates a threshold is a failure listing its files.
+func buildJUnit(rf ResultFile, opts options) junitTestSuites {
+	suite := junitTestSuite{Name: "count-fell"}
+	for _, app := range rf.Applications {
+		var files strings.Builder
+		for _, gr := range app.GrepResults {
+			fmt.Fprintf(&files, "%s: %d\n", gr.FileName, gr.Count)
+		}
+		testCase := junitTestCase{Name: app.Name, ClassName: "count-fell"}
+		if app.Status == StatusFailed {
+			testCase.Error = &junitProblem{Message: app.Error, Type: "error"}
+			suite.Errors++
+		} else if violations := applicationViolations(app, opts); len(violations) > 0 {
+			testCase.Failure = &junitProblem{Message: strings.Join(violations, ", "), Type: "threshold", Text: files.String()}
+			suite.Failures++
+		} else {
+			testCase.SystemOut = files.String()
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.Tests = len(suite.TestCases)
+	return junitTestSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Suites:   []junitTestSuite{suite},
+	}
+}
+
+func writeJUnit(fileName string, rf ResultFile, opts options, perm os.FileMode) error {
+	file, err := xml.MarshalIndent(buildJUnit(rf, opts), "", " ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fileName, perm, func(w io.Writer) error {
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		_, err := w.Write(file)
+		return err
+	})
+}
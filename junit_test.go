@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/xml"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildJUnit(t *testing.T) {
+	is := IS.New(t)
+	five := 5
+	rf := ResultFile{Applications: []Application{
+		{Name: "clean", Status: StatusOK, CountSum: 2, GrepResults: []GrepResult{{FileName: "main.go", Count: 2}}},
+		{Name: "noisy", Status: StatusOK, CountSum: 6, GrepResults: []GrepResult{{FileName: "a.go", Count: 4}, {FileName: "b.go", Count: 2}}},
+		{Name: "secret", Status: StatusOK, CountSum: 1, SeverityCounts: map[string]int{SeverityCritical: 1}},
+		{Name: "broken", Status: StatusFailed, Error: "unable to clone"},
+	}}
+
+	suites := buildJUnit(rf, options{FailIfTotalAbove: &five})
+
+	is.Equal(4, suites.Tests)
+	is.Equal(2, suites.Failures)
+	is.Equal(1, suites.Errors)
+	cases := suites.Suites[0].TestCases
+	is.True(cases[0].Failure == nil && cases[0].Error == nil)
+	is.Equal(&junitProblem{Message: "the count sum 6 is above 5", Type: "threshold", Text: "a.go: 4\nb.go: 2\n"}, cases[1].Failure)
+	is.Equal("1 critical matches were found", cases[2].Failure.Message)
+	is.Equal(&junitProblem{Message: "unable to clone", Type: "error"}, cases[3].Error)
+}
+
+func TestRunJUnit(t *testing.T) {
+	is := IS.New(t)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": ["fell"]}`), 0644))
+	resultPath := filepath.Join(t.TempDir(), "results.xml")
+
+	code := run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJUnit, Dir: "./testdata", FailIfAnyMatch: true}, nil)
+
+	is.Equal(ExitCodeThreshold, code)
+	content, err := os.ReadFile(resultPath)
+	is.NoErr(err)
+	is.True(strings.HasPrefix(string(content), xml.Header))
+	var suites junitTestSuites
+	is.NoErr(xml.Unmarshal(content, &suites))
+	is.Equal(1, suites.Tests)
+	is.Equal(1, suites.Failures)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// logCommand logs the command which is about to run, the credentials in its urls are redacted
+func logCommand(args []string) {
+	slog.Info("running command", "command", strings.Join(redactArgs(args), " "))
+}
+
+// parseLogLevel parses debug, info, warn or error
+func parseLogLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return l, fmt.Errorf("unknown log level '%s', must be debug, info, warn or error", level)
+	}
+	return l, nil
+}
+
+// logLevel is the lowest level which is logged, quiet leaves out everything below warnings
+func logLevel(level string, quiet bool) (slog.Level, error) {
+	l, err := parseLogLevel(level)
+	if err != nil {
+		return l, err
+	}
+	if quiet && l < slog.LevelWarn {
+		l = slog.LevelWarn
+	}
+	return l, nil
+}
+
+// newLogger returns a logger writing to w in the format, text or json, which leaves out the records below the level
+func newLogger(w io.Writer, level slog.Level, format string) (*slog.Logger, error) {
+	handlerOptions := &slog.HandlerOptions{Level: level}
+	switch format {
+	case LogFormatText:
+		return slog.New(slog.NewTextHandler(w, handlerOptions)), nil
+	case LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, handlerOptions)), nil
+	}
+	return nil, fmt.Errorf("unknown log format '%s', must be %s or %s", format, LogFormatText, LogFormatJSON)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	IS "github.com/matryer/is"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewLogger(t *testing.T) {
+	is := IS.New(t)
+	var out bytes.Buffer
+
+	logger, err := newLogger(&out, slog.LevelWarn, LogFormatJSON)
+
+	is.NoErr(err)
+	logger.Info("running command", "command", "git clone")
+	logger.Warn("repository has no files, is the url correct?", "repository", "a")
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	is.Equal(1, len(lines)) // the info record is below the level
+	var record map[string]interface{}
+	is.NoErr(json.Unmarshal([]byte(lines[0]), &record))
+	is.Equal("WARN", record["level"])
+	is.Equal("a", record["repository"])
+}
+
+func TestNewLoggerErrors(t *testing.T) {
+	is := IS.New(t)
+
+	_, err := logLevel("verbose", false)
+	is.True(err != nil)
+	_, err = newLogger(&bytes.Buffer{}, slog.LevelInfo, "xml")
+	is.True(err != nil)
+}
+
+func TestLogLevelQuiet(t *testing.T) {
+	is := IS.New(t)
+
+	level, err := logLevel("debug", true)
+	is.NoErr(err)
+	is.Equal(slog.LevelWarn, level)
+	level, err = logLevel("error", true)
+	is.NoErr(err)
+	is.Equal(slog.LevelError, level)
+	level, err = logLevel("info", false)
+	is.NoErr(err)
+	is.Equal(slog.LevelInfo, level)
+}
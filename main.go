@@ -1,16 +1,28 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
-	"sync"
+	"syscall"
+	"time"
 )
 
 const (
@@ -18,77 +30,654 @@ const (
 	ResultFilePath = "./results.json"
 
 	GrepErrorCodeNoMatches = 1
+
+	maxGrepLineLength = 1024 * 1024
+
+	ScoreModeCount   = "count"
+	ScoreModeDensity = "density"
+
+	FileOrderName  = "name"
+	FileOrderCount = "count"
+
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	// FormatJSONL is another name of FormatNDJSON, which log pipelines know JSON Lines as
+	FormatJSONL = "jsonl"
+
+	DefaultOutputFileMode os.FileMode = 0664
+
+	DefaultGrepBinary = "grep"
+	DefaultGitBinary  = "git"
+
+	NoExtension = "(none)"
+
+	// StatusOK is the status of an application which was searched, StatusEmpty of one which repository has no files
+	// and StatusFailed of one which could not be searched, its error is saved as well
+	StatusOK     = "ok"
+	StatusEmpty  = "empty"
+	StatusFailed = "failed"
+
+	GitDir = ".git"
+)
+
+const (
+	ExitCodeSuccess        = 0
+	ExitCodeFailure        = 1
+	ExitCodeConfigError    = 2
+	ExitCodePartialFailure = 3
+	ExitCodeTotalFailure   = 4
+	ExitCodeCriticalMatch  = 5
+	ExitCodeThreshold      = 6
 )
 
 type Config struct {
-	SearchWords  []string     `json:"search_words"`
-	ExcludeDirs  []string     `json:"exclude_dirs"`
-	Repositories []Repository `json:"repositories"`
+	SearchWords          []string     `json:"search_words"`
+	Presets              []string     `json:"presets"`
+	CaseSensitive        bool         `json:"case_sensitive"`
+	WordBoundary         bool         `json:"word_boundary"`
+	Regex                bool         `json:"regex"`
+	RegexEngine          string       `json:"regex_engine"`
+	ExcludeDirs          []string     `json:"exclude_dirs"`
+	ExcludePatterns      []string     `json:"exclude_patterns"`
+	ExcludeFiles         []string     `json:"exclude_files"`
+	IncludeGlobs         []string     `json:"include_globs"`
+	IncludeExtensions    []string     `json:"include_extensions"`
+	SkipBinary           *bool        `json:"skip_binary"`
+	MaxFileSize          string       `json:"max_file_size"`
+	RespectGitignore     bool         `json:"respect_gitignore"`
+	UseGrepperIgnore     *bool        `json:"use_grepperignore"`
+	ListSkippedFiles     bool         `json:"list_skipped_files"`
+	KeepClones           bool         `json:"keep_clones"`
+	FullHistory          bool         `json:"full_history"`
+	DeterministicTemp    bool         `json:"deterministic_temp"`
+	CacheDir             string       `json:"cache_dir"`
+	CacheMaxAge          string       `json:"cache_max_age"`
+	StateFile            string       `json:"state_file"`
+	BaselineFile         string       `json:"baseline_file"`
+	UTF8Only             bool         `json:"utf8_only"`
+	IntraRepoConcurrency int          `json:"intra_repo_concurrency"`
+	MaxConcurrency       int          `json:"max_concurrency"`
+	SSHKeyPath           string       `json:"ssh_key_path"`
+	TokenEnv             string       `json:"token_env"`
+	ScanGitDir           bool         `json:"scan_git_dir"`
+	FollowSymlinks       bool         `json:"follow_symlinks"`
+	AppendMode           bool         `json:"append_mode"`
+	FailFast             bool         `json:"fail_fast"`
+	SummaryOnly          bool         `json:"summary_only"`
+	IncludeMatches       bool         `json:"include_matches"`
+	ContextLines         int          `json:"context_lines"`
+	WordRepoCoverage     bool         `json:"word_repo_coverage"`
+	ScoreMode            string       `json:"score_mode"`
+	FileOrder            string       `json:"file_order"`
+	Stats                bool         `json:"stats"`
+	MaxCountPerFile      int          `json:"max_count_per_file"`
+	SearchArchives       bool         `json:"search_archives"`
+	WebhookURL           string       `json:"webhook_url"`
+	WebhookTimeout       string       `json:"webhook_timeout"`
+	PushgatewayURL       string       `json:"pushgateway_url"`
+	PushgatewayJob       string       `json:"pushgateway_job"`
+	OTLPEndpoint         string       `json:"otlp_endpoint"`
+	Multiline            bool         `json:"multiline"`
+	SearchBackend        string       `json:"search_backend"`
+	CloneProtocol        string       `json:"clone_protocol"`
+	OutputFileMode       string       `json:"output_file_mode"`
+	GrepBinary           string       `json:"grep_binary"`
+	GitBinary            string       `json:"git_binary"`
+	GitClient            string       `json:"git_client"`
+	MatcherCommand       []string     `json:"matcher_command"`
+	Repositories         []Repository `json:"repositories"`
+	RepositoriesFile     string       `json:"repositories_file"`
+	GitHubOrg            string       `json:"github_org"`
+	GitHubTopic          string       `json:"github_topic"`
+	GitHubNameFilter     string       `json:"github_name_filter"`
+	GitHubAPIURL         string       `json:"github_api_url"`
+	GitLabGroup          string       `json:"gitlab_group"`
+	GitLabURL            string       `json:"gitlab_url"`
+	GitLabTokenEnv       string       `json:"gitlab_token_env"`
+	BitbucketWorkspace   string       `json:"bitbucket_workspace"`
+	BitbucketProject     string       `json:"bitbucket_project"`
+	BitbucketURL         string       `json:"bitbucket_url"`
+	BitbucketTokenEnv    string       `json:"bitbucket_token_env"`
+	AzureDevOpsOrg       string       `json:"azure_devops_org"`
+	AzureDevOpsProject   string       `json:"azure_devops_project"`
+	AzureDevOpsURL       string       `json:"azure_devops_url"`
+	AzureDevOpsTokenEnv  string       `json:"azure_devops_token_env"`
+	GiteaURL             string       `json:"gitea_url"`
+	GiteaOrg             string       `json:"gitea_org"`
+	GiteaUser            string       `json:"gitea_user"`
+	GiteaTokenEnv        string       `json:"gitea_token_env"`
+	SkipArchived         bool         `json:"skip_archived"`
+	SkipForks            bool         `json:"skip_forks"`
+	DiscoveryInclude     []string     `json:"discovery_include"`
+	DiscoveryExclude     []string     `json:"discovery_exclude"`
+	// WordOptions are the search words given as SearchWord objects, which override the options of the config
+	WordOptions map[string]SearchWord `json:"-"`
 }
 type Repository struct {
-	Name        string   `json:"name"`
-	Url         string   `json:"url"`
-	ExcludeDirs []string `json:"exclude_dirs"`
+	Name string `json:"name"`
+	Url  string `json:"url"`
+	// Path is a local dir which is searched instead of cloning a url
+	Path              string   `json:"path,omitempty"`
+	ExcludeDirs       []string `json:"exclude_dirs"`
+	ExcludeFiles      []string `json:"exclude_files,omitempty"`
+	IncludeGlobs      []string `json:"include_globs,omitempty"`
+	IncludeExtensions []string `json:"include_extensions,omitempty"`
+	ChangedSince      string   `json:"changed_since"`
+	SSHKeyPath        string   `json:"ssh_key_path,omitempty"`
+	TokenEnv          string   `json:"token_env,omitempty"`
+	// TokenUsername is the user name the token is sent with, TokenUsername when empty
+	TokenUsername string         `json:"token_username,omitempty"`
+	History       *HistoryConfig `json:"history,omitempty"`
+	Refs          []string       `json:"refs,omitempty"`
+	// Ref is the branch, tag or full commit SHA the repository is cloned at, the default branch when empty
+	Ref string `json:"ref,omitempty"`
+	// Branch is the same as Ref
+	Branch string `json:"branch,omitempty"`
+	// Subdirs are searched as their own applications, see analyzeCheckout
+	Subdirs []Subdir `json:"subdirs,omitempty"`
+	// subdirExcludes are the subdirs skipped when searching the rest of the repository, which unlike the exclude_dirs
+	// of the config are not warned about
+	subdirExcludes []string
 }
 type ResultFile struct {
-	TotalApplications int           `json:"total_applications"`
-	SearchWords       []string      `json:"search_words"`
-	TotalCountSum     int           `json:"total_count_sum"`
-	Applications      []Application `json:"applications"`
+	Metadata              *RunMetadata   `json:"metadata,omitempty"`
+	TotalApplications     int            `json:"total_applications"`
+	SucceededApplications int            `json:"succeeded_applications"`
+	FailedApplications    int            `json:"failed_applications"`
+	EmptyApplications     int            `json:"empty_applications"`
+	SearchWords           []string       `json:"search_words"`
+	TotalCountSum         int            `json:"total_count_sum"`
+	ExtensionTotals       map[string]int `json:"extension_totals"`
+	WordTotals            map[string]int `json:"word_totals,omitempty"`
+	GroupTotals           map[string]int `json:"group_totals,omitempty"`
+	SeverityTotals        map[string]int `json:"severity_totals,omitempty"`
+	TechDebt              []TechDebt     `json:"tech_debt,omitempty"`
+	NewMatches            []NewMatch     `json:"new_matches,omitempty"`
+	WordCoverage          []WordCoverage `json:"word_coverage,omitempty"`
+	Errors                []string       `json:"errors,omitempty"`
+	Applications          []Application  `json:"applications"`
 }
 type Application struct {
-	Name        string       `json:"name"`
-	CountSum    int          `json:"count_sum"`
-	GrepResults []GrepResult `json:"grep_results"`
+	Name         string         `json:"name"`
+	Status       string         `json:"status"`
+	Ref          string         `json:"ref,omitempty"`
+	Commit       string         `json:"commit,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	CountSum     int            `json:"count_sum"`
+	FilesSkipped int            `json:"files_skipped,omitempty"`
+	SkippedFiles []string       `json:"skipped_files,omitempty"`
+	LinesScanned int            `json:"lines_scanned,omitempty"`
+	Density      float64        `json:"density,omitempty"`
+	ClonePath    string         `json:"clone_path,omitempty"`
+	GrepResults  []GrepResult   `json:"grep_results,omitempty"`
+	History      []HistoryEntry `json:"history,omitempty"`
+	// WordCounts is the count sum per search word
+	WordCounts map[string]int `json:"word_counts,omitempty"`
+	// GroupCounts is the count sum per group of search words
+	GroupCounts map[string]int `json:"group_counts,omitempty"`
+	// SeverityCounts is the count sum per severity of search words
+	SeverityCounts map[string]int `json:"severity_counts,omitempty"`
+	// Stats are the timings and the size of the repository
+	Stats *RepoStats `json:"stats,omitempty"`
+	// Subdirs are the applications of the subdirs of a monorepo, they are listed next to the application in the result
+	Subdirs []Application `json:"subdirs,omitempty"`
 }
 type GrepResult struct {
 	FileName string `json:"file_name"`
 	Count    int    `json:"count"`
+	// Truncated is set when the count was clamped to max_count_per_file
+	Truncated bool `json:"truncated,omitempty"`
+	// Matches are the locations of the matches, they are only set with include_matches
+	Matches []Match `json:"matches,omitempty"`
+	// Words is the count per search word
+	Words map[string]int `json:"words,omitempty"`
+}
+
+// grepOptions narrows down which files grep searches
+type grepOptions struct {
+	// Binary is the name or path of grep, DefaultGrepBinary is used when empty
+	Binary       string
+	ExcludeDirs  []string
+	ExcludeFiles []string
+	IncludeGlobs []string
+	// Paths relative to the searched path, the whole path is searched when empty
+	Paths []string
+	// ScanGitDir searches the .git dir as well, it is excluded by default
+	ScanGitDir bool
+	// FollowSymlinks follows the symlinks in the searched path, they are skipped by default
+	FollowSymlinks bool
+	// CaseSensitive matches the search words case-sensitive, they are matched case-insensitive by default
+	CaseSensitive bool
+	// Multiline matches the search words across lines, only the native search backend supports it
+	Multiline bool
+	// WordBoundary only matches the search words as whole words
+	WordBoundary bool
+	// FixedStrings matches the search words literally, they are regular expressions by default
+	FixedStrings bool
+	// RegexEngine is the regex engine the search words are matched with, RegexEngineBasic when empty
+	RegexEngine string
+	// SearchBinary searches binary files as text, they are skipped by default
+	SearchBinary bool
+	// MaxFileSize skips the files larger than this number of bytes, no file is skipped when 0
+	MaxFileSize int64
+}
+
+// options are the options given on the command line
+type options struct {
+	ConfigPath string
+	ResultPath string
+	Format     string
+	// Dir is searched instead of the repositories in the config when set
+	Dir string
+	// MaxConcurrency overrides max_concurrency of the config when set
+	MaxConcurrency int
+	// CacheDir overrides cache_dir of the config when set
+	CacheDir string
+	// WithLines sets include_matches of the config
+	WithLines bool
+	// ExtraOutputs are more files the result is saved to, see outputs
+	ExtraOutputs []string
+	// Baseline saves the matches as the baseline to ResultPath instead of saving the result
+	Baseline bool
+	// CompareTo is a previous result file, the files which count increased since are listed as new matches
+	CompareTo string
+	// FailIfTotalAbove exits with ExitCodeThreshold when the total count sum is above it, when set
+	FailIfTotalAbove *int
+	// FailIfAnyMatch exits with ExitCodeThreshold when anything is found
+	FailIfAnyMatch bool
+	// TemplatePath is the text/template file of the template format
+	TemplatePath string
+	// Top is the number of applications in the markdown format and the summary table, all of them when 0
+	Top int
+	// LogLevel is the lowest level which is logged, debug, info, warn or error
+	LogLevel string
+	// LogFormat is the format of the log, text or json
+	LogFormat string
+	// Quiet only logs warnings and errors and does not report the progress
+	Quiet bool
+	// Progress is where the progress is reported during the run, nothing is reported when nil
+	Progress *os.File
+	// Stdout is where the summary table is printed at the end of the run, nothing is printed when nil
+	Stdout *os.File
+	// Context interrupts the run like SIGINT does when it is done, the run is only interrupted by signals when nil
+	Context context.Context
 }
 
 func main() {
+	os.Exit(cli(os.Args[1:], analyzeRepo, os.Stderr))
+}
+
+// run analyzes the repositories in the config, saves the result and returns the exit code.
+// The result is saved even when some of the repositories failed.
+func run(opts options, analyze analyzeFunc) int {
+	startedAt := time.Now()
 	var results ResultFile
-	cfg, err := loadConfig(ConfigFilePath)
+	cfg, err := loadConfig(opts.ConfigPath)
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("invalid config", "error", err)
+		return ExitCodeConfigError
+	}
+	cfg.SearchBackend = resolveSearchBackend(cfg.SearchBackend)
+	if cfg.SearchBackend == SearchBackendRipgrep && cfg.IntraRepoConcurrency > 1 && len(cfg.MatcherCommand) == 0 {
+		slog.Warn("intra_repo_concurrency is ignored by the ripgrep search backend, rg searches with its own threads")
+	}
+	outputs := opts.outputs()
+	if err := validateOutputs(outputs, cfg, opts.TemplatePath); err != nil {
+		slog.Error("invalid config", "error", err)
+		return ExitCodeConfigError
+	}
+
+	if opts.Dir != "" {
+		cfg.Repositories, analyze = localDir(opts.Dir)
+	} else {
+		discovered, err := discoverRepositories(context.Background(), cfg)
+		if err != nil {
+			slog.Error("unable to discover repositories", "error", err)
+			return ExitCodeTotalFailure
+		}
+		cfg.Repositories = mergeDiscovered(cfg.Repositories, discovered)
+	}
+	// git is only required when it is used, which depends on the repositories
+	if err := checkBinaries(cfg); err != nil {
+		slog.Error("invalid config", "error", err)
+		return ExitCodeConfigError
+	}
+	if opts.MaxConcurrency > 0 {
+		cfg.MaxConcurrency = opts.MaxConcurrency
+	}
+	if opts.CacheDir != "" {
+		cfg.CacheDir = opts.CacheDir
+	}
+	if opts.WithLines {
+		cfg.IncludeMatches = true
+	}
+	for _, out := range outputs {
+		if out.Format == FormatSARIF {
+			cfg.IncludeMatches = true
+		}
+	}
+	if err := validateLineMatching(cfg); err != nil {
+		slog.Error("invalid config", "error", err)
+		return ExitCodeConfigError
+	}
+	if cfg.CacheDir != "" {
+		if err := checkSharedClones(cfg.Repositories, "cache_dir"); err != nil {
+			slog.Error("invalid config", "error", err)
+			return ExitCodeConfigError
+		}
+		maxAge, _ := cacheMaxAge(cfg.CacheMaxAge)
+		if err := evictCachedClones(cfg.CacheDir, maxAge, time.Now()); err != nil {
+			slog.Error("unable to evict cached clones", "error", err)
+		}
+	}
+
+	var previous ResultFile
+	if opts.CompareTo != "" {
+		if previous, err = readResultFile(opts.CompareTo); err == nil {
+			err = validateCompareTo(opts.CompareTo, previous)
+		}
+		if err != nil {
+			slog.Error("unable to read the result to compare to", "error", err)
+			return ExitCodeConfigError
+		}
 	}
 
 	results.TotalApplications = len(cfg.Repositories)
-	results.Applications = make([]Application, results.TotalApplications)
 	results.SearchWords = cfg.SearchWords
 
-	var wg sync.WaitGroup
-	wg.Add(results.TotalApplications)
-	for i, repo := range cfg.Repositories {
-		go func(repo Repository, index int) {
-			defer wg.Done()
-			result, err := analyzeRepo(repo, cfg.SearchWords, append(cfg.ExcludeDirs, cfg.Repositories[index].ExcludeDirs...))
-			if err != nil {
-				log.Fatalf("failed on repo '%s': %s", repo.Name, err.Error())
+	var state *scanState
+	if cfg.StateFile != "" && opts.Dir == "" {
+		if state, err = loadState(cfg.StateFile); err != nil {
+			slog.Error("unable to load state", "error", err)
+			return ExitCodeConfigError
+		}
+		analyze = state.skipping(analyze)
+	}
+	if cfg.BaselineFile != "" && !opts.Baseline {
+		baseline, err := loadBaseline(cfg.BaselineFile)
+		if err != nil {
+			slog.Error("unable to load baseline", "error", err)
+			return ExitCodeConfigError
+		}
+		analyze = baseline.subtracting(analyze)
+	}
+
+	var stream *ndjsonWriter
+	if streamed(outputs[0].Format) {
+		if stream, err = createNDJSON(outputs[0].Path, cfg.outputFileMode()); err != nil {
+			slog.Error("unable to save result", "error", err)
+			return ExitCodeFailure
+		}
+		defer stream.Close()
+		analyze = stream.streaming(analyze)
+	}
+
+	// on SIGINT or SIGTERM the clones and searches in progress are cancelled, which removes their clones,
+	// and the result of the repositories which were finished is saved
+	parent := opts.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	var runSpan *span
+	var trace *tracer
+	if cfg.OTLPEndpoint != "" {
+		trace = newTracer()
+		ctx, runSpan = startSpan(withTracer(ctx, trace), "run")
+	}
+	var p *progress
+	if opts.Progress != nil {
+		p = newProgress(opts.Progress, isTerminal(opts.Progress), len(cfg.Repositories))
+		analyze = p.tracking(analyze)
+		p.run()
+	}
+	apps, errs := scan(ctx, cfg, analyze)
+	if p != nil {
+		p.finish()
+	}
+	if trace != nil {
+		runSpan.end(nil)
+		if err := trace.export(cfg.OTLPEndpoint); err != nil {
+			slog.Error("unable to export traces", "error", err)
+		}
+	}
+	// the subdirs of monorepos are applications of their own
+	results.TotalApplications = len(apps)
+	if ctx.Err() != nil {
+		slog.Warn("interrupted, saving the result of the finished repositories")
+	}
+	// a second signal while the result is saved stops the program right away
+	stop()
+	if state != nil {
+		if err := state.save(cfg.StateFile, cfg.Repositories, cfg.outputFileMode()); err != nil {
+			slog.Error("unable to save state", "error", err)
+		}
+	}
+	for _, err := range errs {
+		slog.Error("repository failed", "error", err)
+		results.Errors = append(results.Errors, err.Error())
+	}
+	if opts.Baseline {
+		if err := writeBaseline(opts.ResultPath, newBaseline(apps), cfg.outputFileMode()); err != nil {
+			slog.Error("unable to save baseline", "error", err)
+			return ExitCodeFailure
+		}
+		return scanExitCode(len(cfg.Repositories), len(errs))
+	}
+	results.Applications = apps
+	results = calculateTotals(results)
+	// the config file was read when the config was loaded, reading it again can not fail unless it was removed since
+	configContent, _ := os.ReadFile(opts.ConfigPath)
+	results.Metadata = newRunMetadata(startedAt, time.Now(), configContent)
+	results.FailedApplications = len(errs)
+	if cfg.KeepClones {
+		printKeptClones(results)
+	}
+	logCriticalMatches(results)
+	if containsString(cfg.Presets, PresetTechDebt) {
+		results.TechDebt = calculateTechDebt(results)
+	}
+	if opts.CompareTo != "" {
+		results.NewMatches = compareResults(previous, results)
+	}
+	if cfg.WordRepoCoverage {
+		results.WordCoverage = calculateWordCoverage(results)
+	}
+	if cfg.SummaryOnly {
+		results = removeGrepResults(results)
+	}
+	// the delta of the summary table is since -compare-to, or else since the json result which is overwritten
+	var before *ResultFile
+	for _, out := range outputs {
+		if opts.CompareTo != "" {
+			before = &previous
+			break
+		}
+		if opts.Stdout != nil && out.Format == FormatJSON {
+			if overwritten, err := readResultFile(out.Path); err == nil {
+				before = &overwritten
 			}
-			results.Applications[index] = Application{
-				Name:        repo.Name,
-				CountSum:    sumTotalCountForGrepResults(result),
-				GrepResults: result,
+			break
+		}
+	}
+	if stream != nil {
+		if err := stream.writeSummary(results); err != nil {
+			slog.Error("unable to save result", "error", err)
+			return ExitCodeFailure
+		}
+	} else {
+		// the other outputs are saved even when one of them fails
+		saved := true
+		for _, out := range outputs {
+			if err := saveResult(out, results, cfg, opts); err != nil {
+				slog.Error("unable to save result", "output", out.Path, "error", err)
+				saved = false
 			}
-		}(repo, i)
+		}
+		if !saved {
+			return ExitCodeFailure
+		}
+	}
+	if opts.Stdout != nil {
+		printSummaryTable(opts.Stdout, results, before, opts.Top, useColor(opts.Stdout))
+	}
+	if cfg.PushgatewayURL != "" {
+		if err := pushMetrics(cfg.PushgatewayURL, cfg.PushgatewayJob, results); err != nil {
+			slog.Error("unable to push metrics", "error", err)
+		}
+	}
+	if cfg.WebhookURL != "" {
+		timeout, _ := webhookTimeout(cfg.WebhookTimeout)
+		summary := WebhookSummary{
+			TotalApplications: results.TotalApplications,
+			TotalCountSum:     results.TotalCountSum,
+			Failures:          len(errs),
+			Timestamp:         time.Now(),
+		}
+		if err := notifyWebhook(cfg.WebhookURL, timeout, summary); err != nil {
+			slog.Error("unable to notify webhook", "error", err)
+		}
+	}
+
+	if code := scanExitCode(len(cfg.Repositories), len(errs)); code != ExitCodeSuccess {
+		return code
+	}
+	if results.SeverityTotals[SeverityCritical] > 0 {
+		return ExitCodeCriticalMatch
+	}
+	if exceedsThreshold(opts, results.TotalCountSum) {
+		return ExitCodeThreshold
+	}
+	return ExitCodeSuccess
+}
+
+// exceedsThreshold reports whether the total count sum fails the run because of -fail-if-total-above or
+// -fail-if-any-match, the reason is logged
+func exceedsThreshold(opts options, total int) bool {
+	if opts.FailIfAnyMatch && total > 0 {
+		slog.Error("failing: matches were found", "total_count_sum", total)
+		return true
+	}
+	if opts.FailIfTotalAbove != nil && total > *opts.FailIfTotalAbove {
+		slog.Error("failing: the total count sum is above the threshold", "total_count_sum", total, "threshold", *opts.FailIfTotalAbove)
+		return true
+	}
+	return false
+}
+
+// formatFeatures are the features of a format of the result file
+type formatFeatures struct {
+	// Streamed formats write the applications as soon as they are searched
+	Streamed bool
+	// AppendMode formats can be added to an existing result file
+	AppendMode bool
+	// SummaryOnly formats can be written without the results per file
+	SummaryOnly bool
+}
 
+// formats are the known formats of the result file
+var formats = map[string]formatFeatures{
+	FormatJSON:     {AppendMode: true, SummaryOnly: true},
+	FormatNDJSON:   {Streamed: true, SummaryOnly: true},
+	FormatJSONL:    {Streamed: true, SummaryOnly: true},
+	FormatSARIF:    {},
+	FormatJUnit:    {SummaryOnly: true},
+	FormatCSV:      {},
+	FormatHTML:     {SummaryOnly: true},
+	FormatMarkdown: {SummaryOnly: true},
+	FormatXLSX:     {},
+	FormatTemplate: {SummaryOnly: true},
+}
+
+// validateFormat checks that the format of the result file is known and can be used with the config
+func validateFormat(format string, cfg Config) error {
+	features, ok := formats[format]
+	if !ok {
+		return fmt.Errorf("unknown format '%s'", format)
+	}
+	if cfg.AppendMode && !features.AppendMode {
+		return errors.New("append_mode can not be used with the " + format + " format")
+	}
+	if cfg.SummaryOnly && !features.SummaryOnly {
+		return errors.New("summary_only can not be used with the " + format + " format")
 	}
+	return nil
+}
 
-	wg.Wait()
-	results.TotalCountSum = calculateTotalCountSum(results)
-	if err := writeResult(ResultFilePath, sortOnAppCountSumDesc(results)); err != nil {
-		log.Fatal("unable to save result: %w", err)
+// scanExitCode returns the exit code for a scan of total repositories of which failed repositories failed
+func scanExitCode(total, failed int) int {
+	switch {
+	case failed == 0:
+		return ExitCodeSuccess
+	case failed < total:
+		return ExitCodePartialFailure
+	default:
+		return ExitCodeTotalFailure
 	}
 }
 
+// density is the number of matches per thousand lines
+func density(count, lines int) float64 {
+	if lines == 0 {
+		return 0
+	}
+	return float64(count) * 1000 / float64(lines)
+}
+
+// sortApplications sorts the applications on the score of the score mode. A copy of the applications is sorted, so
+// the result which is shared by all outputs keeps its order.
+func sortApplications(result ResultFile, scoreMode string) ResultFile {
+	result.Applications = append([]Application(nil), result.Applications...)
+	if scoreMode == ScoreModeDensity {
+		return sortOnAppDensityDesc(result)
+	}
+	return sortOnAppCountSumDesc(result)
+}
+
+// sortOnAppDensityDesc sorts the applications on density, applications with the same density are sorted on name
+func sortOnAppDensityDesc(result ResultFile) ResultFile {
+	sort.SliceStable(result.Applications, func(i, j int) bool {
+		if result.Applications[i].Density != result.Applications[j].Density {
+			return result.Applications[i].Density > result.Applications[j].Density
+		}
+		return result.Applications[i].Name < result.Applications[j].Name
+	})
+	return result
+}
+
+// sortOnAppCountSumDesc sorts the applications on count sum, applications with the same count sum are sorted on name
 func sortOnAppCountSumDesc(result ResultFile) ResultFile {
-	sort.Slice(result.Applications, func(i, j int) bool {
-		return result.Applications[i].CountSum > result.Applications[j].CountSum
+	sort.SliceStable(result.Applications, func(i, j int) bool {
+		if result.Applications[i].CountSum != result.Applications[j].CountSum {
+			return result.Applications[i].CountSum > result.Applications[j].CountSum
+		}
+		return result.Applications[i].Name < result.Applications[j].Name
 	})
 	return result
 }
 
+// sortGrepResults sorts the results on file name, or on count with the file order count, so the result is the same in
+// every run regardless of the order the files were searched in
+func sortGrepResults(grs []GrepResult, fileOrder string) {
+	sort.SliceStable(grs, func(i, j int) bool {
+		if fileOrder == FileOrderCount && grs[i].Count != grs[j].Count {
+			return grs[i].Count > grs[j].Count
+		}
+		return grs[i].FileName < grs[j].FileName
+	})
+}
+
+// capCounts clamps the count of every result above max to max and marks it as truncated.
+// The counts per search word are left as they are.
+func capCounts(grs []GrepResult, max int) {
+	for i := range grs {
+		if grs[i].Count > max {
+			grs[i].Count = max
+			grs[i].Truncated = true
+		}
+	}
+}
+
 func sumTotalCountForGrepResults(grs []GrepResult) int {
 	var result int
 	for _, gr := range grs {
@@ -97,19 +686,328 @@ func sumTotalCountForGrepResults(grs []GrepResult) int {
 	return result
 }
 
-func analyzeRepo(r Repository, searchWords, excludeDirs []string) ([]GrepResult, error) {
-	path, removeDir, err := cloneRepo(r)
+// analyzeRepo clones the repo and greps it for the search words in the config.
+// The clone is removed afterwards unless keep_clones is set, the path of the clone is then kept in the application.
+func analyzeRepo(ctx context.Context, r Repository, cfg Config) (Application, error) {
+	if r.Path != "" {
+		return analyzeLocalRepo(ctx, r, cfg)
+	}
+	reportPhase(ctx, PhaseCloning)
+	cloneCtx, cloneSpan := startSpan(ctx, "clone", "repository", r.Name)
+	cloneStart := time.Now()
+	path, removeDir, err := cloneRepo(cloneCtx, r, cfg)
+	cloneSeconds := seconds(cloneStart)
+	cloneSpan.end(err)
 	if err != nil || removeDir == nil {
-		return nil, err
+		return Application{Name: r.Name}, err
+	}
+	if !cfg.KeepClones {
+		defer removeDir()
 	}
-	defer removeDir()
 
-	result, err := grep(path, searchWords, excludeDirs)
+	commit, err := cfg.headCommit(ctx, path)
 	if err != nil {
-		return nil, err
+		return Application{Name: r.Name}, err
+	}
+	app, err := analyzeCheckout(ctx, r, cfg, path)
+	app.Ref = r.Ref
+	app.Commit = commit
+	if cfg.Stats {
+		addDiskUsage(&app, path)
+		app.Stats.CloneSeconds = cloneSeconds
+	}
+	if cfg.KeepClones {
+		app.ClonePath = path
+	}
+	for i := range app.Subdirs {
+		app.Subdirs[i].Ref, app.Subdirs[i].Commit, app.Subdirs[i].ClonePath = app.Ref, app.Commit, app.ClonePath
+	}
+	return app, err
+}
+
+// analyzeLocalRepo searches the local dir of the repository, the commit is only set when the dir is a git repository
+func analyzeLocalRepo(ctx context.Context, r Repository, cfg Config) (Application, error) {
+	if err := checkDirExists(r.Path); err != nil {
+		return Application{Name: r.Name}, err
+	}
+	app, err := analyzeCheckout(ctx, r, cfg, r.Path)
+	if err != nil {
+		return app, err
+	}
+	if cfg.Stats {
+		addDiskUsage(&app, r.Path)
+	}
+	if commit, err := cfg.headCommit(ctx, r.Path); err == nil {
+		app.Commit = commit
+		for i := range app.Subdirs {
+			app.Subdirs[i].Commit = commit
+		}
+	}
+	return app, nil
+}
+
+// analyzePath greps the files in path for the search words in the config
+func analyzePath(ctx context.Context, r Repository, cfg Config, path string) (Application, error) {
+	app := Application{Name: r.Name}
+	hasFiles, err := containsFiles(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return app, fmt.Errorf("%w: %s", ErrClonePathMissing, path)
+	}
+	if err != nil {
+		return app, err
+	}
+	if !hasFiles {
+		slog.Warn("repository has no files, is the url correct?", "repository", r.Name)
+		app.Status = StatusEmpty
+		return app, nil
 	}
+	app.Status = StatusOK
 
-	return result, nil
+	opts := grepOptions{
+		Binary:         cfg.GrepBinary,
+		ExcludeDirs:    append(append([]string{}, cfg.ExcludeDirs...), r.ExcludeDirs...),
+		ExcludeFiles:   append(append([]string{}, cfg.ExcludeFiles...), r.ExcludeFiles...),
+		IncludeGlobs:   includeGlobs(cfg, r),
+		ScanGitDir:     cfg.ScanGitDir,
+		FollowSymlinks: cfg.FollowSymlinks,
+		CaseSensitive:  cfg.CaseSensitive,
+		Multiline:      cfg.Multiline,
+		SearchBinary:   !cfg.skipBinary(),
+	}
+	if cfg.useGrepperIgnore() {
+		excludeDirs, excludeFiles, err := readGrepperIgnore(path)
+		if err != nil {
+			return app, fmt.Errorf("unable to read %s: %w", GrepperIgnoreFile, err)
+		}
+		opts.ExcludeDirs = append(opts.ExcludeDirs, excludeDirs...)
+		opts.ExcludeFiles = append(opts.ExcludeFiles, excludeFiles...)
+	}
+	opts.MaxFileSize, _ = parseFileSize(cfg.MaxFileSize)
+	if cfg.ListSkippedFiles && opts.MaxFileSize > 0 {
+		if app.SkippedFiles, err = skippedLargeFiles(path, opts); err != nil {
+			return app, err
+		}
+	}
+	if cfg.searchBackend() == SearchBackendGrep && len(cfg.MatcherCommand) == 0 {
+		for _, dir := range nestedExcludeDirs(opts.ExcludeDirs) {
+			slog.Warn("grep can not skip the nested exclude dir, its matches are removed after searching", "repository", r.Name, "dir", dir)
+		}
+	}
+	opts.ExcludeDirs = append(opts.ExcludeDirs, r.subdirExcludes...)
+	if r.ChangedSince != "" {
+		files, err := changedFiles(ctx, cfg.gitBinary(), path, r.ChangedSince)
+		if err != nil {
+			return app, err
+		}
+		opts.Paths = filterExcludedFiles(filterExcludedDirs(files, opts.ExcludeDirs), opts.ExcludeFiles)
+		if len(opts.Paths) == 0 {
+			app.GrepResults = []GrepResult{}
+			return app, nil
+		}
+	}
+
+	var result []GrepResult
+	groups := cfg.searchWordGroups()
+	reportPhase(ctx, PhaseSearching)
+	_, searchSpan := startSpan(ctx, "search", "repository", r.Name, "search_backend", cfg.searchBackend())
+	searchStart := time.Now()
+	if len(cfg.MatcherCommand) > 0 {
+		result, err = matchFiles(ctx, path, opts, matcherCommandCounter(cfg.MatcherCommand), cfg.IntraRepoConcurrency)
+	} else {
+		result, err = searchGroups(groups, func(group searchWordGroup) ([]GrepResult, error) {
+			opts := group.options(opts)
+			switch cfg.SearchBackend {
+			case SearchBackendNative:
+				return searchNative(ctx, path, group.Words, opts, cfg.IntraRepoConcurrency)
+			case SearchBackendRipgrep:
+				return ripgrep(ctx, path, group.Words, opts)
+			default:
+				return grepConcurrent(ctx, path, group.Words, opts, cfg.IntraRepoConcurrency)
+			}
+		})
+	}
+	if err == nil && cfg.SearchArchives {
+		// the results inside the archives are filtered the same way as the other results
+		var archiveResults []GrepResult
+		archiveResults, err = searchGroups(groups, func(group searchWordGroup) ([]GrepResult, error) {
+			return searchArchives(path, group.Words, group.options(opts))
+		})
+		result = append(result, archiveResults...)
+	}
+	searchSpan.end(err)
+	if cfg.Stats {
+		app.Stats = &RepoStats{SearchSeconds: seconds(searchStart)}
+	}
+	if err != nil {
+		return app, err
+	}
+	_, parseSpan := startSpan(ctx, "parse", "repository", r.Name)
+	err = processResults(ctx, r, cfg, path, opts, groups, result, &app)
+	parseSpan.end(err)
+	return app, err
+}
+
+// processResults filters the results of the search of path and sets them with their counts in the application
+func processResults(ctx context.Context, r Repository, cfg Config, path string, opts grepOptions, groups []searchWordGroup, result []GrepResult, app *Application) error {
+	if cfg.RespectGitignore {
+		ignored, err := listIgnoredFiles(ctx, cfg.gitBinary(), path)
+		if err != nil {
+			return err
+		}
+		result = filterIgnoredFiles(result, ignored)
+	}
+	excludes, err := compileExcludePatterns(cfg.ExcludePatterns)
+	if err != nil {
+		return err
+	}
+	if len(cfg.MatcherCommand) == 0 {
+		if result, err = filterExcludedLines(path, result, groups, excludes); err != nil {
+			return err
+		}
+	}
+	if cfg.UTF8Only {
+		if result, app.FilesSkipped, err = filterUTF8(path, result, opts); err != nil {
+			return err
+		}
+		if app.FilesSkipped > 0 {
+			slog.Info("skipped non UTF-8 files", "repository", r.Name, "files", app.FilesSkipped)
+		}
+	}
+	if cfg.IncludeMatches || cfg.ContextLines > 0 {
+		if err := addMatches(path, result, groups, cfg.ContextLines); err != nil {
+			return err
+		}
+		filterExcludedMatches(result, excludes)
+	}
+	if cfg.MaxCountPerFile > 0 {
+		capCounts(result, cfg.MaxCountPerFile)
+	}
+
+	app.CountSum = sumTotalCountForGrepResults(result)
+	app.WordCounts = sumWordCounts(result)
+	app.GroupCounts = cfg.groupCounts(app.WordCounts)
+	app.SeverityCounts = cfg.severityCounts(app.WordCounts)
+	if cfg.ScoreMode == ScoreModeDensity || cfg.Stats {
+		// the searched files are listed once for both the density and the stats
+		files, err := searchedFiles(path, opts)
+		if err != nil {
+			return err
+		}
+		if cfg.Stats {
+			app.Stats.FileCount = len(files)
+		}
+		if cfg.ScoreMode == ScoreModeDensity {
+			if app.LinesScanned, err = countLines(path, files); err != nil {
+				return err
+			}
+			app.Density = density(app.CountSum, app.LinesScanned)
+		}
+	}
+	if r.History != nil {
+		for i, group := range groups {
+			history, err := repoHistory(ctx, cfg.gitBinary(), path, r.History.Commits, group, opts)
+			if err != nil {
+				return err
+			}
+			if i == 0 {
+				app.History = history
+				continue
+			}
+			for j := range history {
+				app.History[j].Count += history[j].Count
+			}
+		}
+	}
+	sortGrepResults(result, cfg.FileOrder)
+	app.GrepResults = result
+	return nil
+}
+
+// localDir returns a repository named after the dir and an analyzeFunc searching the dir instead of cloning the repository
+func localDir(dir string) ([]Repository, analyzeFunc) {
+	dir = filepath.Clean(dir)
+	name := dir
+	if abs, err := filepath.Abs(dir); err == nil {
+		name = filepath.Base(abs)
+	}
+	analyze := func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		if err := checkDirExists(dir); err != nil {
+			return Application{Name: r.Name}, err
+		}
+		return analyzePath(ctx, r, cfg, dir)
+	}
+	return []Repository{{Name: name}}, analyze
+}
+
+// errFileFound stops the walk of containsFiles at the first file
+var errFileFound = errors.New("file found")
+
+// ErrClonePathMissing is returned when the clone of a repository is gone while it is being searched
+var ErrClonePathMissing = errors.New("clone path is missing")
+
+// ErrDirMissing is returned when the local dir to search, of -dir or the path of a repository, does not exist
+var ErrDirMissing = errors.New("dir does not exist")
+
+// checkDirExists returns ErrDirMissing when dir does not exist, which unlike a clone which is gone is a mistake in
+// the config or the arguments
+func checkDirExists(dir string) error {
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrDirMissing, dir)
+	}
+	return nil
+}
+
+// containsFiles reports whether there are any files in path, not counting the .git dir
+func containsFiles(path string) (bool, error) {
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == GitDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return errFileFound
+	})
+	if errors.Is(err, errFileFound) {
+		return true, nil
+	}
+	return false, err
+}
+
+// printKeptClones logs where the clone of each repository was kept
+func printKeptClones(rf ResultFile) {
+	for _, app := range rf.Applications {
+		if app.ClonePath != "" {
+			slog.Info("kept clone", "repository", app.Name, "path", app.ClonePath)
+		}
+	}
+}
+
+// countApplicationStatuses counts the applications which were searched and the applications which were empty
+func countApplicationStatuses(results ResultFile) (succeeded, empty int) {
+	for _, app := range results.Applications {
+		switch app.Status {
+		case StatusOK:
+			succeeded++
+		case StatusEmpty:
+			empty++
+		}
+	}
+	return succeeded, empty
+}
+
+// calculateTotals calculates the statuses and the totals of the result from its applications
+func calculateTotals(rf ResultFile) ResultFile {
+	rf.SucceededApplications, rf.EmptyApplications = countApplicationStatuses(rf)
+	rf.TotalCountSum = calculateTotalCountSum(rf)
+	rf.ExtensionTotals = calculateExtensionTotals(rf)
+	rf.WordTotals = calculateWordTotals(rf)
+	rf.GroupTotals = calculateGroupTotals(rf)
+	rf.SeverityTotals = calculateSeverityTotals(rf)
+	return rf
 }
 
 func calculateTotalCountSum(rf ResultFile) int {
@@ -120,6 +1018,34 @@ func calculateTotalCountSum(rf ResultFile) int {
 	return result
 }
 
+// removeGrepResults removes the per file results of the applications, the totals must be calculated beforehand
+func removeGrepResults(rf ResultFile) ResultFile {
+	for i := range rf.Applications {
+		rf.Applications[i].GrepResults = nil
+	}
+	return rf
+}
+
+// calculateExtensionTotals sums the counts of all applications per file extension, files without an extension are summed under NoExtension
+func calculateExtensionTotals(rf ResultFile) map[string]int {
+	result := make(map[string]int)
+	for _, app := range rf.Applications {
+		for _, gr := range app.GrepResults {
+			result[fileExtension(gr.FileName)] += gr.Count
+		}
+	}
+	return result
+}
+
+// fileExtension returns the extension of the file name, dotfiles like .gitignore are seen as having no extension
+func fileExtension(fileName string) string {
+	base := path.Base(fileName)
+	if ext := path.Ext(base); ext != "" && ext != base {
+		return ext
+	}
+	return NoExtension
+}
+
 // loadConfig gets the repos information from the given filename
 func loadConfig(filename string) (Config, error) {
 	var cfg Config
@@ -127,33 +1053,399 @@ func loadConfig(filename string) (Config, error) {
 	if err != nil {
 		return cfg, err
 	}
+	if isYAML(filename) {
+		if file, err = yamlToJSON(file); err != nil {
+			return cfg, err
+		}
+	}
 	err = json.Unmarshal(file, &cfg)
 	if err != nil {
 		return cfg, err
 	}
-	return cfg, nil
+	if err := applyPresets(&cfg); err != nil {
+		return cfg, err
+	}
+	cfg.SearchWords = dedupeSearchWords(cfg)
+	if cfg.RepositoriesFile != "" {
+		repos, err := loadRepositoriesFile(filename, cfg.RepositoriesFile)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Repositories = append(cfg.Repositories, repos...)
+	}
+	cfg.Repositories, err = expandRefs(cfg.Repositories)
+	if err != nil {
+		return cfg, err
+	}
+	resolveRepositoryPaths(filename, cfg.Repositories)
+	return cfg, validateConfig(cfg)
 }
 
-// grep uses the grep command in OS and searches for the given searchWords
-func grep(path string, searchWords, excludeDirs []string) ([]GrepResult, error) {
-	args := grepExcludeDirStr(excludeDirs)
-	args = append(args, searchWordsStr(searchWords)...)
-	args = append(args, "--recursive", "--ignore-case", "--only-matching", path)
+// checkBinaries checks that the configured grep and git binaries can be found, git only when it is needed
+func checkBinaries(cfg Config) error {
+	var binaries []string
+	if cfg.needsGit() {
+		binaries = append(binaries, cfg.gitBinary())
+	}
+	switch cfg.searchBackend() {
+	case SearchBackendGrep:
+		binaries = append(binaries, cfg.grepBinary())
+	case SearchBackendRipgrep:
+		binaries = append(binaries, DefaultRipgrepBinary)
+	}
+	if len(cfg.MatcherCommand) > 0 {
+		binaries = append(binaries, cfg.MatcherCommand[0])
+	}
+	for _, binary := range binaries {
+		if _, err := exec.LookPath(binary); err != nil {
+			return fmt.Errorf("unable to find '%s', is it installed and on PATH: %w", binary, err)
+		}
+	}
+	if cfg.searchBackend() == SearchBackendGrep && cfg.RegexEngine == RegexEnginePerl && len(cfg.MatcherCommand) == 0 {
+		return checkGrepPerl(cfg.grepBinary())
+	}
+	return nil
+}
+
+// needsGit reports whether git is run for the repositories, which is to clone them, to list the files ignored by
+// .gitignore, the changed files or the history. Searching a local dir without these does not need git, its commit
+// is only recorded when git is found. go-git clones without git, but the cache_dir and state_file still run git.
+func (cfg Config) needsGit() bool {
+	if cfg.RespectGitignore {
+		return true
+	}
+	cloneNeedsGit := cfg.GitClient != GitClientGoGit || cfg.CacheDir != "" || cfg.StateFile != ""
+	for _, r := range cfg.Repositories {
+		if (r.Url != "" && r.Path == "" && cloneNeedsGit) || r.ChangedSince != "" || r.History != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// headCommit returns the SHA of the commit checked out at path, with go-git when it is the git_client
+func (cfg Config) headCommit(ctx context.Context, path string) (string, error) {
+	if cfg.GitClient == GitClientGoGit {
+		return goGitHeadCommit(path)
+	}
+	return headCommit(ctx, cfg.gitBinary(), path)
+}
 
-	grepCmd := exec.Command("grep", args...)
-	log.Println("running command: " + strings.Join(grepCmd.Args, " "))
-	grepOut, err := grepCmd.Output()
+// outputFileMode parses the octal output_file_mode, DefaultOutputFileMode is used when it is not set
+func (cfg Config) outputFileMode() os.FileMode {
+	mode, err := parseFileMode(cfg.OutputFileMode)
 	if err != nil {
+		return DefaultOutputFileMode
+	}
+	return mode
+}
+
+func parseFileMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return DefaultOutputFileMode, nil
+	}
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || perm > uint64(os.ModePerm) {
+		return 0, fmt.Errorf("invalid output_file_mode '%s', must be an octal file mode like 0644", mode)
+	}
+	return os.FileMode(perm), nil
+}
+
+func (cfg Config) grepBinary() string {
+	if cfg.GrepBinary == "" {
+		return DefaultGrepBinary
+	}
+	return cfg.GrepBinary
+}
+
+func (cfg Config) gitHubAPIURL() string {
+	if cfg.GitHubAPIURL == "" {
+		return DefaultGitHubAPIURL
+	}
+	return cfg.GitHubAPIURL
+}
+
+func (cfg Config) gitLabURL() string {
+	if cfg.GitLabURL == "" {
+		return DefaultGitLabURL
+	}
+	return cfg.GitLabURL
+}
+
+func (cfg Config) bitbucketURL() string {
+	if cfg.BitbucketURL == "" {
+		return DefaultBitbucketCloudURL
+	}
+	return cfg.BitbucketURL
+}
+
+func (cfg Config) azureDevOpsURL() string {
+	if cfg.AzureDevOpsURL == "" {
+		return DefaultAzureDevOpsURL
+	}
+	return cfg.AzureDevOpsURL
+}
+
+func (cfg Config) searchBackend() string {
+	if cfg.SearchBackend == "" {
+		return SearchBackendGrep
+	}
+	return cfg.SearchBackend
+}
+
+// useGrepperIgnore reports whether the .grepperignore files of the repositories are read, which they are unless
+// use_grepperignore is set to false
+func (cfg Config) useGrepperIgnore() bool {
+	return cfg.UseGrepperIgnore == nil || *cfg.UseGrepperIgnore
+}
+
+// skipBinary reports whether binary files are skipped, which they are unless skip_binary is set to false
+func (cfg Config) skipBinary() bool {
+	return cfg.SkipBinary == nil || *cfg.SkipBinary
+}
+
+func (cfg Config) gitBinary() string {
+	if cfg.GitBinary == "" {
+		return DefaultGitBinary
+	}
+	return cfg.GitBinary
+}
+
+// validateConfig checks that the config has search words and that every repository can be cloned
+func validateConfig(cfg Config) error {
+	if len(cfg.SearchWords) == 0 {
+		return errors.New("no search words given")
+	}
+	for i, repo := range cfg.Repositories {
+		if repo.Name == "" {
+			return fmt.Errorf("repository %d has no name", i)
+		}
+		if repo.Url == "" && repo.Path == "" {
+			return fmt.Errorf("repository '%s' has no url or path", repo.Name)
+		}
+		if repo.Url != "" && repo.Path != "" {
+			return fmt.Errorf("repository '%s' has both a url and a path, only one of them can be set", repo.Name)
+		}
+		if repo.Path != "" && repo.Ref != "" {
+			return fmt.Errorf("repository '%s' has a path, which can not be searched at a ref", repo.Name)
+		}
+		if err := validateHistory(repo); err != nil {
+			return err
+		}
+		if err := validateExtensions(repo.IncludeExtensions); err != nil {
+			return err
+		}
+		if err := validateSubdirs(repo); err != nil {
+			return err
+		}
+	}
+	if err := validateExtensions(cfg.IncludeExtensions); err != nil {
+		return err
+	}
+	if cfg.DeterministicTemp {
+		if err := checkSharedClones(cfg.Repositories, "deterministic_temp"); err != nil {
+			return err
+		}
+	}
+	switch cfg.SearchBackend {
+	case "", SearchBackendGrep, SearchBackendNative, SearchBackendRipgrep:
+	default:
+		return fmt.Errorf("unknown search_backend '%s', must be %s, %s or %s", cfg.SearchBackend, SearchBackendGrep, SearchBackendNative, SearchBackendRipgrep)
+	}
+	if err := validateRegexEngine(cfg); err != nil {
+		return err
+	}
+	if err := validateLineMatching(cfg); err != nil {
+		return err
+	}
+	if cfg.Multiline && cfg.SearchBackend != SearchBackendNative {
+		// grep matches line by line, matching across lines needs a search backend reading the whole file
+		return errors.New("multiline is not supported by the grep search backend, use the native search backend")
+	}
+	if _, err := path.Match(cfg.GitHubNameFilter, ""); err != nil {
+		return fmt.Errorf("invalid github_name_filter: %w", err)
+	}
+	for _, pattern := range append(append([]string{}, cfg.DiscoveryInclude...), cfg.DiscoveryExclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid discovery filter '%s': %w", pattern, err)
+		}
+	}
+	if cfg.BitbucketProject != "" && cfg.BitbucketURL == "" {
+		return errors.New("bitbucket_project needs the bitbucket_url of the Bitbucket Server")
+	}
+	if cfg.BitbucketProject != "" && cfg.BitbucketWorkspace != "" {
+		return errors.New("bitbucket_workspace and bitbucket_project can not be combined, the bitbucket_url is either Bitbucket Cloud or Server")
+	}
+	if cfg.AzureDevOpsProject != "" && cfg.AzureDevOpsOrg == "" {
+		return errors.New("azure_devops_project needs the azure_devops_org it belongs to")
+	}
+	if (cfg.GiteaOrg != "" || cfg.GiteaUser != "") && cfg.GiteaURL == "" {
+		return errors.New("gitea_org and gitea_user need the gitea_url of the Gitea or Forgejo instance")
+	}
+	if cfg.GiteaOrg != "" && cfg.GiteaUser != "" {
+		return errors.New("gitea_org and gitea_user can not be combined")
+	}
+	if cfg.MaxConcurrency < 0 {
+		return errors.New("max_concurrency can not be negative")
+	}
+	if cfg.MaxCountPerFile < 0 {
+		return errors.New("max_count_per_file can not be negative")
+	}
+	if cfg.ContextLines < 0 {
+		return errors.New("context_lines can not be negative")
+	}
+	if _, err := parseFileSize(cfg.MaxFileSize); err != nil {
+		return err
+	}
+	if err := validateSeverities(cfg.WordOptions); err != nil {
+		return err
+	}
+	if _, err := compileExcludePatterns(cfg.ExcludePatterns); err != nil {
+		return err
+	}
+	if cfg.ScoreMode != "" && cfg.ScoreMode != ScoreModeCount && cfg.ScoreMode != ScoreModeDensity {
+		return fmt.Errorf("unknown score_mode '%s', must be %s or %s", cfg.ScoreMode, ScoreModeCount, ScoreModeDensity)
+	}
+	if cfg.FileOrder != "" && cfg.FileOrder != FileOrderName && cfg.FileOrder != FileOrderCount {
+		return fmt.Errorf("unknown file_order '%s', must be %s or %s", cfg.FileOrder, FileOrderName, FileOrderCount)
+	}
+	if _, err := parseFileMode(cfg.OutputFileMode); err != nil {
+		return err
+	}
+	if err := validateCloneProtocol(cfg.CloneProtocol); err != nil {
+		return err
+	}
+	if err := validateGitClient(cfg.GitClient); err != nil {
+		return err
+	}
+	if _, err := webhookTimeout(cfg.WebhookTimeout); err != nil {
+		return fmt.Errorf("invalid webhook_timeout: %w", err)
+	}
+	if _, err := cacheMaxAge(cfg.CacheMaxAge); err != nil {
+		return fmt.Errorf("invalid cache_max_age: %w", err)
+	}
+	return nil
+}
+
+// checkSharedClones returns an error when two repositories have the same url and ref, which the option cloning each
+// repository into a dir named after them does not support as they would be cloned into the same dir at the same time
+func checkSharedClones(repos []Repository, option string) error {
+	names := map[string]string{}
+	for _, repo := range repos {
+		if repo.Path != "" {
+			continue
+		}
+		if other, ok := names[cloneKey(repo)]; ok {
+			return fmt.Errorf("repositories '%s' and '%s' have the same url, which %s does not support", other, repo.Name, option)
+		}
+		names[cloneKey(repo)] = repo.Name
+	}
+	return nil
+}
+
+// grep uses the grep command in OS and searches for the given searchWords.
+// Symlinks are not followed by grep itself, as it would follow those pointing outside of path as well, and grep has no
+// limit on the size of a file: with either, the files are listed first and grep is only given the ones to search.
+func grep(ctx context.Context, path string, searchWords []string, opts grepOptions) ([]GrepResult, error) {
+	if !opts.FollowSymlinks && opts.MaxFileSize <= 0 {
+		return grepPaths(ctx, path, searchWords, opts)
+	}
+	files, err := searchedFiles(path, opts)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrClonePathMissing, err)
+		}
+		return nil, err
+	}
+	return searchListed(files, opts, func(opts grepOptions) ([]GrepResult, error) {
+		return grepPaths(ctx, path, searchWords, opts)
+	})
+}
+
+// grepPaths runs a single grep command searching the paths of the options, or the whole path without them
+func grepPaths(ctx context.Context, path string, searchWords []string, opts grepOptions) ([]GrepResult, error) {
+	grepCmd := grepCommand(ctx, path, searchWords, opts)
+	logCommand(grepCmd.Args)
+	var stderr bytes.Buffer
+	grepCmd.Stderr = &stderr
+	stdout, err := grepCmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute grep command: %w", err)
+	}
+	if err := grepCmd.Start(); err != nil {
+		return nil, fmt.Errorf("unable to execute grep command: %w", err)
+	}
+
+	// the output is counted while grep is running, as it can be too large to keep in memory
+	result, parseErr := parseGrepStream(stdout, path)
+	if err := grepCmd.Wait(); err != nil {
 		var exitError *exec.ExitError
 		if errors.As(err, &exitError) {
 			if exitError.ExitCode() == GrepErrorCodeNoMatches {
 				return []GrepResult{}, nil
 			}
-			return nil, fmt.Errorf("unable to execute grep command: %s", string(exitError.Stderr))
+			if strings.Contains(stderr.String(), "No such file or directory") {
+				return nil, fmt.Errorf("%w: %s", ErrClonePathMissing, strings.TrimSpace(stderr.String()))
+			}
+			return nil, fmt.Errorf("unable to execute grep command: %s", stderr.String())
 		}
 		return nil, fmt.Errorf("unable to execute grep command: %w", err)
 	}
-	return parseGrepOutput(string(grepOut), path), nil
+	if parseErr != nil {
+		return nil, fmt.Errorf("unable to read grep output: %w", parseErr)
+	}
+	result = filterExcludeFiles(filterExcludeDirs(filterIncludeGlobs(result, opts.IncludeGlobs), opts.ExcludeDirs), opts.ExcludeFiles)
+	return attributeWords(result, searchWords, opts.CaseSensitive), nil
+}
+
+func grepCommand(ctx context.Context, path string, searchWords []string, opts grepOptions) *exec.Cmd {
+	excludeDirs := opts.ExcludeDirs
+	if !opts.ScanGitDir {
+		excludeDirs = append([]string{GitDir}, excludeDirs...)
+	}
+	args := grepExcludeDirStr(excludeDirs)
+	args = append(args, grepExcludeStr(opts.ExcludeFiles)...)
+	args = append(args, grepIncludeStr(grepIncludes(opts.IncludeGlobs))...)
+	if opts.RegexEngine == RegexEnginePerl && !opts.FixedStrings {
+		searchWords = []string{perlPattern(searchWords)}
+	}
+	args = append(args, searchWordsStr(searchWords)...)
+	// the symlinks to follow are resolved by grep, the files of their targets are listed in the paths
+	args = append(args, "--recursive")
+	args = append(args, "--only-matching", "--with-filename")
+	if opts.SearchBinary {
+		args = append(args, "--binary-files=text")
+	} else {
+		args = append(args, "--binary-files=without-match")
+	}
+	if !opts.CaseSensitive {
+		args = append(args, "--ignore-case")
+	}
+	if opts.WordBoundary {
+		args = append(args, "--word-regexp")
+	}
+	if opts.FixedStrings {
+		args = append(args, "--fixed-strings")
+	}
+	args = append(args, grepRegexEngineArgs(opts.RegexEngine, opts.FixedStrings)...)
+	args = append(args, grepPathsStr(path, opts.Paths)...)
+
+	binary := opts.Binary
+	if binary == "" {
+		binary = DefaultGrepBinary
+	}
+	return exec.CommandContext(ctx, binary, args...)
+}
+
+func grepPathsStr(basePath string, paths []string) []string {
+	if len(paths) == 0 {
+		return []string{basePath}
+	}
+	var result []string
+	for _, path := range paths {
+		result = append(result, basePath+"/"+path)
+	}
+	return result
 }
 
 func searchWordsStr(searchWords []string) []string {
@@ -167,28 +1459,91 @@ func searchWordsStr(searchWords []string) []string {
 func grepExcludeDirStr(excludeDirs []string) []string {
 	var result []string
 	for _, dir := range excludeDirs {
+		// grep only matches --exclude-dir against base names, nested paths are filtered by filterExcludeDirs
+		if strings.Contains(strings.TrimSuffix(dir, "/"), "/") {
+			continue
+		}
 		result = append(result, "--exclude-dir="+dir)
 	}
 	return result
 }
 
-func parseGrepOutput(out, basePath string) []GrepResult {
-	var results []GrepResult
-	pathCounts := make(map[string]int)
+func grepExcludeStr(excludeFiles []string) []string {
+	var result []string
+	for _, file := range excludeFiles {
+		// grep only matches --exclude against base names, globs with a path are filtered by filterExcludeFiles
+		if strings.Contains(file, "/") {
+			continue
+		}
+		result = append(result, "--exclude="+file)
+	}
+	return result
+}
+
+func grepIncludeStr(includes []string) []string {
+	var result []string
+	for _, include := range includes {
+		result = append(result, "--include="+include)
+	}
+	return result
+}
 
+// parseGrepOutput counts the matches per file, the words of the results are counted per matched text
+func parseGrepOutput(out, basePath string) []GrepResult {
+	counter := newGrepCounter(basePath)
 	for _, line := range strings.Split(out, "\n") {
-		if path, searchWord := splitOutputLine(line); path != "" && searchWord != "" {
-			pathCounts[removeBasePath(path, basePath)] += 1
+		counter.add(line)
+	}
+	return counter.results()
+}
+
+// parseGrepStream counts the matches per file like parseGrepOutput, reading the output line by line
+func parseGrepStream(r io.Reader, basePath string) ([]GrepResult, error) {
+	counter := newGrepCounter(basePath)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxGrepLineLength)
+	for scanner.Scan() {
+		counter.add(scanner.Text())
+	}
+	return counter.results(), scanner.Err()
+}
+
+// grepCounter counts the matches per file from the lines of the grep output
+type grepCounter struct {
+	basePath   string
+	pathCounts map[string]int
+	pathWords  map[string]map[string]int
+}
+
+func newGrepCounter(basePath string) *grepCounter {
+	return &grepCounter{
+		basePath:   basePath,
+		pathCounts: make(map[string]int),
+		pathWords:  make(map[string]map[string]int),
+	}
+}
+
+func (c *grepCounter) add(line string) {
+	if path, searchWord := splitOutputLine(line); path != "" && searchWord != "" {
+		path = removeBasePath(path, c.basePath)
+		c.pathCounts[path] += 1
+		if c.pathWords[path] == nil {
+			c.pathWords[path] = make(map[string]int)
 		}
+		c.pathWords[path][searchWord] += 1
 	}
+}
 
-	for path, count := range pathCounts {
+func (c *grepCounter) results() []GrepResult {
+	var results []GrepResult
+	for path, count := range c.pathCounts {
 		results = append(results, GrepResult{
 			FileName: path,
 			Count:    count,
+			Words:    c.pathWords[path],
 		})
 	}
-
+	sortGrepResults(results, FileOrderName)
 	return results
 }
 
@@ -202,29 +1557,36 @@ func splitOutputLine(grepLine string) (string, string) {
 	return "", ""
 }
 
+// removeBasePath returns the path relative to basePath, which grep prints the path of the file with. Both are
+// cleaned first, so a basePath with a trailing or a double slash is the same dir.
 func removeBasePath(path, basePath string) string {
-	if cleanName := strings.Split(path, basePath+"/"); len(cleanName) >= 1 {
-		return cleanName[1]
+	rel, err := filepath.Rel(basePath, path)
+	if err != nil {
+		return path
 	}
-	return ""
+	return rel
 }
 
-func writeResult(fileName string, data ResultFile) error {
+// writeResult writes the data to fileName, a crash while writing leaves any previous result in fileName intact
+func writeResult(fileName string, data ResultFile, perm os.FileMode) error {
 	file, err := json.MarshalIndent(data, "", " ")
 	if err != nil {
 		return err
 	}
-	if err = ioutil.WriteFile(fileName, file, 0664); err != nil {
+	return writeFileAtomic(fileName, perm, func(w io.Writer) error {
+		_, err := w.Write(file)
 		return err
-	}
-	return nil
+	})
 }
 
 type removeDir = func()
 
 // cloneRepo clones the given repo using 'git clone' and returns the path to the cloned repo and a func to remove it in the filesystem
-func cloneRepo(r Repository) (string, removeDir, error) {
-	dir, err := ioutil.TempDir("", "clone")
+func cloneRepo(ctx context.Context, r Repository, cfg Config) (string, removeDir, error) {
+	if cfg.CacheDir != "" {
+		return cachedClone(ctx, r, cfg)
+	}
+	dir, err := cloneDir(r, cfg)
 	if err != nil {
 		return "", nil, err
 	}
@@ -232,17 +1594,87 @@ func cloneRepo(r Repository) (string, removeDir, error) {
 		func(path string) {
 			err := os.RemoveAll(path)
 			if err != nil {
-				log.Println("unable to remove dir: ", err)
+				slog.Error("unable to remove dir", "error", err)
 			}
 		}(dir)
 	}
 
-	cloneCmd := exec.Command("git", "clone", r.Url, dir)
-	log.Println("running command: " + strings.Join(cloneCmd.Args, " "))
-	if err := cloneCmd.Run(); err != nil {
+	if err := gitClone(ctx, r, dir, cfg); err != nil {
 		removeDir()
-		log.Fatal("unable to git clone "+r.Name, err)
+		return "", nil, err
 	}
 
 	return dir, removeDir, nil
 }
+
+// gitClone clones the repository into dir and checks out its ref when it is a commit SHA, with go-git when it is the git_client
+func gitClone(ctx context.Context, r Repository, dir string, cfg Config) error {
+	if cfg.GitClient == GitClientGoGit {
+		return goGitClone(ctx, r, dir, cfg)
+	}
+	cloneCmd := cloneCommand(ctx, r, dir, cfg)
+	logCommand(cloneCmd.Args)
+	if err := cloneCmd.Run(); err != nil {
+		return fmt.Errorf("unable to git clone %s: %w", r.Name, err)
+	}
+	if isCommitSHA(r.Ref) {
+		_, checkoutSpan := startSpan(ctx, "checkout", "repository", r.Name, "ref", r.Ref)
+		err := checkoutCommit(ctx, cfg.gitBinary(), dir, r.Ref, cloneDepth(r, cfg), gitAuthEnv(r, cfg))
+		checkoutSpan.end(err)
+		if err != nil {
+			return fmt.Errorf("unable to check out %s of %s: %w", r.Ref, r.Name, err)
+		}
+	}
+	return nil
+}
+
+// cloneDir creates the dir the repository is cloned into. With deterministic_temp the name of the dir is derived
+// from the url of the repository, so repeated runs use the same path, and whatever a previous run left in it is removed.
+func cloneDir(r Repository, cfg Config) (string, error) {
+	if !cfg.DeterministicTemp {
+		return ioutil.TempDir("", "clone")
+	}
+	dir := filepath.Join(os.TempDir(), deterministicDirName(cloneKey(r)))
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("unable to clean up clone dir '%s': %w", dir, err)
+	}
+	// Mkdir fails when the dir exists, so another run which recreated the dir in between is never shared
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return "", fmt.Errorf("unable to create clone dir '%s': %w", dir, err)
+	}
+	return dir, nil
+}
+
+// deterministicDirName returns the name of the clone dir of the url when deterministic_temp is set
+func deterministicDirName(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return "clone-" + hex.EncodeToString(sum[:8])
+}
+
+// cloneCommand builds the 'git clone' command, when an ssh key path is configured it is used by ssh for this command only
+func cloneCommand(ctx context.Context, r Repository, dir string, cfg Config) *exec.Cmd {
+	args := []string{"clone"}
+	if depth := cloneDepth(r, cfg); depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth), "--single-branch")
+	}
+	if r.Ref != "" && !isCommitSHA(r.Ref) {
+		args = append(args, "--branch", r.Ref)
+	}
+	args = append(args, cloneURL(r.Url, cfg.CloneProtocol), dir)
+	cmd := exec.CommandContext(ctx, cfg.gitBinary(), args...)
+	cmd.Env = gitAuthEnv(r, cfg)
+	return cmd
+}
+
+// cloneDepth returns the number of commits to clone, 0 clones the whole history.
+// Only the last commit is cloned unless full_history is set or the repository needs more of its history.
+func cloneDepth(r Repository, cfg Config) int {
+	switch {
+	case cfg.FullHistory, r.ChangedSince != "":
+		return 0
+	case r.History != nil:
+		return r.History.Commits
+	default:
+		return 1
+	}
+}
@@ -1,9 +1,17 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	IS "github.com/matryer/is"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseGrepOutput(t *testing.T) {
@@ -26,19 +34,30 @@ func TestParseGrepOutput(t *testing.T) {
 	}
 }
 
+func TestSortGrepResults(t *testing.T) {
+	is := IS.New(t)
+	grs := []GrepResult{{FileName: "b.go", Count: 1}, {FileName: "c.go", Count: 3}, {FileName: "a.go", Count: 1}}
+
+	sortGrepResults(grs, FileOrderName)
+	is.Equal([]GrepResult{{FileName: "a.go", Count: 1}, {FileName: "b.go", Count: 1}, {FileName: "c.go", Count: 3}}, grs)
+	sortGrepResults(grs, FileOrderCount)
+	is.Equal([]GrepResult{{FileName: "c.go", Count: 3}, {FileName: "a.go", Count: 1}, {FileName: "b.go", Count: 1}}, grs)
+	is.True(validateConfig(Config{SearchWords: []string{"fell"}, FileOrder: "size"}) != nil)
+}
+
 func TestGrep(t *testing.T) {
 	is := IS.New(t)
 	expectedResult := []GrepResult{
 		{
-			"testdata_1.txt",
-			2,
+			FileName: "testdata_1.txt",
+			Count:    2,
 		},
 		{
-			"testdata_2.txt",
-			4,
+			FileName: "testdata_2.txt",
+			Count:    4,
 		},
 	}
-	result, err := grep("./testdata", []string{"fell"}, []string{})
+	result, err := grep(context.Background(), "./testdata", []string{"fell"}, grepOptions{})
 
 	is.NoErr(err)
 	is.Equal(len(expectedResult), len(result))
@@ -48,3 +67,654 @@ func TestGrep(t *testing.T) {
 	}
 
 }
+
+func TestSortOnAppCountSumDesc(t *testing.T) {
+	is := IS.New(t)
+	expectedNames := []string{"delta", "alpha", "bravo", "charlie", "echo"}
+	result := ResultFile{
+		Applications: []Application{
+			{Name: "charlie", CountSum: 5},
+			{Name: "echo", CountSum: 1},
+			{Name: "alpha", CountSum: 5},
+			{Name: "delta", CountSum: 9},
+			{Name: "bravo", CountSum: 5},
+		},
+	}
+
+	sorted := sortOnAppCountSumDesc(result)
+
+	is.Equal(len(expectedNames), len(sorted.Applications))
+	for i, app := range sorted.Applications {
+		is.Equal(expectedNames[i], app.Name)
+	}
+}
+
+func TestAnalyzeRepoKeepClones(t *testing.T) {
+	is := IS.New(t)
+	repo := Repository{Name: "test-repo", Url: newTestRepo(t, map[string]string{"words.txt": "fell fell"})}
+
+	app, err := analyzeRepo(context.Background(), repo, Config{SearchWords: []string{"fell"}, KeepClones: true})
+	is.NoErr(err)
+	defer os.RemoveAll(app.ClonePath)
+
+	is.Equal(2, app.CountSum)
+	is.True(app.ClonePath != "")
+	_, err = os.Stat(filepath.Join(app.ClonePath, "words.txt"))
+	is.NoErr(err) // the clone should still exist
+}
+
+func TestAnalyzeRepoRemovesClone(t *testing.T) {
+	is := IS.New(t)
+	repo := Repository{Name: "test-repo", Url: newTestRepo(t, map[string]string{"words.txt": "fell fell"})}
+	// with deterministic_temp the path of the clone is known up front
+	path := filepath.Join(os.TempDir(), deterministicDirName(cloneKey(repo)))
+
+	app, err := analyzeRepo(context.Background(), repo, Config{SearchWords: []string{"fell"}, DeterministicTemp: true})
+
+	is.NoErr(err)
+	is.Equal(2, app.CountSum) // the repository was cloned into the path
+	is.Equal("", app.ClonePath)
+	_, err = os.Stat(path)
+	is.True(os.IsNotExist(err))
+}
+
+// newTestRepo creates a git repository with the given files committed and returns its path
+func newTestRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "--all"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--message=init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+		}
+	}
+	return dir
+}
+
+func TestCalculateExtensionTotals(t *testing.T) {
+	is := IS.New(t)
+	result := ResultFile{
+		Applications: []Application{
+			{
+				Name: "backend",
+				GrepResults: []GrepResult{
+					{FileName: "main.go", Count: 3},
+					{FileName: "game/game.go", Count: 2},
+					{FileName: "LICENSE", Count: 12},
+					{FileName: "docs/README.md", Count: 1},
+				},
+			},
+			{
+				Name: "frontend",
+				GrepResults: []GrepResult{
+					{FileName: "src/index.js", Count: 4},
+					{FileName: ".gitignore", Count: 1},
+					{FileName: "CHANGELOG.md", Count: 5},
+				},
+			},
+		},
+	}
+
+	totals := calculateExtensionTotals(result)
+
+	is.Equal(map[string]int{".go": 5, ".md": 6, ".js": 4, NoExtension: 13}, totals)
+}
+
+func TestCloneCommandWithSSHKeyPath(t *testing.T) {
+	is := IS.New(t)
+	repo := Repository{Name: "test-repo", Url: "git@github.com:akselleirv/introspect-backend.git"}
+
+	cmd := cloneCommand(context.Background(), repo, "/tmp/clone", Config{SSHKeyPath: "/keys/id_ed25519"})
+
+	is.Equal([]string{"git", "clone", "--depth", "1", "--single-branch", repo.Url, "/tmp/clone"}, cmd.Args)
+	is.Equal("GIT_SSH_COMMAND=ssh -i '/keys/id_ed25519' -o StrictHostKeyChecking=no", cmd.Env[len(cmd.Env)-1])
+}
+
+func TestCloneCommandWithoutSSHKeyPath(t *testing.T) {
+	is := IS.New(t)
+
+	cmd := cloneCommand(context.Background(), Repository{Url: "git@github.com:akselleirv/introspect-backend.git"}, "/tmp/clone", Config{})
+
+	is.Equal(nil, cmd.Env) // the environment of the process is used
+}
+
+func TestGrepExcludesGitDir(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, "words.txt"), []byte("fell"), 0644))
+	is.NoErr(os.Mkdir(filepath.Join(dir, ".git"), 0755))
+	is.NoErr(os.WriteFile(filepath.Join(dir, ".git", "COMMIT_EDITMSG"), []byte("fell fell"), 0644))
+
+	for _, concurrency := range []int{1, 2} {
+		result, err := grepConcurrent(context.Background(), dir, []string{"fell"}, grepOptions{}, concurrency)
+		is.NoErr(err)
+		is.Equal([]GrepResult{{FileName: "words.txt", Count: 1, Words: map[string]int{"fell": 1}}}, result) // the .git dir is excluded by default
+	}
+
+	result, err := grep(context.Background(), dir, []string{"fell"}, grepOptions{ScanGitDir: true})
+	is.NoErr(err)
+	is.Equal(3, sumTotalCountForGrepResults(result))
+}
+
+func TestRemoveGrepResults(t *testing.T) {
+	is := IS.New(t)
+	result := ResultFile{
+		Applications: []Application{
+			{Name: "backend", GrepResults: []GrepResult{{FileName: "main.go", Count: 3}, {FileName: "LICENSE", Count: 2}}},
+			{Name: "frontend", GrepResults: []GrepResult{{FileName: "index.js", Count: 4}}},
+		},
+	}
+	for i := range result.Applications {
+		result.Applications[i].CountSum = sumTotalCountForGrepResults(result.Applications[i].GrepResults)
+	}
+	result.TotalCountSum = calculateTotalCountSum(result)
+
+	summary := removeGrepResults(result)
+	file, err := json.Marshal(summary)
+
+	is.NoErr(err)
+	is.True(!strings.Contains(string(file), "grep_results")) // the per file results should be left out
+	is.Equal(9, summary.TotalCountSum)
+	is.Equal(5, summary.Applications[0].CountSum)
+	is.Equal(4, summary.Applications[1].CountSum)
+}
+
+func TestRunExitCodes(t *testing.T) {
+	validConfig := `{"search_words": ["fell"], "repositories": [{"name": "a", "url": "a.git"}, {"name": "b", "url": "b.git"}]}`
+	testCases := []struct {
+		name     string
+		config   string
+		failing  []string
+		exitCode int
+	}{
+		{"success", validConfig, nil, ExitCodeSuccess},
+		{"invalid json", `{"search_words": [`, nil, ExitCodeConfigError},
+		{"no search words", `{"repositories": [{"name": "a", "url": "a.git"}]}`, nil, ExitCodeConfigError},
+		{"repository without url", `{"search_words": ["fell"], "repositories": [{"name": "a"}]}`, nil, ExitCodeConfigError},
+		{"partial failure", validConfig, []string{"a"}, ExitCodePartialFailure},
+		{"total failure", validConfig, []string{"a", "b"}, ExitCodeTotalFailure},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := IS.New(t)
+			dir := t.TempDir()
+			configPath := filepath.Join(dir, "config.json")
+			resultPath := filepath.Join(dir, "results.json")
+			is.NoErr(os.WriteFile(configPath, []byte(tc.config), 0644))
+			analyze := func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+				for _, name := range tc.failing {
+					if r.Name == name {
+						return Application{}, errors.New("clone failed")
+					}
+				}
+				return Application{Name: r.Name, CountSum: 1}, nil
+			}
+
+			is.Equal(tc.exitCode, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON}, analyze))
+
+			_, err := os.Stat(resultPath)
+			is.Equal(tc.exitCode != ExitCodeConfigError, err == nil) // the result is saved unless the config is invalid
+		})
+	}
+}
+
+func TestValidateConfigRejectsMultilineWithGrep(t *testing.T) {
+	is := IS.New(t)
+	cfg := Config{SearchWords: []string{"func.*error"}, Multiline: true}
+
+	is.True(validateConfig(cfg) != nil)
+}
+
+func TestCommandsUseConfiguredBinaries(t *testing.T) {
+	is := IS.New(t)
+	cfg := Config{GrepBinary: "ggrep", GitBinary: "/opt/git/bin/git"}
+
+	grepCmd := grepCommand(context.Background(), "./testdata", []string{"fell"}, grepOptions{Binary: cfg.GrepBinary})
+	cloneCmd := cloneCommand(context.Background(), Repository{Url: "git@github.com:akselleirv/introspect-backend.git"}, "/tmp/clone", cfg)
+	diffCmd := gitDiffCommand(context.Background(), cfg.gitBinary(), "/tmp/clone", "origin/main")
+
+	is.Equal("ggrep", grepCmd.Args[0])
+	is.Equal("/opt/git/bin/git", cloneCmd.Args[0])
+	is.Equal("/opt/git/bin/git", diffCmd.Args[0])
+}
+
+func TestCheckBinaries(t *testing.T) {
+	is := IS.New(t)
+
+	is.NoErr(checkBinaries(Config{}))
+	is.True(checkBinaries(Config{GrepBinary: "grep-binary-which-does-not-exist"}) != nil)
+
+	// git is only required when it is run
+	missingGit := "git-binary-which-does-not-exist"
+	is.NoErr(checkBinaries(Config{GitBinary: missingGit, Repositories: []Repository{{Name: "dir"}, {Name: "local", Path: "/src/local"}}}))
+	is.True(checkBinaries(Config{GitBinary: missingGit, Repositories: []Repository{{Name: "remote", Url: "https://example.com/remote.git"}}}) != nil)
+	is.True(checkBinaries(Config{GitBinary: missingGit, Repositories: []Repository{{Name: "local", Path: "/src/local", ChangedSince: "main"}}}) != nil)
+	is.True(checkBinaries(Config{GitBinary: missingGit, RespectGitignore: true}) != nil)
+}
+
+func TestContainsFiles(t *testing.T) {
+	is := IS.New(t)
+	empty := t.TempDir()
+	is.NoErr(os.MkdirAll(filepath.Join(empty, ".git", "objects"), 0755))
+	is.NoErr(os.WriteFile(filepath.Join(empty, ".git", "HEAD"), []byte("ref: refs/heads/main"), 0644))
+	is.NoErr(os.Mkdir(filepath.Join(empty, "docs"), 0755))
+
+	hasFiles, err := containsFiles(empty)
+	is.NoErr(err)
+	is.True(!hasFiles) // only the .git dir and empty dirs
+
+	hasFiles, err = containsFiles("./testdata")
+	is.NoErr(err)
+	is.True(hasFiles)
+}
+
+func TestAnalyzeRepoStatus(t *testing.T) {
+	is := IS.New(t)
+	empty := t.TempDir()
+	cmd := exec.Command("git", "init", "--quiet", empty)
+	is.NoErr(cmd.Run())
+	noMatches := newTestRepo(t, map[string]string{"words.txt": "nothing to see"})
+
+	app, err := analyzeRepo(context.Background(), Repository{Name: "empty", Url: empty}, Config{SearchWords: []string{"fell"}})
+	is.NoErr(err)
+	is.Equal(StatusEmpty, app.Status)
+
+	app, err = analyzeRepo(context.Background(), Repository{Name: "no-matches", Url: noMatches}, Config{SearchWords: []string{"fell"}})
+	is.NoErr(err)
+	is.Equal(StatusOK, app.Status)
+	is.Equal(0, app.CountSum)
+}
+
+func TestGrepCaseSensitive(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, "todo.txt"), []byte("TODO todo TODO"), 0644))
+
+	result, err := grep(context.Background(), dir, []string{"TODO", "todo"}, grepOptions{CaseSensitive: true})
+
+	is.NoErr(err)
+	is.Equal(3, sumTotalCountForGrepResults(result))
+	is.Equal(map[string]int{"TODO": 2, "todo": 1}, sumWordCounts(result))
+}
+
+func TestRunDir(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	resultPath := filepath.Join(dir, "results.json")
+	config := `{"search_words": ["fell"], "exclude_dirs": ["encoding"], "repositories": [{"name": "a", "url": "a.git"}]}`
+	is.NoErr(os.WriteFile(configPath, []byte(config), 0644))
+	analyze := func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		t.Fatal("the repositories in the config should not be analyzed")
+		return Application{}, nil
+	}
+
+	is.Equal(ExitCodeSuccess, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON, Dir: "./testdata"}, analyze))
+
+	content, err := os.ReadFile(resultPath)
+	is.NoErr(err)
+	var result ResultFile
+	is.NoErr(json.Unmarshal(content, &result))
+	is.Equal(1, result.TotalApplications)
+	is.Equal(6, result.TotalCountSum)
+	is.Equal("testdata", result.Applications[0].Name)
+	counts := make(map[string]int)
+	for _, gr := range result.Applications[0].GrepResults {
+		counts[gr.FileName] = gr.Count
+	}
+	is.Equal(map[string]int{"testdata_1.txt": 2, "testdata_2.txt": 4}, counts)
+}
+
+func TestRunDirWithTrailingSlash(t *testing.T) {
+	is := IS.New(t)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": ["fell"], "exclude_dirs": ["encoding"]}`), 0644))
+	abs, err := filepath.Abs("./testdata")
+	is.NoErr(err)
+
+	for _, dir := range []string{"./testdata/", "./testdata//", abs + "/"} {
+		resultPath := filepath.Join(t.TempDir(), "results.json")
+		is.Equal(ExitCodeSuccess, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON, Dir: dir}, nil))
+
+		result, err := readResultFile(resultPath)
+		is.NoErr(err)
+		is.Equal("testdata", result.Applications[0].Name)
+		counts := make(map[string]int)
+		for _, gr := range result.Applications[0].GrepResults {
+			counts[gr.FileName] = gr.Count
+		}
+		is.Equal(map[string]int{"testdata_1.txt": 2, "testdata_2.txt": 4}, counts) // dir: dir
+	}
+}
+
+func TestRemoveBasePath(t *testing.T) {
+	is := IS.New(t)
+	is.Equal("a/b.txt", removeBasePath("./testdata/a/b.txt", "./testdata"))
+	is.Equal("a/b.txt", removeBasePath("testdata//a/b.txt", "testdata/"))
+	is.Equal("b.txt", removeBasePath("/src/repo/b.txt", "/src/repo/"))
+}
+
+func TestRunWithLines(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	resultPath := filepath.Join(dir, "results.json")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": ["fell"], "exclude_dirs": ["encoding"]}`), 0644))
+
+	is.Equal(ExitCodeSuccess, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON, Dir: "./testdata", WithLines: true}, nil))
+
+	content, err := os.ReadFile(resultPath)
+	is.NoErr(err)
+	var result ResultFile
+	is.NoErr(json.Unmarshal(content, &result))
+	for _, gr := range result.Applications[0].GrepResults {
+		is.Equal(gr.Count, len(gr.Matches)) // every match has its line
+		is.True(gr.Matches[0].Line > 0)
+	}
+}
+
+func TestRunFailIfAnyMatch(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	resultPath := filepath.Join(dir, "results.json")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": ["fell"]}`), 0644))
+
+	is.Equal(ExitCodeThreshold, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON, Dir: "./testdata", FailIfAnyMatch: true}, nil))
+	_, err := os.Stat(resultPath)
+	is.NoErr(err) // the result is saved before failing
+}
+
+func TestParseGrepStreamMatchesBatch(t *testing.T) {
+	is := IS.New(t)
+	var out strings.Builder
+	for i := 0; i < 200000; i++ {
+		fmt.Fprintf(&out, "/tmp/clone/dir-%d/file-%d.go:%s\n", i%7, i%113, []string{"fell", "FELL", "use"}[i%3])
+	}
+
+	batch := parseGrepOutput(out.String(), "/tmp/clone")
+	stream, err := parseGrepStream(strings.NewReader(out.String()), "/tmp/clone")
+	is.NoErr(err)
+	sortOnFileName(batch)
+	sortOnFileName(stream)
+
+	is.Equal(len(batch), len(stream))
+	is.Equal(batch, stream)
+	is.Equal(200000, sumTotalCountForGrepResults(stream))
+}
+
+func TestSortApplicationsOnDensity(t *testing.T) {
+	is := IS.New(t)
+	apps := []Application{
+		{Name: "large", CountSum: 50, LinesScanned: 100000},
+		{Name: "small", CountSum: 10, LinesScanned: 500},
+		{Name: "empty", CountSum: 0, LinesScanned: 0},
+	}
+	for i := range apps {
+		apps[i].Density = density(apps[i].CountSum, apps[i].LinesScanned)
+	}
+
+	sorted := sortApplications(ResultFile{Applications: apps}, ScoreModeDensity)
+
+	is.Equal(20.0, sorted.Applications[0].Density)
+	is.Equal("small", sorted.Applications[0].Name)
+	is.Equal(0.5, sorted.Applications[1].Density)
+	is.Equal("large", sorted.Applications[1].Name)
+	is.Equal("empty", sorted.Applications[2].Name)
+	is.Equal("large", apps[0].Name) // the applications of the result are not sorted in place
+}
+
+func TestAnalyzePathDensity(t *testing.T) {
+	is := IS.New(t)
+	cfg := Config{SearchWords: []string{"fell"}, ExcludeDirs: []string{"encoding"}, ScoreMode: ScoreModeDensity}
+
+	app, err := analyzePath(context.Background(), Repository{Name: "testdata"}, cfg, "./testdata")
+
+	is.NoErr(err)
+	is.Equal(5, app.LinesScanned)
+	is.Equal(6, app.CountSum)
+	is.Equal(1200.0, app.Density)
+}
+
+func TestClonePathMissing(t *testing.T) {
+	is := IS.New(t)
+	missing := filepath.Join(t.TempDir(), "removed-clone")
+
+	_, err := grep(context.Background(), missing, []string{"fell"}, grepOptions{})
+	is.True(errors.Is(err, ErrClonePathMissing))
+
+	_, err = analyzePath(context.Background(), Repository{Name: "removed"}, Config{SearchWords: []string{"fell"}}, missing)
+	is.True(errors.Is(err, ErrClonePathMissing))
+}
+
+func TestDirMissing(t *testing.T) {
+	is := IS.New(t)
+	missing := filepath.Join(t.TempDir(), "missing")
+	repositories, analyze := localDir(missing)
+
+	_, err := analyze(context.Background(), repositories[0], Config{SearchWords: []string{"fell"}})
+	is.True(errors.Is(err, ErrDirMissing))
+	is.True(!errors.Is(err, ErrClonePathMissing))
+
+	_, err = analyzeLocalRepo(context.Background(), Repository{Name: "local", Path: missing}, Config{SearchWords: []string{"fell"}})
+	is.True(errors.Is(err, ErrDirMissing))
+}
+
+func TestCapCounts(t *testing.T) {
+	is := IS.New(t)
+	grs := []GrepResult{
+		{FileName: "below.go", Count: 99},
+		{FileName: "at.go", Count: 100},
+		{FileName: "above.go", Count: 101},
+		{FileName: "bundle.min.js", Count: 25000},
+	}
+
+	capCounts(grs, 100)
+
+	is.Equal([]GrepResult{
+		{FileName: "below.go", Count: 99},
+		{FileName: "at.go", Count: 100},
+		{FileName: "above.go", Count: 100, Truncated: true},
+		{FileName: "bundle.min.js", Count: 100, Truncated: true},
+	}, grs)
+	is.Equal(399, sumTotalCountForGrepResults(grs))
+}
+
+func TestAnalyzePathMaxCountPerFile(t *testing.T) {
+	is := IS.New(t)
+	cfg := Config{SearchWords: []string{"fell"}, ExcludeDirs: []string{"encoding"}, MaxCountPerFile: 1}
+
+	app, err := analyzePath(context.Background(), Repository{Name: "testdata"}, cfg, "./testdata")
+
+	is.NoErr(err)
+	is.Equal(2, app.CountSum)
+	is.True(app.GrepResults[0].Truncated)
+}
+
+func TestDeterministicDirName(t *testing.T) {
+	is := IS.New(t)
+
+	is.Equal(deterministicDirName("https://github.com/akselleirv/introspect-backend.git"), deterministicDirName("https://github.com/akselleirv/introspect-backend.git"))
+	is.True(deterministicDirName("https://github.com/akselleirv/introspect-backend.git") != deterministicDirName("https://github.com/akselleirv/introspect-frontend.git"))
+}
+
+func TestCloneDirDeterministicTemp(t *testing.T) {
+	is := IS.New(t)
+	t.Setenv("TMPDIR", t.TempDir())
+	r := Repository{Name: "backend", Url: "https://github.com/akselleirv/introspect-backend.git"}
+	cfg := Config{DeterministicTemp: true}
+
+	dir, err := cloneDir(r, cfg)
+	is.NoErr(err)
+	is.NoErr(os.WriteFile(filepath.Join(dir, "left-over.txt"), []byte("fell"), 0644))
+
+	again, err := cloneDir(r, cfg)
+	is.NoErr(err)
+	is.Equal(dir, again)
+	entries, err := os.ReadDir(again)
+	is.NoErr(err)
+	is.Equal(0, len(entries)) // the content of the previous run is removed
+}
+
+func TestAnalyzeRepoPath(t *testing.T) {
+	is := IS.New(t)
+	repo := newTestRepo(t, map[string]string{"words.txt": "fell fell"})
+	commit, err := headCommit(context.Background(), DefaultGitBinary, repo)
+	is.NoErr(err)
+
+	app, err := analyzeRepo(context.Background(), Repository{Name: "local", Path: repo}, Config{SearchWords: []string{"fell"}})
+
+	is.NoErr(err)
+	is.Equal(2, app.CountSum)
+	is.Equal(commit, app.Commit)
+	_, err = os.Stat(repo)
+	is.NoErr(err) // the local dir is not removed like a clone
+}
+
+func TestValidateConfigDeterministicTempDuplicateUrl(t *testing.T) {
+	is := IS.New(t)
+	cfg := Config{SearchWords: []string{"fell"}, DeterministicTemp: true, Repositories: []Repository{
+		{Name: "a", Url: "https://github.com/akselleirv/introspect-backend.git"},
+		{Name: "b", Url: "https://github.com/akselleirv/introspect-backend.git"},
+	}}
+
+	is.True(validateConfig(cfg) != nil)
+	cfg.DeterministicTemp = false
+	is.NoErr(validateConfig(cfg))
+}
+
+func TestCloneCommandWithRef(t *testing.T) {
+	is := IS.New(t)
+
+	cmd := cloneCommand(context.Background(), Repository{Url: "https://github.com/akselleirv/introspect-backend.git", Ref: "v2.0"}, "/tmp/clone", Config{})
+
+	is.Equal([]string{"git", "clone", "--depth", "1", "--single-branch", "--branch", "v2.0", "https://github.com/akselleirv/introspect-backend.git", "/tmp/clone"}, cmd.Args)
+}
+
+func TestRunApplicationCounts(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	resultPath := filepath.Join(dir, "results.json")
+	config := `{"search_words": ["fell"], "repositories": [
+		{"name": "a", "url": "a.git"}, {"name": "b", "url": "b.git"}, {"name": "empty", "url": "empty.git"}, {"name": "failed", "url": "failed.git"}
+	]}`
+	is.NoErr(os.WriteFile(configPath, []byte(config), 0644))
+	analyze := func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		switch r.Name {
+		case "failed":
+			return Application{}, errors.New("clone failed")
+		case "empty":
+			return Application{Name: r.Name, Status: StatusEmpty}, nil
+		}
+		return Application{Name: r.Name, Status: StatusOK, CountSum: 1}, nil
+	}
+
+	is.Equal(ExitCodePartialFailure, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON}, analyze))
+
+	file, err := os.ReadFile(resultPath)
+	is.NoErr(err)
+	var results ResultFile
+	is.NoErr(json.Unmarshal(file, &results))
+	is.Equal(4, results.TotalApplications)
+	is.Equal(2, results.SucceededApplications)
+	is.Equal(1, results.EmptyApplications)
+	is.Equal(1, results.FailedApplications)
+	is.Equal([]string{"failed on repo 'failed': clone failed"}, results.Errors)
+	is.Equal(4, len(results.Applications)) // the failed application is saved with the others
+	failed := results.Applications[len(results.Applications)-1]
+	is.Equal(Application{Name: "failed", Status: StatusFailed, Error: "clone failed"}, failed)
+}
+
+func TestRunInterrupted(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	resultPath := filepath.Join(dir, "results.json")
+	config := `{"search_words": ["fell"], "repositories": [{"name": "fast", "url": "fast.git"}, {"name": "slow", "url": "slow.git"}]}`
+	is.NoErr(os.WriteFile(configPath, []byte(config), 0644))
+	slowStarted := make(chan struct{})
+	analyze := func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		if r.Name == "fast" {
+			return Application{Name: r.Name, Status: StatusOK, CountSum: 1}, nil
+		}
+		close(slowStarted)
+		select {
+		case <-ctx.Done():
+			return Application{}, ctx.Err()
+		case <-time.After(10 * time.Second):
+			return Application{Name: r.Name, Status: StatusOK}, nil
+		}
+	}
+	ctx, interrupt := context.WithCancel(context.Background())
+	defer interrupt()
+	go func() {
+		<-slowStarted
+		interrupt()
+	}()
+
+	start := time.Now()
+	exitCode := run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON, Context: ctx}, analyze)
+
+	is.True(time.Since(start) < 5*time.Second) // the slow repo should be cancelled
+	is.Equal(ExitCodePartialFailure, exitCode)
+	file, err := os.ReadFile(resultPath)
+	is.NoErr(err)
+	var results ResultFile
+	is.NoErr(json.Unmarshal(file, &results))
+	is.Equal(1, results.SucceededApplications)
+	is.Equal(1, results.TotalCountSum)
+}
+
+func TestAnalyzeRepoCancelledRemovesClone(t *testing.T) {
+	is := IS.New(t)
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	repo := newTestRepo(t, map[string]string{"words.txt": "fell"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := analyzeRepo(ctx, Repository{Name: "cancelled", Url: repo}, Config{SearchWords: []string{"fell"}})
+
+	is.True(err != nil)
+	entries, err := os.ReadDir(tmp)
+	is.NoErr(err)
+	is.Equal(0, len(entries)) // the clone dir is removed
+}
+
+func TestCloneDepth(t *testing.T) {
+	is := IS.New(t)
+
+	is.Equal(1, cloneDepth(Repository{}, Config{}))
+	is.Equal(0, cloneDepth(Repository{}, Config{FullHistory: true}))
+	is.Equal(0, cloneDepth(Repository{ChangedSince: "origin/main"}, Config{}))
+	is.Equal(10, cloneDepth(Repository{History: &HistoryConfig{Commits: 10}}, Config{}))
+
+	cmd := cloneCommand(context.Background(), Repository{Url: "https://github.com/akselleirv/introspect-backend.git"}, "/tmp/clone", Config{FullHistory: true})
+	is.Equal([]string{"git", "clone", "https://github.com/akselleirv/introspect-backend.git", "/tmp/clone"}, cmd.Args)
+}
+
+func TestValidateFormat(t *testing.T) {
+	is := IS.New(t)
+	for format, features := range formats {
+		is.NoErr(validateFormat(format, Config{}))
+		is.Equal(features.AppendMode, validateFormat(format, Config{AppendMode: true}) == nil)
+		is.Equal(features.SummaryOnly, validateFormat(format, Config{SummaryOnly: true}) == nil)
+	}
+	is.Equal("unknown format 'yaml'", validateFormat("yaml", Config{}).Error())
+}
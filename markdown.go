@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	FormatMarkdown = "markdown"
+
+	// DefaultTop is the number of applications in the markdown table when -top is not given
+	DefaultTop = 10
+)
+
+// markdownCell escapes the text for a cell of a markdown table
+func markdownCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// renderMarkdown renders the result as a compact summary for pull request comments and wikis: the top applications
+// by count sum, all of them when top is 0, and the totals per search word
+func renderMarkdown(w io.Writer, rf ResultFile, top int) error {
+	apps := append([]Application{}, rf.Applications...)
+	sort.SliceStable(apps, func(i, j int) bool { return apps[i].CountSum > apps[j].CountSum })
+	rest := 0
+	if top > 0 && len(apps) > top {
+		apps, rest = apps[:top], len(apps)-top
+	}
+
+	var b strings.Builder
+	b.WriteString("## count-fell\n\n")
+	fmt.Fprintf(&b, "%d applications, %d failed, total count sum **%d**\n\n", rf.TotalApplications, rf.FailedApplications, rf.TotalCountSum)
+	b.WriteString("| Application | Count sum |\n|---|---:|\n")
+	for _, app := range apps {
+		fmt.Fprintf(&b, "| %s | %d |\n", markdownCell(app.Name), app.CountSum)
+	}
+	if rest > 0 {
+		fmt.Fprintf(&b, "\n%d more applications are not shown.\n", rest)
+	}
+	if len(rf.WordTotals) > 0 {
+		words := make([]string, 0, len(rf.WordTotals))
+		for word := range rf.WordTotals {
+			words = append(words, word)
+		}
+		sort.Slice(words, func(i, j int) bool {
+			if rf.WordTotals[words[i]] != rf.WordTotals[words[j]] {
+				return rf.WordTotals[words[i]] > rf.WordTotals[words[j]]
+			}
+			return words[i] < words[j]
+		})
+		b.WriteString("\n| Search word | Count |\n|---|---:|\n")
+		for _, word := range words {
+			fmt.Fprintf(&b, "| %s | %d |\n", markdownCell(word), rf.WordTotals[word])
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdown(fileName string, rf ResultFile, top int, perm os.FileMode) error {
+	return writeFileAtomic(fileName, perm, func(w io.Writer) error {
+		return renderMarkdown(w, rf, top)
+	})
+}
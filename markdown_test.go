@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	IS "github.com/matryer/is"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	is := IS.New(t)
+	var out bytes.Buffer
+	rf := ResultFile{
+		TotalApplications: 3,
+		TotalCountSum:     6,
+		WordTotals:        map[string]int{"fell": 5, "a|b": 1},
+		Applications: []Application{
+			{Name: "small", CountSum: 1},
+			{Name: "large", CountSum: 4},
+			{Name: "medium", CountSum: 1},
+		},
+	}
+
+	is.NoErr(renderMarkdown(&out, rf, 2))
+
+	is.Equal(`## count-fell
+
+3 applications, 0 failed, total count sum **6**
+
+| Application | Count sum |
+|---|---:|
+| large | 4 |
+| small | 1 |
+
+1 more applications are not shown.
+
+| Search word | Count |
+|---|---:|
+| fell | 5 |
+| a\|b | 1 |
+`, out.String())
+}
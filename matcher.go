@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// countFunc counts the matches in one file, see matcherCommandCounter
+type countFunc = func(ctx context.Context, fileName string) (int, error)
+
+// matcherCommandCounter counts the matches in a file by running the matcher command with the file appended as the last argument.
+// The command is not run through a shell, so the file name can not inject anything into it.
+func matcherCommandCounter(command []string) countFunc {
+	return func(ctx context.Context, fileName string) (int, error) {
+		cmd := matcherCmd(ctx, command, fileName)
+		out, err := cmd.Output()
+		if err != nil {
+			var exitError *exec.ExitError
+			if errors.As(err, &exitError) && len(bytes.TrimSpace(exitError.Stderr)) > 0 {
+				return 0, fmt.Errorf("unable to execute matcher command on '%s': %w: %s", fileName, err, bytes.TrimSpace(exitError.Stderr))
+			}
+			return 0, fmt.Errorf("unable to execute matcher command on '%s': %w", fileName, err)
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(string(out)))
+		if err != nil {
+			return 0, fmt.Errorf("matcher command did not print a count for '%s': %w", fileName, err)
+		}
+		return count, nil
+	}
+}
+
+func matcherCmd(ctx context.Context, command []string, fileName string) *exec.Cmd {
+	args := append(append([]string{}, command[1:]...), fileName)
+	return exec.CommandContext(ctx, command[0], args...)
+}
+
+// matchFiles counts the matches in the files grep would search in path with at most concurrency files counted at a time.
+// Files without any matches are left out, the same way grep does not report them.
+func matchFiles(ctx context.Context, path string, opts grepOptions, count countFunc, concurrency int) ([]GrepResult, error) {
+	files, err := searchedFiles(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	// the files are counted by a fixed pool of workers, which stop at the first error
+	counts := make([]int, len(files))
+	err = eachConcurrent(ctx, len(files), concurrency, func(ctx context.Context, i int) error {
+		n, err := count(ctx, filepath.Join(path, files[i]))
+		counts[i] = n
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := []GrepResult{}
+	for i, file := range files {
+		if counts[i] > 0 {
+			result = append(result, GrepResult{FileName: file, Count: counts[i]})
+		}
+	}
+	return result, nil
+}
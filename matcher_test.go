@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	IS "github.com/matryer/is"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMatcherCmdPassesFileAsArgument(t *testing.T) {
+	is := IS.New(t)
+
+	cmd := matcherCmd(context.Background(), []string{"count-nodes", "--lang=go"}, "/tmp/clone/x; rm -rf ~")
+
+	is.Equal([]string{"count-nodes", "--lang=go", "/tmp/clone/x; rm -rf ~"}, cmd.Args)
+}
+
+func TestMatchFilesWithMatcherCommand(t *testing.T) {
+	is := IS.New(t)
+	counter := matcherCommandCounter([]string{"sh", "-c", `grep -o fell "$1" | wc -l`, "matcher"})
+
+	result, err := matchFiles(context.Background(), "./testdata", grepOptions{ExcludeDirs: []string{"encoding"}}, counter, 2)
+
+	is.NoErr(err)
+	is.Equal(2, len(result))
+	is.Equal(6, sumTotalCountForGrepResults(result))
+}
+
+func TestMatchFilesCommandErrorWithStderr(t *testing.T) {
+	is := IS.New(t)
+	counter := matcherCommandCounter([]string{"sh", "-c", `echo "unsupported language" >&2; exit 3`, "matcher"})
+
+	_, err := matchFiles(context.Background(), "./testdata", grepOptions{ExcludeDirs: []string{"encoding"}}, counter, 1)
+
+	is.True(err != nil)
+	is.True(strings.HasSuffix(err.Error(), "exit status 3: unsupported language"))
+}
+
+func TestMatchFilesStopsAtFirstError(t *testing.T) {
+	is := IS.New(t)
+	files := map[string]string{}
+	for i := 0; i < 50; i++ {
+		files[fmt.Sprintf("%02d.go", i)] = ""
+	}
+	dir := newTestRepo(t, files)
+	var calls atomic.Int32
+	counter := func(ctx context.Context, fileName string) (int, error) {
+		calls.Add(1)
+		return 0, errors.New("matcher failed")
+	}
+
+	_, err := matchFiles(context.Background(), dir, grepOptions{}, counter, 2)
+
+	is.Equal("matcher failed", err.Error())
+	is.True(calls.Load() <= 3) // the remaining files are not counted
+}
+
+func TestMatchFilesCommandWithoutCount(t *testing.T) {
+	is := IS.New(t)
+	counter := matcherCommandCounter([]string{"echo", "not a number"})
+
+	_, err := matchFiles(context.Background(), "./testdata", grepOptions{ExcludeDirs: []string{"encoding"}}, counter, 1)
+
+	is.True(err != nil)
+}
+
+func TestMatchFilesBoundsConcurrency(t *testing.T) {
+	is := IS.New(t)
+	dir := newTestRepo(t, map[string]string{"a.go": "", "b.go": "", "c.go": "", "d.go": "", "e.go": ""})
+	var (
+		mu            sync.Mutex
+		running, peak int
+	)
+	counter := func(ctx context.Context, fileName string) (int, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return 1, nil
+	}
+
+	result, err := matchFiles(context.Background(), dir, grepOptions{}, counter, 2)
+
+	is.NoErr(err)
+	is.Equal(5, len(result))
+	is.True(peak <= 2)
+}
+
+func TestMatchFilesUsesFixedWorkers(t *testing.T) {
+	is := IS.New(t)
+	files := map[string]string{}
+	for i := 0; i < 200; i++ {
+		files[fmt.Sprintf("%03d.go", i)] = ""
+	}
+	dir := newTestRepo(t, files)
+	before := runtime.NumGoroutine()
+	var (
+		mu   sync.Mutex
+		peak int
+	)
+	counter := func(ctx context.Context, fileName string) (int, error) {
+		mu.Lock()
+		if n := runtime.NumGoroutine(); n > peak {
+			peak = n
+		}
+		mu.Unlock()
+		return 1, nil
+	}
+
+	result, err := matchFiles(context.Background(), dir, grepOptions{}, counter, 2)
+
+	is.NoErr(err)
+	is.Equal(200, len(result))
+	is.True(peak-before <= 3) // the 2 workers and the goroutine feeding them, not one goroutine per file
+}
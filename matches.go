@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"unicode/utf8"
+)
+
+// Match is the location of one match of a search word in a file
+type Match struct {
+	// Line is the 1-based line number of the match
+	Line int `json:"line"`
+	// Column is the 1-based column of the match, counted in runes
+	Column int `json:"column"`
+	// Text is the line the match is on
+	Text string `json:"text"`
+	// Word is the search word which matched
+	Word string `json:"word,omitempty"`
+	// Before and After are the lines around the match when context_lines is set
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// addMatches sets the matches of the results found by grep. grep only reports the matched text,
+// so the files are searched again with the search words compiled as one regexp to locate the matches.
+// contextLines is the number of lines saved before and after every match.
+func addMatches(basePath string, grs []GrepResult, groups []searchWordGroup, contextLines int) error {
+	re, err := compileSearchWordGroups(groups)
+	if err != nil {
+		return err
+	}
+	searchWord, err := searchWordOf(groups)
+	if err != nil {
+		return err
+	}
+	for i := range grs {
+		content, err := readMatchedFile(basePath, grs[i].FileName)
+		if err != nil {
+			return err
+		}
+		grs[i].Matches = locateMatches(content, re, contextLines)
+		for j := range grs[i].Matches {
+			grs[i].Matches[j].Word = searchWord(grs[i].Matches[j].Word)
+		}
+	}
+	return nil
+}
+
+// searchWordOf returns a func which attributes a matched text to the search word of the groups which matched it,
+// the same way as attributeWords. A text which can not be attributed is returned as is.
+func searchWordOf(groups []searchWordGroup) (func(text string) string, error) {
+	res := make([]*regexp.Regexp, len(groups))
+	patterns := make([][]*regexp.Regexp, len(groups))
+	for i, group := range groups {
+		re, err := compileSearchWordGroups([]searchWordGroup{group})
+		if err != nil {
+			return nil, err
+		}
+		res[i] = re
+		patterns[i] = wordPatterns(group.Words, group.CaseSensitive)
+	}
+	return func(text string) string {
+		for i, group := range groups {
+			if res[i].FindString(text) == text {
+				return matchingWord(text, group.Words, patterns[i], group.CaseSensitive)
+			}
+		}
+		return text
+	}, nil
+}
+
+// locateMatches returns the location of every match of re in the content, line by line like grep,
+// with up to contextLines lines before and after each match. The Word of the matches is the matched text.
+func locateMatches(content []byte, re *regexp.Regexp, contextLines int) []Match {
+	lines := bytes.Split(bytes.TrimSuffix(content, []byte{'\n'}), []byte{'\n'})
+	for i := range lines {
+		lines[i] = bytes.TrimSuffix(lines[i], []byte{'\r'})
+	}
+	var result []Match
+	for i, line := range lines {
+		for _, loc := range re.FindAllIndex(line, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			match := Match{
+				Line:   i + 1,
+				Column: utf8.RuneCount(line[:loc[0]]) + 1,
+				Text:   string(line),
+				Word:   string(line[loc[0]:loc[1]]),
+			}
+			if contextLines > 0 {
+				match.Before = contextText(lines, i-contextLines, i)
+				match.After = contextText(lines, i+1, i+1+contextLines)
+			}
+			result = append(result, match)
+		}
+	}
+	return result
+}
+
+// contextText returns the lines from start up to end, clamped to the lines there are
+func contextText(lines [][]byte, start, end int) []string {
+	if start < 0 {
+		start = 0
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	var result []string
+	for _, line := range lines[start:end] {
+		result = append(result, string(line))
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestLocateMatchesCountsColumnsInRunes(t *testing.T) {
+	is := IS.New(t)
+	re := regexp.MustCompile("(?i)fell")
+
+	matches := locateMatches([]byte("fell\r\nÆrø fell på føll fell\n\n日本 FELL"), re, 0)
+
+	is.Equal([]Match{
+		{Line: 1, Column: 1, Text: "fell", Word: "fell"},
+		{Line: 2, Column: 5, Text: "Ærø fell på føll fell", Word: "fell"},
+		{Line: 2, Column: 18, Text: "Ærø fell på føll fell", Word: "fell"},
+		{Line: 4, Column: 4, Text: "日本 FELL", Word: "FELL"},
+	}, matches)
+}
+
+func TestAnalyzePathIncludeMatches(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, "nordic.txt"), []byte("første linje\nblåbær fell\n"), 0644))
+	cfg := Config{SearchWords: []string{"fell"}, IncludeMatches: true}
+
+	app, err := analyzePath(context.Background(), Repository{Name: "nordic"}, cfg, dir)
+
+	is.NoErr(err)
+	is.Equal(1, len(app.GrepResults))
+	is.Equal([]Match{{Line: 2, Column: 8, Text: "blåbær fell", Word: "fell"}}, app.GrepResults[0].Matches)
+}
+
+func TestSearchWordOf(t *testing.T) {
+	is := IS.New(t)
+	groups := []searchWordGroup{{Words: []string{"fel+"}}, {Words: []string{"Needle"}, CaseSensitive: true}}
+
+	searchWord, err := searchWordOf(groups)
+
+	is.NoErr(err)
+	is.Equal("fel+", searchWord("FELL"))
+	is.Equal("Needle", searchWord("Needle"))
+	is.Equal("needle", searchWord("needle"))
+}
+
+func TestLocateMatchesContextLines(t *testing.T) {
+	is := IS.New(t)
+	re := regexp.MustCompile("(?i)fell")
+
+	matches := locateMatches([]byte("fell\none\ntwo\nthree fell\nfour\n"), re, 2)
+
+	is.Equal([]Match{
+		{Line: 1, Column: 1, Text: "fell", Word: "fell", After: []string{"one", "two"}},
+		{Line: 4, Column: 7, Text: "three fell", Word: "fell", Before: []string{"one", "two"}, After: []string{"four"}},
+	}, matches)
+}
+
+func TestAnalyzePathContextLines(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, "nordic.txt"), []byte("første linje\nblåbær fell\n"), 0644))
+	cfg := Config{SearchWords: []string{"fell"}, ContextLines: 1}
+
+	app, err := analyzePath(context.Background(), Repository{Name: "nordic"}, cfg, dir)
+
+	is.NoErr(err)
+	is.Equal([]Match{{Line: 2, Column: 8, Text: "blåbær fell", Word: "fell", Before: []string{"første linje"}}}, app.GrepResults[0].Matches)
+	is.True(validateConfig(Config{SearchWords: []string{"fell"}, ContextLines: -1}) != nil)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// CommandMerge combines result files, e.g. of scans sharded across several CI jobs
+	CommandMerge = "merge"
+
+	OnDuplicateError = "error"
+	OnDuplicateFirst = "first"
+	OnDuplicateLast  = "last"
+)
+
+// runMerge merges the result files given as arguments and saves the merged result to -output
+func runMerge(args []string, output io.Writer) int {
+	flags := flag.NewFlagSet(CommandMerge, flag.ContinueOnError)
+	flags.SetOutput(output)
+	resultPath := flags.String("output", ResultFilePath, "file the merged result is saved to")
+	onDuplicate := flags.String("on-duplicate", OnDuplicateError, "what to do with applications with the same name in several result files: "+
+		strings.Join([]string{OnDuplicateError, OnDuplicateFirst, OnDuplicateLast}, ", "))
+	flags.Usage = func() {
+		fmt.Fprintf(output, "usage: %s [-output <path>] [-on-duplicate error|first|last] <result>...\n", CommandMerge)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitCodeSuccess
+		}
+		return ExitCodeConfigError
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return ExitCodeConfigError
+	}
+	var results []ResultFile
+	for _, fileName := range flags.Args() {
+		result, err := readResultFile(fileName)
+		if err != nil {
+			fmt.Fprintln(output, err)
+			return ExitCodeConfigError
+		}
+		results = append(results, result)
+	}
+
+	merged, err := mergeResultFiles(results, flags.Args(), *onDuplicate)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		return ExitCodeConfigError
+	}
+	if err := writeResult(*resultPath, merged, DefaultOutputFileMode); err != nil {
+		fmt.Fprintln(output, "unable to save result: ", err)
+		return ExitCodeFailure
+	}
+	return ExitCodeSuccess
+}
+
+// mergeResultFiles combines the applications of the results, named after their files, and calculates the totals again.
+// Applications with the same name are an error, unless onDuplicate keeps the first or the last of them.
+func mergeResultFiles(results []ResultFile, fileNames []string, onDuplicate string) (ResultFile, error) {
+	switch onDuplicate {
+	case OnDuplicateError, OnDuplicateFirst, OnDuplicateLast:
+	default:
+		return ResultFile{}, fmt.Errorf("unknown on-duplicate '%s', must be %s, %s or %s", onDuplicate, OnDuplicateError, OnDuplicateFirst, OnDuplicateLast)
+	}
+
+	var merged ResultFile
+	index := make(map[string]int)
+	// origin is the index of the result the application is kept from
+	origin := make(map[string]int)
+	// dropped are the names of the duplicate applications which are left out, per result
+	dropped := make([]map[string]bool, len(results))
+	var coverage, techDebt bool
+	for i, result := range results {
+		dropped[i] = make(map[string]bool)
+		for _, word := range result.SearchWords {
+			if !containsString(merged.SearchWords, word) {
+				merged.SearchWords = append(merged.SearchWords, word)
+			}
+		}
+		coverage = coverage || result.WordCoverage != nil
+		techDebt = techDebt || result.TechDebt != nil
+		for _, app := range result.Applications {
+			j, ok := index[app.Name]
+			switch {
+			case !ok:
+				index[app.Name] = len(merged.Applications)
+				origin[app.Name] = i
+				merged.Applications = append(merged.Applications, app)
+			case onDuplicate == OnDuplicateError:
+				return ResultFile{}, fmt.Errorf("application '%s' is in both '%s' and '%s'", app.Name, fileNames[origin[app.Name]], fileNames[i])
+			case onDuplicate == OnDuplicateLast:
+				dropped[origin[app.Name]][app.Name] = true
+				origin[app.Name] = i
+				merged.Applications[j] = app
+			default:
+				dropped[i][app.Name] = true
+			}
+		}
+	}
+	// the errors of the applications which are left out are left out as well
+	for i, result := range results {
+		for _, err := range result.Errors {
+			if !droppedError(err, dropped[i]) {
+				merged.Errors = append(merged.Errors, err)
+			}
+		}
+	}
+
+	merged = calculateTotals(merged)
+	// a result written with summary_only has no files to count the extension totals from, its totals are added
+	for _, result := range results {
+		if !hasGrepResults(result) {
+			for extension, count := range result.ExtensionTotals {
+				merged.ExtensionTotals[extension] += count
+			}
+		}
+	}
+	merged.Metadata = mergeMetadata(results)
+	merged.TotalApplications = len(merged.Applications)
+	scoreMode := ScoreModeCount
+	for _, app := range merged.Applications {
+		if app.Status == StatusFailed {
+			merged.FailedApplications++
+		}
+		if app.LinesScanned > 0 {
+			scoreMode = ScoreModeDensity
+		}
+	}
+	if techDebt {
+		merged.TechDebt = calculateTechDebt(merged)
+	}
+	if coverage {
+		merged.WordCoverage = calculateWordCoverage(merged)
+	}
+	return sortApplications(merged, scoreMode), nil
+}
+
+// hasGrepResults reports whether any application of the result has its results per file
+func hasGrepResults(result ResultFile) bool {
+	for _, app := range result.Applications {
+		if len(app.GrepResults) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// droppedError reports whether the error of a result is about one of the dropped applications, the errors of failed
+// repositories name them like "failed on repo 'name': ..."
+func droppedError(err string, dropped map[string]bool) bool {
+	for name := range dropped {
+		if strings.Contains(err, "repo '"+name+"'") {
+			return true
+		}
+	}
+	return false
+}
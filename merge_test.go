@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	IS "github.com/matryer/is"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeResultFiles(t *testing.T) {
+	is := IS.New(t)
+	teamA := ResultFile{SearchWords: []string{"fell"}, Errors: []string{"failed on repo 'broken'"}, Applications: []Application{
+		{Name: "api", Status: StatusOK, CountSum: 2, WordCounts: map[string]int{"fell": 2}, GrepResults: []GrepResult{{FileName: "main.go", Count: 2}}},
+		{Name: "broken", Status: StatusFailed},
+	}}
+	teamB := ResultFile{SearchWords: []string{"fell", "legacy"}, Applications: []Application{
+		{Name: "web", Status: StatusOK, CountSum: 5, WordCounts: map[string]int{"legacy": 5}, GrepResults: []GrepResult{{FileName: "index.js", Count: 5}}},
+		{Name: "api", Status: StatusOK, CountSum: 1, WordCounts: map[string]int{"fell": 1}, GrepResults: []GrepResult{{FileName: "main.go", Count: 1}}},
+	}}
+
+	_, err := mergeResultFiles([]ResultFile{teamA, teamB}, []string{"a.json", "b.json"}, OnDuplicateError)
+	is.True(err != nil)
+
+	merged, err := mergeResultFiles([]ResultFile{teamA, teamB}, []string{"a.json", "b.json"}, OnDuplicateLast)
+
+	is.NoErr(err)
+	is.Equal(3, merged.TotalApplications)
+	is.Equal(2, merged.SucceededApplications)
+	is.Equal(1, merged.FailedApplications)
+	is.Equal(6, merged.TotalCountSum)
+	is.Equal([]string{"fell", "legacy"}, merged.SearchWords)
+	is.Equal(map[string]int{"fell": 1, "legacy": 5}, merged.WordTotals)
+	is.Equal(map[string]int{".go": 1, ".js": 5}, merged.ExtensionTotals)
+	is.Equal([]string{"failed on repo 'broken'"}, merged.Errors)
+	is.Equal("web", merged.Applications[0].Name) // sorted on count sum
+
+	merged, err = mergeResultFiles([]ResultFile{teamA, teamB}, []string{"a.json", "b.json"}, OnDuplicateFirst)
+	is.NoErr(err)
+	is.Equal(7, merged.TotalCountSum)
+}
+
+func TestMergeResultFilesDropsErrorsOfDuplicates(t *testing.T) {
+	is := IS.New(t)
+	monday := ResultFile{Errors: []string{"failed on repo 'api': exit status 128", "failed on repo 'web': exit status 128"}, Applications: []Application{
+		{Name: "api", Status: StatusFailed, Error: "exit status 128"},
+		{Name: "web", Status: StatusFailed, Error: "exit status 128"},
+	}}
+	tuesday := ResultFile{Applications: []Application{
+		{Name: "api", Status: StatusOK, CountSum: 1},
+	}}
+
+	merged, err := mergeResultFiles([]ResultFile{monday, tuesday}, []string{"monday.json", "tuesday.json"}, OnDuplicateLast)
+	is.NoErr(err)
+	is.Equal(1, merged.FailedApplications)
+	is.Equal([]string{"failed on repo 'web': exit status 128"}, merged.Errors)
+
+	merged, err = mergeResultFiles([]ResultFile{tuesday, monday}, []string{"tuesday.json", "monday.json"}, OnDuplicateFirst)
+	is.NoErr(err)
+	is.Equal(1, merged.FailedApplications)
+	is.Equal([]string{"failed on repo 'web': exit status 128"}, merged.Errors)
+}
+
+func TestMergeSummaryOnlyResultFiles(t *testing.T) {
+	is := IS.New(t)
+	shardA := ResultFile{ExtensionTotals: map[string]int{".go": 3, ".md": 1}, Applications: []Application{
+		{Name: "api", Status: StatusOK, CountSum: 4, WordCounts: map[string]int{"fell": 4}},
+	}}
+	shardB := ResultFile{ExtensionTotals: map[string]int{".go": 2}, Applications: []Application{
+		{Name: "web", Status: StatusOK, CountSum: 2, WordCounts: map[string]int{"fell": 2}},
+	}}
+	shardC := ResultFile{Applications: []Application{
+		{Name: "cli", Status: StatusOK, CountSum: 1, GrepResults: []GrepResult{{FileName: "main.go", Count: 1}}},
+	}}
+
+	merged, err := mergeResultFiles([]ResultFile{shardA, shardB, shardC}, []string{"a.json", "b.json", "c.json"}, OnDuplicateError)
+
+	is.NoErr(err)
+	is.Equal(7, merged.TotalCountSum)
+	is.Equal(map[string]int{".go": 6, ".md": 1}, merged.ExtensionTotals)
+}
+
+func TestCliMerge(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	aPath := filepath.Join(dir, "a.json")
+	bPath := filepath.Join(dir, "b.json")
+	resultPath := filepath.Join(dir, "results.json")
+	is.NoErr(writeResult(aPath, ResultFile{Applications: []Application{{Name: "api", Status: StatusOK, CountSum: 1}}}, 0644))
+	is.NoErr(writeResult(bPath, ResultFile{Applications: []Application{{Name: "web", Status: StatusOK, CountSum: 2}}}, 0644))
+	var output bytes.Buffer
+
+	is.Equal(ExitCodeSuccess, cli([]string{"merge", "-output", resultPath, aPath, bPath}, nil, &output))
+
+	merged, err := readResultFile(resultPath)
+	is.NoErr(err)
+	is.Equal(3, merged.TotalCountSum)
+	is.Equal(ExitCodeConfigError, cli([]string{"merge", "-output", resultPath, aPath, aPath}, nil, &output))
+	is.Equal(ExitCodeConfigError, cli([]string{"merge", "-on-duplicate", "rename", aPath}, nil, &output))
+}
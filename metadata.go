@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"runtime/debug"
+	"time"
+)
+
+// Version is the version of count-fell, it is set when building a release with -ldflags "-X main.Version=v1.2.3"
+var Version = "dev"
+
+// RunMetadata describes the run which produced a result file, so a result file of the past can be audited
+type RunMetadata struct {
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	ToolVersion     string    `json:"tool_version"`
+	// ConfigHash is the SHA-256 of the content of the config file, the flags of the run are not part of it
+	ConfigHash string `json:"config_hash"`
+}
+
+// toolVersion returns Version, or the commit count-fell was built from when no version is set
+func toolVersion() string {
+	if Version != "dev" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return Version + "+" + setting.Value
+			}
+		}
+	}
+	return Version
+}
+
+// configHash hashes the content of the config file as it is, before the flags of the run change the config
+func configHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func newRunMetadata(startedAt, finishedAt time.Time, configContent []byte) *RunMetadata {
+	return &RunMetadata{
+		StartedAt:       startedAt,
+		FinishedAt:      finishedAt,
+		DurationSeconds: finishedAt.Sub(startedAt).Seconds(),
+		ToolVersion:     toolVersion(),
+		ConfigHash:      configHash(configContent),
+	}
+}
+
+// mergeMetadata combines the metadata of merged result files: the run started with the first of them and finished with
+// the last, the tool version and the config hash are kept when all of them have the same. It is nil when none of the
+// results has metadata.
+func mergeMetadata(results []ResultFile) *RunMetadata {
+	var merged *RunMetadata
+	for _, result := range results {
+		m := result.Metadata
+		if m == nil {
+			continue
+		}
+		if merged == nil {
+			copied := *m
+			merged = &copied
+			continue
+		}
+		if m.StartedAt.Before(merged.StartedAt) {
+			merged.StartedAt = m.StartedAt
+		}
+		if m.FinishedAt.After(merged.FinishedAt) {
+			merged.FinishedAt = m.FinishedAt
+		}
+		if m.ToolVersion != merged.ToolVersion {
+			merged.ToolVersion = ""
+		}
+		if m.ConfigHash != merged.ConfigHash {
+			merged.ConfigHash = ""
+		}
+	}
+	if merged != nil {
+		merged.DurationSeconds = merged.FinishedAt.Sub(merged.StartedAt).Seconds()
+	}
+	return merged
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewRunMetadata(t *testing.T) {
+	is := IS.New(t)
+	startedAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	metadata := newRunMetadata(startedAt, startedAt.Add(90*time.Second), []byte(`{"search_words": ["fell"]}`))
+
+	is.Equal(90.0, metadata.DurationSeconds)
+	is.True(metadata.ToolVersion != "")
+	is.Equal(64, len(metadata.ConfigHash))
+	is.Equal(metadata.ConfigHash, configHash([]byte(`{"search_words": ["fell"]}`)))
+	is.True(metadata.ConfigHash != configHash([]byte(`{"search_words": ["todo"]}`)))
+}
+
+func TestRunMetadata(t *testing.T) {
+	is := IS.New(t)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": ["fell"]}`), 0644))
+	resultPath := filepath.Join(t.TempDir(), "results.json")
+
+	is.Equal(ExitCodeSuccess, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON, Dir: "./testdata"}, nil))
+
+	rf, err := readResultFile(resultPath)
+	is.NoErr(err)
+	is.True(rf.Metadata != nil)
+	is.True(!rf.Metadata.FinishedAt.Before(rf.Metadata.StartedAt))
+	is.Equal(toolVersion(), rf.Metadata.ToolVersion)
+	is.Equal(configHash([]byte(`{"search_words": ["fell"]}`)), rf.Metadata.ConfigHash)
+
+	// the flags of the run do not change the hash of the config file
+	is.Equal(ExitCodeSuccess, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON, Dir: "./testdata", WithLines: true}, nil))
+	withLines, err := readResultFile(resultPath)
+	is.NoErr(err)
+	is.Equal(rf.Metadata.ConfigHash, withLines.Metadata.ConfigHash)
+}
+
+func TestMergeMetadata(t *testing.T) {
+	is := IS.New(t)
+	startedAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	shardA := ResultFile{Metadata: &RunMetadata{StartedAt: startedAt, FinishedAt: startedAt.Add(time.Minute), ToolVersion: "v1.2.3", ConfigHash: "a"}}
+	shardB := ResultFile{Metadata: &RunMetadata{StartedAt: startedAt.Add(time.Second), FinishedAt: startedAt.Add(2 * time.Minute), ToolVersion: "v1.2.3", ConfigHash: "b"}}
+
+	is.Equal(&RunMetadata{
+		StartedAt:       startedAt,
+		FinishedAt:      startedAt.Add(2 * time.Minute),
+		DurationSeconds: 120,
+		ToolVersion:     "v1.2.3",
+	}, mergeMetadata([]ResultFile{shardA, {}, shardB}))
+	is.Equal(startedAt.Add(time.Minute), shardA.Metadata.FinishedAt) // the metadata of the inputs is not changed
+	is.True(mergeMetadata([]ResultFile{{}, {}}) == nil)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Subdir is a dir of a monorepo which is searched as its own application
+type Subdir struct {
+	// Name is the name of the application, '<repository>/<path>' when empty
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+func (s Subdir) appName(r Repository) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return r.Name + "/" + path.Clean(s.Path)
+}
+
+// analyzeCheckout searches the repository checked out at dir. Every subdir of the repository is searched as its own
+// application in the Subdirs of the returned application, which has the files outside of the subdirs.
+func analyzeCheckout(ctx context.Context, r Repository, cfg Config, dir string) (Application, error) {
+	if len(r.Subdirs) == 0 {
+		return analyzePath(ctx, r, cfg, dir)
+	}
+	rest := r
+	for _, subdir := range r.Subdirs {
+		rest.subdirExcludes = append(rest.subdirExcludes, path.Clean(subdir.Path))
+	}
+	app, err := analyzePath(ctx, rest, cfg, dir)
+	if err != nil {
+		return app, err
+	}
+	for _, subdir := range r.Subdirs {
+		sub := r
+		sub.Name = subdir.appName(r)
+		sub.Subdirs = nil
+		subPath := filepath.Join(dir, filepath.FromSlash(subdir.Path))
+		if err := checkDirExists(subPath); err != nil {
+			return app, fmt.Errorf("unable to search subdir '%s': %w", subdir.Path, err)
+		}
+		subApp, err := analyzePath(ctx, sub, cfg, subPath)
+		if err != nil {
+			return app, fmt.Errorf("unable to search subdir '%s': %w", subdir.Path, err)
+		}
+		app.Subdirs = append(app.Subdirs, subApp)
+	}
+	return app, nil
+}
+
+// validateSubdirs checks that the subdirs of the repository are relative paths inside of it
+func validateSubdirs(r Repository) error {
+	for _, subdir := range r.Subdirs {
+		clean := path.Clean(subdir.Path)
+		if subdir.Path == "" || clean == "." || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("subdir '%s' of repository '%s' is not a dir inside the repository", subdir.Path, r.Name)
+		}
+	}
+	if len(r.Subdirs) > 0 && r.ChangedSince != "" {
+		// git diff lists the changed files relative to the root of the repository, not to the subdir
+		return fmt.Errorf("repository '%s' has subdirs, which can not be combined with changed_since", r.Name)
+	}
+	return nil
+}
+
+// withSubdirs returns the application followed by the applications of its subdirs
+func withSubdirs(app Application) []Application {
+	subdirs := app.Subdirs
+	app.Subdirs = nil
+	return append([]Application{app}, subdirs...)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	IS "github.com/matryer/is"
+	"log/slog"
+	"testing"
+)
+
+func TestAnalyzeCheckoutSubdirs(t *testing.T) {
+	is := IS.New(t)
+	dir := newTestRepo(t, map[string]string{
+		"README.md":                    "fell",
+		"services/payments/main.go":    "fell fell",
+		"services/payments/api/api.go": "fell",
+		"services/auth/main.go":        "fell fell fell",
+	})
+	r := Repository{Name: "monorepo", Subdirs: []Subdir{{Path: "services/payments"}, {Name: "auth", Path: "services/auth/"}}}
+
+	app, err := analyzeCheckout(context.Background(), r, Config{SearchWords: []string{"fell"}}, dir)
+
+	is.NoErr(err)
+	is.Equal("monorepo", app.Name)
+	is.Equal(1, app.CountSum) // the files outside of the subdirs
+	is.Equal(2, len(app.Subdirs))
+	is.Equal("monorepo/services/payments", app.Subdirs[0].Name)
+	is.Equal(3, app.Subdirs[0].CountSum)
+	is.Equal("auth", app.Subdirs[1].Name)
+	is.Equal(3, app.Subdirs[1].CountSum)
+}
+
+func TestAnalyzeCheckoutMissingSubdir(t *testing.T) {
+	is := IS.New(t)
+	dir := newTestRepo(t, map[string]string{"README.md": "fell"})
+	r := Repository{Name: "monorepo", Subdirs: []Subdir{{Path: "services/payments"}}}
+
+	_, err := analyzeCheckout(context.Background(), r, Config{SearchWords: []string{"fell"}}, dir)
+
+	is.True(errors.Is(err, ErrDirMissing)) // the subdir is missing, not the clone
+	is.True(!errors.Is(err, ErrClonePathMissing))
+}
+
+func TestAnalyzeCheckoutSubdirsWithoutWarning(t *testing.T) {
+	is := IS.New(t)
+	dir := newTestRepo(t, map[string]string{"README.md": "fell", "services/payments/main.go": "fell fell"})
+	r := Repository{Name: "monorepo", Subdirs: []Subdir{{Path: "services/payments"}}}
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	app, err := analyzeCheckout(context.Background(), r, Config{SearchWords: []string{"fell"}, SearchBackend: SearchBackendGrep}, dir)
+
+	is.NoErr(err)
+	is.Equal(1, app.CountSum)
+	is.Equal("", logs.String()) // the subdirs are not nested exclude dirs of the config
+	is.Equal(0, len(r.ExcludeDirs))
+}
+
+func TestValidateSubdirs(t *testing.T) {
+	is := IS.New(t)
+
+	is.NoErr(validateSubdirs(Repository{Name: "monorepo", Subdirs: []Subdir{{Path: "services/payments"}}}))
+	is.True(validateSubdirs(Repository{Name: "monorepo", Subdirs: []Subdir{{Path: ""}}}) != nil)
+	is.True(validateSubdirs(Repository{Name: "monorepo", Subdirs: []Subdir{{Path: "."}}}) != nil)
+	is.True(validateSubdirs(Repository{Name: "monorepo", Subdirs: []Subdir{{Path: "../other"}}}) != nil)
+	is.True(validateSubdirs(Repository{Name: "monorepo", Subdirs: []Subdir{{Path: "/srv/other"}}}) != nil)
+	is.True(validateSubdirs(Repository{Name: "monorepo", ChangedSince: "origin/main", Subdirs: []Subdir{{Path: "services"}}}) != nil)
+}
+
+func TestScanSubdirs(t *testing.T) {
+	is := IS.New(t)
+	cfg := Config{Repositories: []Repository{{Name: "monorepo"}, {Name: "other"}}}
+	analyze := func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		app := Application{Name: r.Name, Status: StatusOK}
+		if r.Name == "monorepo" {
+			app.Subdirs = []Application{{Name: "payments", Status: StatusOK}, {Name: "auth", Status: StatusOK}}
+		}
+		return app, nil
+	}
+
+	apps, errs := scan(context.Background(), cfg, analyze)
+
+	is.Equal(0, len(errs))
+	var names []string
+	for _, app := range apps {
+		names = append(names, app.Name)
+		is.Equal(0, len(app.Subdirs))
+	}
+	is.Equal([]string{"monorepo", "payments", "auth", "other"}, names)
+}
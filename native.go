@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+const (
+	SearchBackendGrep   = "grep"
+	SearchBackendNative = "native"
+)
+
+// searchNative searches the files grep would search in path for the search words without grep, using Go regular expressions.
+// Like grep the files are matched line by line and binary files are skipped unless SearchBinary, with multiline the whole file is matched at once
+// and '.' matches line breaks as well. At most concurrency files are searched at a time.
+func searchNative(ctx context.Context, path string, searchWords []string, opts grepOptions, concurrency int) ([]GrepResult, error) {
+	re, err := compileNativeSearchWords(quoteSearchWords(searchWords, opts.FixedStrings), opts.CaseSensitive, opts.WordBoundary, opts.Multiline)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search words: %w", err)
+	}
+	files, err := searchedFiles(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*GrepResult, len(files))
+	err = eachConcurrent(ctx, len(files), concurrency, func(ctx context.Context, i int) error {
+		content, err := os.ReadFile(filepath.Join(path, files[i]))
+		if err != nil {
+			return err
+		}
+		if !opts.SearchBinary && isBinary(content) {
+			return nil
+		}
+		var gr GrepResult
+		var ok bool
+		if opts.Multiline {
+			gr, ok = matchContent(files[i], content, re)
+		} else {
+			gr, ok = matchLines(files[i], content, re)
+		}
+		if ok {
+			matched[i] = &gr
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := []GrepResult{}
+	for _, gr := range matched {
+		if gr != nil {
+			results = append(results, *gr)
+		}
+	}
+	return attributeWords(results, searchWords, opts.CaseSensitive), nil
+}
+
+// compileNativeSearchWords compiles the search words for searchNative, '^' and '$' match at the start and end of every line
+func compileNativeSearchWords(searchWords []string, caseSensitive, wordBoundary, multiline bool) (*regexp.Regexp, error) {
+	flags := "(?m)"
+	if multiline {
+		flags = "(?ms)"
+	}
+	re, err := compileSearchWords(searchWords, caseSensitive, wordBoundary)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(flags + re.String())
+}
+
+// matchLines matches the content line by line the same way grep does, so a match never spans more than one line
+func matchLines(name string, content []byte, re *regexp.Regexp) (GrepResult, bool) {
+	gr := GrepResult{FileName: name, Words: make(map[string]int)}
+	for _, line := range bytes.Split(content, []byte{'\n'}) {
+		for _, match := range re.FindAll(line, -1) {
+			if len(match) == 0 {
+				continue
+			}
+			gr.Count++
+			gr.Words[string(match)]++
+		}
+	}
+	return gr, gr.Count > 0
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearchNativeMatchesGrep(t *testing.T) {
+	is := IS.New(t)
+	opts := grepOptions{ExcludeDirs: []string{"encoding"}}
+
+	grepped, err := grep(context.Background(), "./testdata", []string{"fell", "needle"}, opts)
+	is.NoErr(err)
+	native, err := searchNative(context.Background(), "./testdata", []string{"fell", "needle"}, opts, 1)
+	is.NoErr(err)
+
+	sortOnFileName(grepped)
+	sortOnFileName(native)
+	is.Equal(grepped, native)
+}
+
+func TestSearchNative(t *testing.T) {
+	is := IS.New(t)
+	dir := newTestRepo(t, map[string]string{
+		"main.go":                   "TODO: fix\ntodo later\n// TODO",
+		"vendor/lib.go":             "TODO",
+		"pkg/generated/api.go":      "TODO",
+		"image.png":                 "\x00TODO",
+		"docs/readme.md":            "nothing to do",
+		"docs/multi/line/notes.txt": "TODO\n",
+	})
+	opts := grepOptions{ExcludeDirs: []string{"vendor", "pkg/generated"}, IncludeGlobs: []string{"*.go", "*.txt"}}
+
+	result, err := searchNative(context.Background(), dir, []string{"todo"}, opts, 1)
+	is.NoErr(err)
+	sortOnFileName(result)
+	is.Equal([]GrepResult{
+		{FileName: "docs/multi/line/notes.txt", Count: 1, Words: map[string]int{"todo": 1}},
+		{FileName: "main.go", Count: 3, Words: map[string]int{"todo": 3}},
+	}, result)
+
+	opts.CaseSensitive = true
+	result, err = searchNative(context.Background(), dir, []string{"TODO"}, opts, 1)
+	is.NoErr(err)
+	is.Equal(3, sumTotalCountForGrepResults(result))
+}
+
+func TestSearchNativeLines(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, "errors.go"), []byte("func a() error {\n\treturn nil\n}\nfunc b() {\n}\n"), 0644))
+
+	result, err := searchNative(context.Background(), dir, []string{"^func", "func.*error"}, grepOptions{}, 1)
+	is.NoErr(err)
+	is.Equal(2, sumTotalCountForGrepResults(result)) // 'func.*error' does not match across the lines of b
+
+	result, err = searchNative(context.Background(), dir, []string{"func b.*}"}, grepOptions{}, 1)
+	is.NoErr(err)
+	is.Equal(0, len(result))
+
+	result, err = searchNative(context.Background(), dir, []string{"func b.*}"}, grepOptions{Multiline: true}, 1)
+	is.NoErr(err)
+	is.Equal(1, sumTotalCountForGrepResults(result))
+}
+
+func TestValidateConfigSearchBackend(t *testing.T) {
+	is := IS.New(t)
+
+	is.NoErr(validateConfig(Config{SearchWords: []string{"func.*error"}, SearchBackend: SearchBackendNative, Multiline: true}))
+	is.True(validateConfig(Config{SearchWords: []string{"fell"}, SearchBackend: "ag"}) != nil)
+	is.NoErr(checkBinaries(Config{SearchBackend: SearchBackendNative, GrepBinary: "grep-binary-which-does-not-exist"}))
+}
+
+func TestAnalyzePathSkipBinary(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "logo.png"), []byte("oldAPI\x00\x01oldAPI\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("oldAPI\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	no := false
+	for _, backend := range []string{SearchBackendGrep, SearchBackendNative} {
+		t.Run(backend, func(t *testing.T) {
+			is := IS.New(t)
+			cfg := Config{SearchWords: []string{"oldAPI"}, SearchBackend: backend}
+
+			app, err := analyzePath(context.Background(), Repository{Name: "repo"}, cfg, dir)
+
+			is.NoErr(err)
+			is.Equal(1, app.CountSum)
+
+			cfg.SkipBinary = &no
+			app, err = analyzePath(context.Background(), Repository{Name: "repo"}, cfg, dir)
+
+			is.NoErr(err)
+			is.Equal(3, app.CountSum)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// ndjsonWriter writes each application as one line of JSON as soon as it is analyzed, followed by a summary line
+type ndjsonWriter struct {
+	mu              sync.Mutex
+	file            *os.File
+	encoder         *json.Encoder
+	extensionTotals map[string]int
+}
+
+// Summary is the last line of a result file in the ndjson format
+type Summary struct {
+	Metadata              *RunMetadata   `json:"metadata,omitempty"`
+	TotalApplications     int            `json:"total_applications"`
+	SucceededApplications int            `json:"succeeded_applications"`
+	FailedApplications    int            `json:"failed_applications"`
+	EmptyApplications     int            `json:"empty_applications"`
+	SearchWords           []string       `json:"search_words"`
+	TotalCountSum         int            `json:"total_count_sum"`
+	ExtensionTotals       map[string]int `json:"extension_totals"`
+	WordTotals            map[string]int `json:"word_totals,omitempty"`
+	GroupTotals           map[string]int `json:"group_totals,omitempty"`
+	SeverityTotals        map[string]int `json:"severity_totals,omitempty"`
+	TechDebt              []TechDebt     `json:"tech_debt,omitempty"`
+	NewMatches            []NewMatch     `json:"new_matches,omitempty"`
+	Errors                []string       `json:"errors,omitempty"`
+}
+
+func createNDJSON(fileName string, perm os.FileMode) (*ndjsonWriter, error) {
+	file, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &ndjsonWriter{
+		file:            file,
+		encoder:         json.NewEncoder(file),
+		extensionTotals: make(map[string]int),
+	}, nil
+}
+
+// streaming wraps analyze so each analyzed application is written right away.
+// The per file results are not kept in the returned application as they are already written.
+func (w *ndjsonWriter) streaming(analyze analyzeFunc) analyzeFunc {
+	return func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		app, err := analyze(ctx, r, cfg)
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if err != nil {
+			// the error is returned to scan, which does not stream, so the failed application is written here
+			if encodeErr := w.encoder.Encode(failedApplication(r, err)); encodeErr != nil {
+				slog.Error("unable to stream failed application", "repository", r.Name, "error", encodeErr)
+			}
+			return app, err
+		}
+		for i, part := range withSubdirs(app) {
+			for _, gr := range part.GrepResults {
+				w.extensionTotals[fileExtension(gr.FileName)] += gr.Count
+			}
+			if cfg.SummaryOnly {
+				part.GrepResults = nil
+			}
+			if err := w.encoder.Encode(part); err != nil {
+				return app, err
+			}
+			if i > 0 {
+				app.Subdirs[i-1].GrepResults = nil
+			}
+		}
+		app.GrepResults = nil
+		return app, nil
+	}
+}
+
+// writeSummary writes the totals of the results as the last line
+func (w *ndjsonWriter) writeSummary(rf ResultFile) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.encoder.Encode(Summary{
+		Metadata:              rf.Metadata,
+		TotalApplications:     rf.TotalApplications,
+		SucceededApplications: rf.SucceededApplications,
+		FailedApplications:    rf.FailedApplications,
+		EmptyApplications:     rf.EmptyApplications,
+		SearchWords:           rf.SearchWords,
+		TotalCountSum:         rf.TotalCountSum,
+		ExtensionTotals:       w.extensionTotals,
+		WordTotals:            rf.WordTotals,
+		GroupTotals:           rf.GroupTotals,
+		SeverityTotals:        rf.SeverityTotals,
+		TechDebt:              rf.TechDebt,
+		NewMatches:            rf.NewMatches,
+		Errors:                rf.Errors,
+	})
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.file.Close()
+}
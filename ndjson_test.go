@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunNDJSON(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	resultPath := filepath.Join(dir, "results.ndjson")
+	var repos []string
+	for i := 0; i < 10; i++ {
+		repos = append(repos, fmt.Sprintf(`{"name": "repo-%d", "url": "repo-%d.git"}`, i, i))
+	}
+	config := `{"search_words": ["fell"], "repositories": [` + strings.Join(repos, ",") + `]}`
+	is.NoErr(os.WriteFile(configPath, []byte(config), 0644))
+	analyze := func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		grs := []GrepResult{{FileName: "main.go", Count: 2}, {FileName: "README", Count: 1}}
+		return Application{Name: r.Name, CountSum: 3, GrepResults: grs}, nil
+	}
+
+	is.Equal(ExitCodeSuccess, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatNDJSON}, analyze))
+
+	file, err := os.Open(resultPath)
+	is.NoErr(err)
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	is.NoErr(scanner.Err())
+	is.Equal(11, len(lines)) // one line per application and the summary
+
+	seen := make(map[string]int)
+	for _, line := range lines[:10] {
+		var app Application
+		is.NoErr(json.Unmarshal([]byte(line), &app))
+		is.Equal(2, len(app.GrepResults))
+		seen[app.Name]++
+	}
+	for i := 0; i < 10; i++ {
+		is.Equal(1, seen[fmt.Sprintf("repo-%d", i)]) // every application should appear exactly once
+	}
+
+	var summary Summary
+	is.NoErr(json.Unmarshal([]byte(lines[10]), &summary))
+	is.Equal(10, summary.TotalApplications)
+	is.Equal(30, summary.TotalCountSum)
+	is.Equal(map[string]int{".go": 20, NoExtension: 10}, summary.ExtensionTotals)
+}
+
+func TestRunJSONL(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	resultPath := filepath.Join(dir, "results.jsonl")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": ["fell"], "repositories": [{"name": "a", "url": "a.git"}]}`), 0644))
+	analyze := func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		return Application{Name: r.Name, CountSum: 1, GrepResults: []GrepResult{{FileName: "main.go", Count: 1}}}, nil
+	}
+
+	is.Equal(ExitCodeSuccess, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSONL}, analyze))
+
+	content, err := os.ReadFile(resultPath)
+	is.NoErr(err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	is.Equal(2, len(lines)) // the application and the summary
+	is.True(validateFormat(FormatJSONL, Config{AppendMode: true}) != nil)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// resultOutput is a file the result is saved to in one of the formats
+type resultOutput struct {
+	Path   string
+	Format string
+}
+
+// outputFormats are the formats of the result file by the extension of the file
+var outputFormats = map[string]string{
+	".json":   FormatJSON,
+	".ndjson": FormatNDJSON,
+	".jsonl":  FormatJSONL,
+	".sarif":  FormatSARIF,
+	".xml":    FormatJUnit,
+	".csv":    FormatCSV,
+	".html":   FormatHTML,
+	".md":     FormatMarkdown,
+	".xlsx":   FormatXLSX,
+}
+
+// outputs returns the files the result is saved to. A single -output is saved in -format, with more of them the
+// format of each is given by its extension, or by -format when the extension is not known.
+func (opts options) outputs() []resultOutput {
+	if len(opts.ExtraOutputs) == 0 {
+		return []resultOutput{{Path: opts.ResultPath, Format: opts.Format}}
+	}
+	var outputs []resultOutput
+	for _, path := range append([]string{opts.ResultPath}, opts.ExtraOutputs...) {
+		format, ok := outputFormats[strings.ToLower(filepath.Ext(path))]
+		if !ok {
+			format = opts.Format
+		}
+		outputs = append(outputs, resultOutput{Path: path, Format: format})
+	}
+	return outputs
+}
+
+// validateOutputs checks the format of every output, the ndjson format streams the applications without keeping their
+// files so it can only be the single output
+func validateOutputs(outputs []resultOutput, cfg Config, templatePath string) error {
+	for _, out := range outputs {
+		if err := validateFormat(out.Format, cfg); err != nil {
+			return err
+		}
+		if out.Format == FormatTemplate {
+			if _, err := loadTemplate(templatePath); err != nil {
+				return err
+			}
+		}
+		if len(outputs) > 1 && streamed(out.Format) {
+			return errors.New("the " + out.Format + " format of '" + out.Path + "' can not be combined with other outputs")
+		}
+	}
+	return nil
+}
+
+// streamed reports whether the applications are written as soon as they are searched in the format
+func streamed(format string) bool {
+	return formats[format].Streamed
+}
+
+// saveResult saves the result to the output in its format, which is not streamed
+func saveResult(out resultOutput, results ResultFile, cfg Config, opts options) error {
+	switch out.Format {
+	case FormatSARIF:
+		return writeSARIF(out.Path, results, cfg.WordOptions, cfg.outputFileMode())
+	case FormatJUnit:
+		return writeJUnit(out.Path, results, opts, cfg.outputFileMode())
+	case FormatCSV:
+		return writeCSV(out.Path, results, cfg.outputFileMode())
+	case FormatHTML:
+		return writeHTML(out.Path, results, cfg.outputFileMode())
+	case FormatMarkdown:
+		return writeMarkdown(out.Path, results, opts.Top, cfg.outputFileMode())
+	case FormatXLSX:
+		return writeXLSX(out.Path, results, cfg.outputFileMode())
+	case FormatTemplate:
+		return writeTemplate(out.Path, results, opts.TemplatePath, cfg.outputFileMode())
+	}
+	if cfg.AppendMode {
+		return appendResult(out.Path, sortApplications(results, cfg.ScoreMode), time.Now(), cfg.outputFileMode())
+	}
+	return writeResult(out.Path, sortApplications(results, cfg.ScoreMode), cfg.outputFileMode())
+}
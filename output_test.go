@@ -0,0 +1,69 @@
+package main
+
+import (
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputs(t *testing.T) {
+	is := IS.New(t)
+
+	is.Equal([]resultOutput{{Path: "results.txt", Format: FormatCSV}}, options{ResultPath: "results.txt", Format: FormatCSV}.outputs())
+	is.Equal([]resultOutput{
+		{Path: "results.json", Format: FormatJSON},
+		{Path: "report.HTML", Format: FormatHTML},
+		{Path: "summary.md", Format: FormatMarkdown},
+		{Path: "results.txt", Format: FormatCSV},
+	}, options{ResultPath: "results.json", ExtraOutputs: []string{"report.HTML", "summary.md", "results.txt"}, Format: FormatCSV}.outputs())
+}
+
+func TestValidateOutputs(t *testing.T) {
+	is := IS.New(t)
+
+	is.NoErr(validateOutputs([]resultOutput{{Path: "results.ndjson", Format: FormatNDJSON}}, Config{}, ""))
+	is.True(validateOutputs([]resultOutput{{Path: "results.ndjson", Format: FormatNDJSON}, {Path: "report.html", Format: FormatHTML}}, Config{}, "") != nil)
+	is.True(validateOutputs([]resultOutput{{Path: "results.json", Format: FormatJSON}, {Path: "results.csv", Format: FormatCSV}}, Config{AppendMode: true}, "") != nil)
+}
+
+func TestRunMultipleOutputs(t *testing.T) {
+	is := IS.New(t)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": ["fell"]}`), 0644))
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "results.json"), filepath.Join(dir, "report.html"), filepath.Join(dir, "summary.md")}
+
+	code := run(options{ConfigPath: configPath, ResultPath: paths[0], ExtraOutputs: paths[1:], Format: FormatJSON, Dir: "./testdata"}, nil)
+
+	is.Equal(ExitCodeSuccess, code)
+	rf, err := readResultFile(paths[0])
+	is.NoErr(err)
+	is.True(rf.TotalCountSum > 0)
+	for _, path := range paths[1:] {
+		_, err := os.Stat(path)
+		is.NoErr(err)
+	}
+}
+
+func TestSaveResultDoesNotReorderApplications(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	results := ResultFile{Applications: []Application{
+		{Name: "a", CountSum: 1, GrepResults: []GrepResult{{FileName: "a.go", Count: 1}}},
+		{Name: "b", CountSum: 5, GrepResults: []GrepResult{{FileName: "b.go", Count: 5}}},
+	}}
+	csvBefore := resultOutput{Path: filepath.Join(dir, "before.csv"), Format: FormatCSV}
+	csvAfter := resultOutput{Path: filepath.Join(dir, "after.csv"), Format: FormatCSV}
+
+	is.NoErr(saveResult(csvBefore, results, Config{}, options{}))
+	is.NoErr(saveResult(resultOutput{Path: filepath.Join(dir, "results.json"), Format: FormatJSON}, results, Config{}, options{}))
+	is.NoErr(saveResult(csvAfter, results, Config{}, options{}))
+
+	is.Equal("a", results.Applications[0].Name) // the json output sorts a copy
+	before, err := os.ReadFile(csvBefore.Path)
+	is.NoErr(err)
+	after, err := os.ReadFile(csvAfter.Path)
+	is.NoErr(err)
+	is.Equal(string(before), string(after)) // the order of the outputs does not matter
+}
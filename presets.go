@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	PresetSecrets  = "secrets"
+	PresetTechDebt = "tech_debt"
+)
+
+// presets are the curated search words which can be enabled with presets. Their regular expressions are written
+// without intervals, alternation or escapes so they mean the same in every regex_engine and search backend.
+var presets = map[string][]SearchWord{
+	PresetSecrets: {
+		presetWord(PresetSecrets, "A[KS]IA"+strings.Repeat("[0-9A-Z]", 16)),
+		presetWord(PresetSecrets, "gh[pousr]_"+strings.Repeat("[A-Za-z0-9]", 36)),
+		presetWord(PresetSecrets, "github_pat_"+strings.Repeat("[A-Za-z0-9_]", 82)),
+		presetWord(PresetSecrets, "-----BEGIN [A-Z ]*PRIVATE KEY-----"),
+		presetWord(PresetSecrets, "xox[abpors]-"+strings.Repeat("[0-9A-Za-z-]", 10)),
+		presetWord(PresetSecrets, "AIza"+strings.Repeat("[0-9A-Za-z_-]", 35)),
+	},
+	PresetTechDebt: {
+		presetMarker(PresetTechDebt, "TODO"),
+		presetMarker(PresetTechDebt, "FIXME"),
+		presetMarker(PresetTechDebt, "HACK"),
+		presetMarker(PresetTechDebt, "XXX"),
+	},
+}
+
+// presetWord returns a case-sensitive regular expression search word in the group of the preset
+func presetWord(preset, pattern string) SearchWord {
+	yes := true
+	return SearchWord{Pattern: pattern, CaseSensitive: &yes, Regex: &yes, Group: preset}
+}
+
+// presetMarker returns a case-sensitive fixed string search word which is matched as a whole word in the group of the
+// preset
+func presetMarker(preset, marker string) SearchWord {
+	yes, no := true, false
+	return SearchWord{Pattern: marker, CaseSensitive: &yes, WordBoundary: &yes, Regex: &no, Group: preset}
+}
+
+// applyPresets adds the search words of the presets of the config after its own search words. A search word which
+// is already in the config keeps its own options.
+func applyPresets(cfg *Config) error {
+	for _, name := range cfg.Presets {
+		words, ok := presets[name]
+		if !ok {
+			return fmt.Errorf("unknown preset '%s', must be %s or %s", name, PresetSecrets, PresetTechDebt)
+		}
+		for _, word := range words {
+			if containsString(cfg.SearchWords, word.Pattern) {
+				continue
+			}
+			cfg.SearchWords = append(cfg.SearchWords, word.Pattern)
+			if cfg.WordOptions == nil {
+				cfg.WordOptions = map[string]SearchWord{}
+			}
+			cfg.WordOptions[word.Pattern] = word
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PhaseCloning   = "cloning"
+	PhaseSearching = "searching"
+
+	// progressTTYInterval is how often the progress line is redrawn on a terminal
+	progressTTYInterval = 500 * time.Millisecond
+	// progressLogInterval is how often the progress is logged when not on a terminal
+	progressLogInterval = 30 * time.Second
+	// progressMaxNames is how many repositories are named per phase, the others are counted
+	progressMaxNames = 3
+)
+
+// progress reports how many repositories are done and what the others are doing while a run is in progress.
+// On a terminal it redraws a single line, otherwise it is logged periodically.
+type progress struct {
+	mu  sync.Mutex
+	out io.Writer
+	tty bool
+	// width of the terminal, the line is cut to it so it never wraps
+	width  int
+	total  int
+	done   int
+	start  time.Time
+	phases map[string]string
+	stop   chan struct{}
+	wg     sync.WaitGroup
+
+	// outMu guards the drawn line, which is cleared before a log record is written to the terminal and drawn again
+	// after it
+	outMu sync.Mutex
+	drawn string
+	// logger is the default logger before the progress line was drawn, which is restored when it is finished
+	logger *slog.Logger
+}
+
+// progressHandler clears the progress line before a record is logged and draws it again after it, so the record
+// is not written on the end of the line
+type progressHandler struct {
+	inner    slog.Handler
+	progress *progress
+}
+
+func (h progressHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h progressHandler) Handle(ctx context.Context, r slog.Record) error {
+	p := h.progress
+	p.outMu.Lock()
+	defer p.outMu.Unlock()
+	if p.drawn != "" {
+		fmt.Fprint(p.out, "\r\x1b[K")
+	}
+	err := h.inner.Handle(ctx, r)
+	fmt.Fprint(p.out, p.drawn)
+	return err
+}
+
+func (h progressHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return progressHandler{inner: h.inner.WithAttrs(attrs), progress: h.progress}
+}
+
+func (h progressHandler) WithGroup(name string) slog.Handler {
+	return progressHandler{inner: h.inner.WithGroup(name), progress: h.progress}
+}
+
+// repoProgress is the progress of one repository, which is passed in the context of its analysis
+type repoProgress struct {
+	progress *progress
+	name     string
+}
+
+type progressKey struct{}
+
+func newProgress(out io.Writer, tty bool, total int) *progress {
+	return &progress{out: out, tty: tty, width: terminalWidth(), total: total, start: time.Now(), phases: make(map[string]string), stop: make(chan struct{})}
+}
+
+// tracking wraps analyze so the phases of each repository are reported and it is counted as done when it returns
+func (p *progress) tracking(analyze analyzeFunc) analyzeFunc {
+	return func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		ctx = context.WithValue(ctx, progressKey{}, repoProgress{progress: p, name: r.Name})
+		app, err := analyze(ctx, r, cfg)
+		p.mu.Lock()
+		delete(p.phases, r.Name)
+		p.done++
+		p.mu.Unlock()
+		return app, err
+	}
+}
+
+// reportPhase reports the phase of the repository which is analyzed in the context, when its progress is tracked
+func reportPhase(ctx context.Context, phase string) {
+	rp, ok := ctx.Value(progressKey{}).(repoProgress)
+	if !ok {
+		return
+	}
+	rp.progress.mu.Lock()
+	defer rp.progress.mu.Unlock()
+	rp.progress.phases[rp.name] = phase
+}
+
+// eta estimates the time left from the time taken by the repositories which are done, it is 0 when none is done yet
+func eta(elapsed time.Duration, done, total int) time.Duration {
+	if done == 0 || done >= total {
+		return 0
+	}
+	return (elapsed / time.Duration(done) * time.Duration(total-done)).Round(time.Second)
+}
+
+// reposIn returns the repositories in the phase sorted
+func (p *progress) reposIn(phase string) []string {
+	var repos []string
+	for repo, ph := range p.phases {
+		if ph == phase {
+			repos = append(repos, repo)
+		}
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+// line describes the progress, e.g. '12/200 repositories done, cloning a, b, searching c, ETA 3m20s'
+func (p *progress) line(now time.Time) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	parts := []string{fmt.Sprintf("%d/%d repositories done", p.done, p.total)}
+	for _, phase := range []string{PhaseCloning, PhaseSearching} {
+		if repos := p.reposIn(phase); len(repos) > progressMaxNames {
+			parts = append(parts, fmt.Sprintf("%s %s +%d more", phase, strings.Join(repos[:progressMaxNames], ", "), len(repos)-progressMaxNames))
+		} else if len(repos) > 0 {
+			parts = append(parts, phase+" "+strings.Join(repos, ", "))
+		}
+	}
+	if left := eta(now.Sub(p.start), p.done, p.total); left > 0 {
+		parts = append(parts, "ETA "+left.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// report draws the progress line on a terminal or logs it
+func (p *progress) report(now time.Time) {
+	if p.tty {
+		p.draw(truncateLine(p.line(now), p.width))
+		return
+	}
+	slog.Info("progress", "status", p.line(now))
+}
+
+// truncateLine cuts the line to less than width characters, as the cursor is only returned to the start of the
+// last row of a line which wrapped and the rows above it would not be cleared
+func truncateLine(line string, width int) string {
+	runes := []rune(line)
+	if width <= 3 || len(runes) < width {
+		return line
+	}
+	return string(runes[:width-4]) + "..."
+}
+
+// draw replaces the progress line on the terminal with line, an empty line clears it
+func (p *progress) draw(line string) {
+	p.outMu.Lock()
+	defer p.outMu.Unlock()
+	fmt.Fprint(p.out, "\r\x1b[K"+line)
+	p.drawn = line
+}
+
+// run reports the progress until finish is called. On a terminal the records of the default logger are written
+// around the progress line in the meantime.
+func (p *progress) run() {
+	interval := progressLogInterval
+	if p.tty {
+		interval = progressTTYInterval
+		p.logger = slog.Default()
+		slog.SetDefault(slog.New(progressHandler{inner: p.logger.Handler(), progress: p}))
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case now := <-ticker.C:
+				p.report(now)
+			}
+		}
+	}()
+}
+
+// finish stops reporting the progress and clears the progress line
+func (p *progress) finish() {
+	close(p.stop)
+	p.wg.Wait()
+	if p.tty {
+		p.draw("")
+		slog.SetDefault(p.logger)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	IS "github.com/matryer/is"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestProgressLine(t *testing.T) {
+	is := IS.New(t)
+	p := newProgress(&bytes.Buffer{}, true, 4)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	analyze := p.tracking(func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		if r.Name == "done" {
+			return Application{Name: r.Name}, nil
+		}
+		reportPhase(ctx, r.Name[:len(r.Name)-2])
+		started <- struct{}{}
+		<-release
+		return Application{Name: r.Name}, nil
+	})
+
+	_, err := analyze(context.Background(), Repository{Name: "done"}, Config{})
+	is.NoErr(err)
+	go analyze(context.Background(), Repository{Name: PhaseCloning + "-a"}, Config{})
+	go analyze(context.Background(), Repository{Name: PhaseSearching + "-b"}, Config{})
+	<-started
+	<-started
+
+	is.Equal("1/4 repositories done, cloning cloning-a, searching searching-b, ETA 30s", p.line(p.start.Add(10*time.Second)))
+	close(release)
+}
+
+func TestProgressLineManyRepositories(t *testing.T) {
+	is := IS.New(t)
+	p := newProgress(&bytes.Buffer{}, true, 300)
+	for i := 0; i < 200; i++ {
+		p.phases[fmt.Sprintf("repo-%03d", i)] = PhaseCloning
+	}
+	p.phases["api"] = PhaseSearching
+
+	is.Equal("0/300 repositories done, cloning repo-000, repo-001, repo-002 +197 more, searching api", p.line(p.start))
+}
+
+func TestTruncateLine(t *testing.T) {
+	is := IS.New(t)
+	is.Equal("12/200 done", truncateLine("12/200 done", 80))
+	is.Equal("12/200 ...", truncateLine("12/200 repositories done", 11)) // shorter than the width so it never wraps
+	is.Equal("blåbær", truncateLine("blåbær", 7))
+}
+
+func TestETA(t *testing.T) {
+	is := IS.New(t)
+
+	is.Equal(time.Duration(0), eta(time.Minute, 0, 10))
+	is.Equal(9*time.Minute, eta(time.Minute, 1, 10))
+	is.Equal(time.Duration(0), eta(time.Minute, 10, 10))
+}
+
+func TestProgressFinishClearsLine(t *testing.T) {
+	is := IS.New(t)
+	var out bytes.Buffer
+	p := newProgress(&out, true, 1)
+
+	p.run()
+	p.finish()
+
+	is.True(bytes.HasSuffix(out.Bytes(), []byte("\r\x1b[K")))
+}
+
+func TestProgressHandlerClearsLine(t *testing.T) {
+	is := IS.New(t)
+	var out bytes.Buffer
+	p := newProgress(&out, true, 2)
+	logger := slog.New(progressHandler{inner: slog.NewTextHandler(&out, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}), progress: p})
+
+	p.draw("0/2 repositories done")
+	logger.Info("running command", "command", "git clone")
+
+	is.Equal("\r\x1b[K0/2 repositories done\r\x1b[Klevel=INFO msg=\"running command\" command=\"git clone\"\n0/2 repositories done", out.String())
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPushgatewayJob     = "count-fell"
+	DefaultPushgatewayTimeout = 10 * time.Second
+)
+
+// prometheusLabel escapes the value of a label in the Prometheus text format
+func prometheusLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// prometheusMetrics renders the counts of the result in the Prometheus text format, the count of every search word in
+// every repository, the count sum of every repository and whether it failed
+func prometheusMetrics(rf ResultFile) string {
+	var b strings.Builder
+	b.WriteString("# HELP grepper_matches_total Matches of the search word in the repository.\n")
+	b.WriteString("# TYPE grepper_matches_total gauge\n")
+	for _, app := range rf.Applications {
+		words := make([]string, 0, len(app.WordCounts))
+		for word := range app.WordCounts {
+			words = append(words, word)
+		}
+		sort.Strings(words)
+		for _, word := range words {
+			fmt.Fprintf(&b, "grepper_matches_total{repo=\"%s\",word=\"%s\"} %d\n", prometheusLabel(app.Name), prometheusLabel(word), app.WordCounts[word])
+		}
+	}
+	b.WriteString("# HELP grepper_count_sum Matches of all search words in the repository.\n")
+	b.WriteString("# TYPE grepper_count_sum gauge\n")
+	for _, app := range rf.Applications {
+		fmt.Fprintf(&b, "grepper_count_sum{repo=\"%s\"} %d\n", prometheusLabel(app.Name), app.CountSum)
+	}
+	b.WriteString("# HELP grepper_repo_failed Whether the repository could not be searched.\n")
+	b.WriteString("# TYPE grepper_repo_failed gauge\n")
+	for _, app := range rf.Applications {
+		failed := 0
+		if app.Status == StatusFailed {
+			failed = 1
+		}
+		fmt.Fprintf(&b, "grepper_repo_failed{repo=\"%s\"} %d\n", prometheusLabel(app.Name), failed)
+	}
+	return b.String()
+}
+
+// pushMetrics pushes the metrics of the result to the Pushgateway at url, replacing the metrics of the job
+func pushMetrics(pushgatewayURL, job string, rf ResultFile) error {
+	if job == "" {
+		job = DefaultPushgatewayJob
+	}
+	target := strings.TrimSuffix(pushgatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+	req, err := http.NewRequest(http.MethodPut, target, bytes.NewReader([]byte(prometheusMetrics(rf))))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	client := &http.Client{Timeout: DefaultPushgatewayTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("pushgateway responded with status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	IS "github.com/matryer/is"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	is := IS.New(t)
+	rf := ResultFile{Applications: []Application{
+		{Name: "a", Status: StatusOK, CountSum: 3, WordCounts: map[string]int{"todo": 1, `say "fell"`: 2}},
+		{Name: "b", Status: StatusFailed},
+	}}
+
+	is.Equal(`# HELP grepper_matches_total Matches of the search word in the repository.
+# TYPE grepper_matches_total gauge
+grepper_matches_total{repo="a",word="say \"fell\""} 2
+grepper_matches_total{repo="a",word="todo"} 1
+# HELP grepper_count_sum Matches of all search words in the repository.
+# TYPE grepper_count_sum gauge
+grepper_count_sum{repo="a"} 3
+grepper_count_sum{repo="b"} 0
+# HELP grepper_repo_failed Whether the repository could not be searched.
+# TYPE grepper_repo_failed gauge
+grepper_repo_failed{repo="a"} 0
+grepper_repo_failed{repo="b"} 1
+`, prometheusMetrics(rf))
+}
+
+func TestPushMetrics(t *testing.T) {
+	is := IS.New(t)
+	var method, path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.EscapedPath(), string(content)
+	}))
+	defer server.Close()
+	rf := ResultFile{Applications: []Application{{Name: "a", CountSum: 1, WordCounts: map[string]int{"fell": 1}}}}
+
+	is.NoErr(pushMetrics(server.URL+"/", "nightly scan", rf))
+
+	is.Equal(http.MethodPut, method)
+	is.Equal("/metrics/job/nightly%20scan", path)
+	is.Equal(prometheusMetrics(rf), body)
+}
+
+func TestPushMetricsStatus(t *testing.T) {
+	is := IS.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	is.True(pushMetrics(server.URL, "", ResultFile{}) != nil)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RefSeparator separates the name of a repository from the ref in the name of its applications
+const RefSeparator = "@"
+
+// expandRefs replaces every repository with refs by one repository per ref, named '<repo>@<ref>'.
+// The expanded repositories keep the rest of the settings, such as the exclude dirs, of the repository.
+func expandRefs(repos []Repository) ([]Repository, error) {
+	var result []Repository
+	for _, repo := range repos {
+		if repo.Branch != "" {
+			if repo.Ref != "" && repo.Ref != repo.Branch {
+				return nil, fmt.Errorf("repository '%s' has both a ref and a branch", repo.Name)
+			}
+			repo.Ref, repo.Branch = repo.Branch, ""
+		}
+		if len(repo.Refs) > 0 && repo.Ref != "" {
+			return nil, fmt.Errorf("repository '%s' has both refs and a ref", repo.Name)
+		}
+		if len(repo.Refs) == 0 {
+			result = append(result, repo)
+			continue
+		}
+		seen := map[string]bool{}
+		for _, ref := range repo.Refs {
+			if ref == "" {
+				return nil, fmt.Errorf("repository '%s' has an empty ref", repo.Name)
+			}
+			if seen[ref] {
+				return nil, fmt.Errorf("repository '%s' has the ref '%s' more than once", repo.Name, ref)
+			}
+			seen[ref] = true
+
+			expanded := repo
+			expanded.Name = repo.Name + RefSeparator + ref
+			expanded.Refs = nil
+			expanded.Ref = ref
+			result = append(result, expanded)
+		}
+	}
+	return result, nil
+}
+
+// cloneKey identifies the clone of the repository, repositories with the same url cloned at different refs differ
+func cloneKey(r Repository) string {
+	if r.Ref == "" {
+		return r.Url
+	}
+	return r.Url + RefSeparator + r.Ref
+}
+
+var commitSHAPattern = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// isCommitSHA reports whether the ref is a full commit SHA, which 'git clone --branch' can not check out
+func isCommitSHA(ref string) bool {
+	return commitSHAPattern.MatchString(ref)
+}
+
+// checkoutCommit fetches the commit into the clone at path and checks it out, with the given depth unless it is 0.
+// env is the environment git is run with, see gitAuthEnv.
+func checkoutCommit(ctx context.Context, gitBinary, path, commit string, depth int, env []string) error {
+	for _, cmd := range checkoutCommitCommands(ctx, gitBinary, path, commit, depth) {
+		cmd.Env = env
+		logCommand(cmd.Args)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, redactCredentials(strings.TrimSpace(string(out))))
+		}
+	}
+	return nil
+}
+
+func checkoutCommitCommands(ctx context.Context, gitBinary, path, commit string, depth int) []*exec.Cmd {
+	fetch := []string{"-C", path, "fetch", "--quiet"}
+	if depth > 0 {
+		fetch = append(fetch, "--depth", strconv.Itoa(depth))
+	}
+	fetch = append(fetch, "origin", commit)
+	return []*exec.Cmd{
+		exec.CommandContext(ctx, gitBinary, fetch...),
+		exec.CommandContext(ctx, gitBinary, "-C", path, "checkout", "--quiet", "--detach", "FETCH_HEAD"),
+	}
+}
+
+// headCommit returns the SHA of the commit checked out in the clone at path, which is empty when the repository has no commits
+func headCommit(ctx context.Context, gitBinary, path string) (string, error) {
+	out, err := exec.CommandContext(ctx, gitBinary, "-C", path, "rev-parse", "--verify", "--quiet", "HEAD").Output()
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) && exitError.ExitCode() == 1 {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve the commit of the clone: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandRefs(t *testing.T) {
+	is := IS.New(t)
+	repos := []Repository{
+		{Name: "backend", Url: "https://github.com/akselleirv/introspect-backend.git", ExcludeDirs: []string{"vendor"}, Refs: []string{"main", "v2.0"}},
+		{Name: "frontend", Url: "https://github.com/akselleirv/introspect-frontend.git"},
+	}
+
+	expanded, err := expandRefs(repos)
+
+	is.NoErr(err)
+	is.Equal([]Repository{
+		{Name: "backend@main", Url: "https://github.com/akselleirv/introspect-backend.git", ExcludeDirs: []string{"vendor"}, Ref: "main"},
+		{Name: "backend@v2.0", Url: "https://github.com/akselleirv/introspect-backend.git", ExcludeDirs: []string{"vendor"}, Ref: "v2.0"},
+		{Name: "frontend", Url: "https://github.com/akselleirv/introspect-frontend.git"},
+	}, expanded)
+}
+
+func TestExpandRefsRejectsInvalidRefs(t *testing.T) {
+	is := IS.New(t)
+
+	_, err := expandRefs([]Repository{{Name: "backend", Refs: []string{"main", ""}}})
+	is.True(err != nil)
+	_, err = expandRefs([]Repository{{Name: "backend", Refs: []string{"main", "main"}}})
+	is.True(err != nil)
+}
+
+func TestScanRefs(t *testing.T) {
+	is := IS.New(t)
+	dir := newTestRepo(t, map[string]string{"words.txt": "fell"})
+	is.NoErr(exec.Command("git", "-C", dir, "tag", "v1.0").Run())
+	is.NoErr(os.WriteFile(filepath.Join(dir, "more.txt"), []byte("fell fell"), 0644))
+	is.NoErr(exec.Command("git", "-C", dir, "add", "--all").Run())
+	is.NoErr(exec.Command("git", "-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--message=more").Run())
+	branch, err := exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD").Output()
+	is.NoErr(err)
+	head := string(branch[:len(branch)-1])
+
+	repos, err := expandRefs([]Repository{{Name: "words", Url: dir, Refs: []string{head, "v1.0"}}})
+	is.NoErr(err)
+	apps, errs := scan(context.Background(), Config{SearchWords: []string{"fell"}, Repositories: repos}, analyzeRepo)
+
+	is.Equal(0, len(errs))
+	is.Equal(2, len(apps))
+	is.Equal("words@"+head, apps[0].Name)
+	is.Equal(3, apps[0].CountSum)
+	is.Equal("words@v1.0", apps[1].Name)
+	is.Equal(1, apps[1].CountSum)
+}
+
+func TestExpandRefsBranch(t *testing.T) {
+	is := IS.New(t)
+
+	expanded, err := expandRefs([]Repository{{Name: "backend", Branch: "release/2.0"}})
+	is.NoErr(err)
+	is.Equal([]Repository{{Name: "backend", Ref: "release/2.0"}}, expanded)
+
+	_, err = expandRefs([]Repository{{Name: "backend", Ref: "main", Branch: "release/2.0"}})
+	is.True(err != nil)
+	_, err = expandRefs([]Repository{{Name: "backend", Ref: "main", Refs: []string{"v2.0"}}})
+	is.True(err != nil)
+}
+
+func TestIsCommitSHA(t *testing.T) {
+	is := IS.New(t)
+
+	is.True(isCommitSHA("a63ed46085d47bd00cef16070aca816d28eb8853"))
+	is.True(!isCommitSHA("a63ed46"))
+	is.True(!isCommitSHA("main"))
+	is.True(!isCommitSHA("A63ED46085D47BD00CEF16070ACA816D28EB8853"))
+}
+
+func TestAnalyzeRepoAtCommit(t *testing.T) {
+	is := IS.New(t)
+	dir := newTestRepo(t, map[string]string{"words.txt": "fell"})
+	first, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	is.NoErr(err)
+	commit := string(first[:len(first)-1])
+	is.NoErr(os.WriteFile(filepath.Join(dir, "more.txt"), []byte("fell fell"), 0644))
+	is.NoErr(exec.Command("git", "-C", dir, "add", "--all").Run())
+	is.NoErr(exec.Command("git", "-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--message=more").Run())
+	cfg := Config{SearchWords: []string{"fell"}}
+	trace := newTracer()
+
+	app, err := analyzeRepo(withTracer(context.Background(), trace), Repository{Name: "words", Url: dir, Ref: commit}, cfg)
+	is.NoErr(err)
+	is.Equal(commit, app.Ref)
+	is.Equal(commit, app.Commit)
+	is.Equal(1, app.CountSum)
+	spans := map[string]otlpSpan{}
+	for _, s := range trace.spans {
+		spans[s.Name] = s
+	}
+	is.Equal(spans["clone"].SpanID, spans["checkout"].ParentSpanID) // the checkout is part of the clone
+
+	app, err = analyzeRepo(context.Background(), Repository{Name: "words", Url: dir}, cfg)
+	is.NoErr(err)
+	is.Equal("", app.Ref)
+	is.True(app.Commit != commit) // the commit of the default branch is recorded
+	is.Equal(40, len(app.Commit))
+	is.Equal(3, app.CountSum)
+}
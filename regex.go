@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// The regex engines the regular expression search words are matched with, see regex_engine
+const (
+	RegexEngineBasic    = "basic"
+	RegexEngineExtended = "extended"
+	RegexEnginePerl     = "perl"
+)
+
+// validateRegexEngine checks the regex_engine of the config, the native search backend only matches Go regular
+// expressions, which are close to extended regular expressions
+func validateRegexEngine(cfg Config) error {
+	switch cfg.RegexEngine {
+	case "", RegexEngineBasic, RegexEngineExtended:
+		return nil
+	case RegexEnginePerl:
+		if cfg.SearchBackend == SearchBackendNative {
+			return errors.New("the perl regex_engine is not supported by the native search backend")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown regex_engine '%s', must be %s, %s or %s", cfg.RegexEngine, RegexEngineBasic, RegexEngineExtended, RegexEnginePerl)
+	}
+}
+
+// validateLineMatching checks that the search words can be matched with Go regular expressions when exclude_patterns,
+// include_matches or context_lines search the files again to find the lines of the matches. The perl regex_engine
+// has syntax which Go does not support, like lookarounds and backreferences.
+func validateLineMatching(cfg Config) error {
+	if cfg.RegexEngine != RegexEnginePerl || (len(cfg.ExcludePatterns) == 0 && !cfg.IncludeMatches && cfg.ContextLines == 0) {
+		return nil
+	}
+	for _, group := range cfg.searchWordGroups() {
+		for i, word := range quoteSearchWords(group.Words, group.FixedStrings) {
+			if _, err := regexp.Compile(word); err != nil {
+				return fmt.Errorf("search word '%s' can not be used with exclude_patterns, include_matches, context_lines or the sarif format, they match the search words with Go regular expressions instead of the perl regex_engine: %w", group.Words[i], err)
+			}
+		}
+	}
+	return nil
+}
+
+// grepRegexEngineArgs returns the args selecting the regex engine of grep and git grep, fixed strings have no engine
+func grepRegexEngineArgs(engine string, fixedStrings bool) []string {
+	if fixedStrings {
+		return nil
+	}
+	switch engine {
+	case RegexEngineExtended:
+		return []string{"--extended-regexp"}
+	case RegexEnginePerl:
+		return []string{"--perl-regexp"}
+	default:
+		return nil
+	}
+}
+
+// perlPattern joins the search words into one alternation, grep only supports a single pattern with --perl-regexp
+func perlPattern(searchWords []string) string {
+	alternatives := make([]string, len(searchWords))
+	for i, word := range searchWords {
+		alternatives[i] = "(?:" + word + ")"
+	}
+	return strings.Join(alternatives, "|")
+}
+
+// checkGrepPerl checks that grep supports --perl-regexp, which is left out of some builds of grep
+func checkGrepPerl(binary string) error {
+	cmd := exec.Command(binary, "--perl-regexp", "--quiet", "x")
+	var stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader("")
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitError *exec.ExitError
+	if err == nil || errors.As(err, &exitError) && exitError.ExitCode() == GrepErrorCodeNoMatches {
+		return nil
+	}
+	return fmt.Errorf("'%s' does not support the perl regex_engine, use the extended regex_engine or the ripgrep search backend: %s", binary, strings.TrimSpace(stderr.String()))
+}
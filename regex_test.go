@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGrepCommandRegexEngine(t *testing.T) {
+	is := IS.New(t)
+
+	cmd := grepCommand(context.Background(), "./testdata", []string{`v\d+`, "fell"}, grepOptions{RegexEngine: RegexEnginePerl})
+	is.Equal([]string{
+		"grep", "--exclude-dir=.git", `--regexp=(?:v\d+)|(?:fell)`, "--recursive", "--only-matching", "--with-filename", "--binary-files=without-match",
+		"--ignore-case", "--perl-regexp", "./testdata",
+	}, cmd.Args)
+
+	cmd = grepCommand(context.Background(), "./testdata", []string{"fell"}, grepOptions{RegexEngine: RegexEngineExtended, FixedStrings: true})
+	is.Equal([]string{
+		"grep", "--exclude-dir=.git", "--regexp=fell", "--recursive", "--only-matching", "--with-filename", "--binary-files=without-match",
+		"--ignore-case", "--fixed-strings", "./testdata",
+	}, cmd.Args) // grep does not allow an engine together with fixed strings
+}
+
+func TestAnalyzePathRegexEngine(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("color colour v12 v3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		engine string
+		words  []string
+		counts map[string]int
+	}{
+		{RegexEngineExtended, []string{"colou?r", "v[0-9]+"}, map[string]int{"colou?r": 2, "v[0-9]+": 2}},
+		{RegexEnginePerl, []string{`colou?r`, `v\d+`}, map[string]int{"colou?r": 2, `v\d+`: 2}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.engine, func(t *testing.T) {
+			is := IS.New(t)
+			cfg := Config{SearchWords: tc.words, Regex: true, RegexEngine: tc.engine}
+			if tc.engine == RegexEnginePerl {
+				if err := checkGrepPerl(DefaultGrepBinary); err != nil {
+					t.Skip(err)
+				}
+			}
+
+			app, err := analyzePath(context.Background(), Repository{Name: "repo"}, cfg, dir)
+
+			is.NoErr(err)
+			is.Equal(tc.counts, app.WordCounts)
+		})
+	}
+}
+
+func TestValidateRegexEngine(t *testing.T) {
+	is := IS.New(t)
+
+	is.NoErr(validateRegexEngine(Config{RegexEngine: RegexEnginePerl, SearchBackend: SearchBackendRipgrep}))
+	is.NoErr(validateRegexEngine(Config{RegexEngine: RegexEngineExtended, SearchBackend: SearchBackendNative}))
+	is.True(validateRegexEngine(Config{RegexEngine: RegexEnginePerl, SearchBackend: SearchBackendNative}) != nil)
+	is.True(validateRegexEngine(Config{RegexEngine: "pcre"}) != nil)
+}
+
+func TestValidateLineMatching(t *testing.T) {
+	is := IS.New(t)
+	lookahead := Config{SearchWords: []string{`fell(?!ow)`}, Regex: true, RegexEngine: RegexEnginePerl}
+
+	is.NoErr(validateLineMatching(lookahead))
+	for _, cfg := range []Config{
+		{SearchWords: lookahead.SearchWords, Regex: true, RegexEngine: RegexEnginePerl, IncludeMatches: true},
+		{SearchWords: lookahead.SearchWords, Regex: true, RegexEngine: RegexEnginePerl, ContextLines: 2},
+		{SearchWords: lookahead.SearchWords, Regex: true, RegexEngine: RegexEnginePerl, ExcludePatterns: []string{"approved"}},
+	} {
+		is.True(validateLineMatching(cfg) != nil)
+	}
+	is.NoErr(validateLineMatching(Config{SearchWords: []string{`fell\d+`}, Regex: true, RegexEngine: RegexEnginePerl, IncludeMatches: true}))
+	is.NoErr(validateLineMatching(Config{SearchWords: []string{`fell(?!ow)`}, RegexEngine: RegexEnginePerl, IncludeMatches: true})) // not a regex
+}
+
+func TestCheckGrepPerl(t *testing.T) {
+	is := IS.New(t)
+	noPerl := filepath.Join(t.TempDir(), "grep")
+	is.NoErr(os.WriteFile(noPerl, []byte("#!/bin/sh\necho 'grep: Perl matching not supported in a --disable-perl-regexp build' >&2\nexit 2\n"), 0755))
+
+	is.True(checkGrepPerl(noPerl) != nil)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// loadRepositoriesFile reads the repositories of a repositories file, a relative file name is relative to the config file
+func loadRepositoriesFile(configPath, fileName string) ([]Repository, error) {
+	if !filepath.IsAbs(fileName) {
+		fileName = filepath.Join(filepath.Dir(configPath), fileName)
+	}
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read repositories_file: %w", err)
+	}
+	return parseRepositoriesFile(string(content))
+}
+
+// resolveRepositoryPaths makes the relative paths of the repositories relative to the dir of the config, the
+// absolute paths are cleaned the same way
+func resolveRepositoryPaths(configPath string, repos []Repository) {
+	for i, repo := range repos {
+		switch {
+		case repo.Path == "":
+		case filepath.IsAbs(repo.Path):
+			repos[i].Path = filepath.Clean(repo.Path)
+		default:
+			repos[i].Path = filepath.Join(filepath.Dir(configPath), repo.Path)
+		}
+	}
+}
+
+// parseRepositoriesFile parses a repositories file, where every line is either a clone url or 'name,url'.
+// Blank lines and lines starting with '#' are ignored. Without a name the repository is named after the url.
+func parseRepositoriesFile(content string) ([]Repository, error) {
+	var result []Repository
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, url := "", line
+		if parts := strings.SplitN(line, ",", 2); len(parts) == 2 {
+			name, url = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		}
+		if url == "" {
+			return nil, fmt.Errorf("line %d of repositories_file has no url", i+1)
+		}
+		if name == "" {
+			name = repoNameFromURL(url)
+		}
+		result = append(result, Repository{Name: name, Url: url})
+	}
+	return result, nil
+}
+
+// repoNameFromURL returns the last path element of the url without the .git suffix
+func repoNameFromURL(url string) string {
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+	if i := strings.LastIndex(url, ":"); i > strings.LastIndex(url, "/") {
+		url = url[i+1:]
+	}
+	return path.Base(url)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRepositoriesFile(t *testing.T) {
+	is := IS.New(t)
+	content := `# backend services
+https://github.com/akselleirv/introspect-backend.git
+
+  # frontend
+frontend,git@github.com:akselleirv/introspect-frontend.git
+git@github.com:akselleirv/introspect-infra.git
+`
+
+	repos, err := parseRepositoriesFile(content)
+
+	is.NoErr(err)
+	is.Equal([]Repository{
+		{Name: "introspect-backend", Url: "https://github.com/akselleirv/introspect-backend.git"},
+		{Name: "frontend", Url: "git@github.com:akselleirv/introspect-frontend.git"},
+		{Name: "introspect-infra", Url: "git@github.com:akselleirv/introspect-infra.git"},
+	}, repos)
+}
+
+func TestParseRepositoriesFileWithoutUrl(t *testing.T) {
+	is := IS.New(t)
+
+	_, err := parseRepositoriesFile("frontend,\n")
+
+	is.True(err != nil)
+}
+
+func TestRepoNameFromURL(t *testing.T) {
+	is := IS.New(t)
+
+	is.Equal("introspect-backend", repoNameFromURL("https://github.com/akselleirv/introspect-backend.git"))
+	is.Equal("introspect-backend", repoNameFromURL("git@github.com:introspect-backend.git"))
+	is.Equal("repo", repoNameFromURL("/srv/git/repo/"))
+}
+
+func TestLoadConfigMergesRepositoriesFile(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	config := `{"search_words": ["fell"], "repositories_file": "repos.txt", "repositories": [{"name": "inline", "url": "inline.git"}]}`
+	is.NoErr(os.WriteFile(configPath, []byte(config), 0644))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "repos.txt"), []byte("# from file\nfile,file.git\n"), 0644))
+
+	cfg, err := loadConfig(configPath)
+
+	is.NoErr(err)
+	is.Equal([]Repository{{Name: "inline", Url: "inline.git"}, {Name: "file", Url: "file.git"}}, cfg.Repositories)
+}
+
+func TestLoadConfigRepositoryPath(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	config := `{"search_words": ["fell"], "repositories": [{"name": "local", "path": "checkout"}, {"name": "abs", "path": "/srv/checkout"}]}`
+	is.NoErr(os.WriteFile(configPath, []byte(config), 0644))
+
+	cfg, err := loadConfig(configPath)
+
+	is.NoErr(err)
+	is.Equal([]Repository{{Name: "local", Path: filepath.Join(dir, "checkout")}, {Name: "abs", Path: "/srv/checkout"}}, cfg.Repositories)
+}
+
+func TestRunRepositoryAbsolutePathWithTrailingSlash(t *testing.T) {
+	is := IS.New(t)
+	abs, err := filepath.Abs("./testdata")
+	is.NoErr(err)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	config := `{"search_words": ["fell"], "exclude_dirs": ["encoding"], "repositories": [{"name": "abs", "path": "` + abs + `/"}]}`
+	is.NoErr(os.WriteFile(configPath, []byte(config), 0644))
+	resultPath := filepath.Join(t.TempDir(), "results.json")
+
+	cfg, err := loadConfig(configPath)
+	is.NoErr(err)
+	is.Equal(abs, cfg.Repositories[0].Path) // the trailing slash is cleaned
+	is.Equal(ExitCodeSuccess, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON}, analyzeRepo))
+
+	result, err := readResultFile(resultPath)
+	is.NoErr(err)
+	is.Equal(6, result.TotalCountSum)
+	is.Equal("testdata_1.txt", result.Applications[0].GrepResults[0].FileName)
+}
+
+func TestValidateConfigRepositoryPath(t *testing.T) {
+	is := IS.New(t)
+	validate := func(r Repository) error {
+		return validateConfig(Config{SearchWords: []string{"fell"}, DeterministicTemp: true, Repositories: []Repository{r, {Name: "other", Path: "/srv/other"}}})
+	}
+
+	is.NoErr(validate(Repository{Name: "local", Path: "/srv/checkout"}))
+	is.True(validate(Repository{Name: "local"}) != nil)
+	is.True(validate(Repository{Name: "local", Url: "local.git", Path: "/srv/checkout"}) != nil)
+	is.True(validate(Repository{Name: "local", Path: "/srv/checkout", Ref: "main"}) != nil)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	SearchBackendRipgrep = "ripgrep"
+	DefaultRipgrepBinary = "rg"
+	// RipgrepErrorCodeNoMatches is the exit code of rg when nothing matched
+	RipgrepErrorCodeNoMatches = 1
+)
+
+// resolveSearchBackend returns the search backend to use, the ripgrep backend falls back to grep when rg is not installed
+func resolveSearchBackend(backend string) string {
+	if backend != SearchBackendRipgrep {
+		return backend
+	}
+	if _, err := exec.LookPath(DefaultRipgrepBinary); err != nil {
+		slog.Warn("ripgrep is not installed, falling back to the grep search backend", "binary", DefaultRipgrepBinary)
+		return SearchBackendGrep
+	}
+	return backend
+}
+
+// ripgrep searches the path for the search words with rg and gives the same result as grep.
+// rg does not use the regular expression syntax of grep, so the search words are matched as rg regular expressions.
+// Like with grep, the files are listed first when symlinks are followed so those pointing outside of path are not.
+func ripgrep(ctx context.Context, path string, searchWords []string, opts grepOptions) ([]GrepResult, error) {
+	if !opts.FollowSymlinks {
+		return ripgrepPaths(ctx, path, searchWords, opts)
+	}
+	files, err := searchedFiles(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return searchListed(files, opts, func(opts grepOptions) ([]GrepResult, error) {
+		return ripgrepPaths(ctx, path, searchWords, opts)
+	})
+}
+
+// ripgrepPaths runs a single rg command searching the paths of the options, or the whole path without them
+func ripgrepPaths(ctx context.Context, path string, searchWords []string, opts grepOptions) ([]GrepResult, error) {
+	rgCmd := ripgrepCommand(ctx, path, searchWords, opts)
+	logCommand(rgCmd.Args)
+	var stderr bytes.Buffer
+	rgCmd.Stderr = &stderr
+	stdout, err := rgCmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute rg command: %w", err)
+	}
+	if err := rgCmd.Start(); err != nil {
+		return nil, fmt.Errorf("unable to execute rg command: %w", err)
+	}
+
+	result, parseErr := parseRipgrepJSON(stdout, path)
+	if err := rgCmd.Wait(); err != nil {
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			if exitError.ExitCode() == RipgrepErrorCodeNoMatches {
+				return []GrepResult{}, nil
+			}
+			return nil, fmt.Errorf("unable to execute rg command: %s", stderr.String())
+		}
+		return nil, fmt.Errorf("unable to execute rg command: %w", err)
+	}
+	if parseErr != nil {
+		return nil, fmt.Errorf("unable to read rg output: %w", parseErr)
+	}
+	result = filterExcludeFiles(filterExcludeDirs(filterIncludeGlobs(result, opts.IncludeGlobs), opts.ExcludeDirs), opts.ExcludeFiles)
+	return attributeWords(result, searchWords, opts.CaseSensitive), nil
+}
+
+// ripgrepCommand builds a 'rg --json' command searching the same files as grepCommand:
+// ignore files and hidden files are not skipped and symlinks are only followed with FollowSymlinks.
+func ripgrepCommand(ctx context.Context, path string, searchWords []string, opts grepOptions) *exec.Cmd {
+	args := []string{"--json", "--no-config", "--no-ignore", "--hidden"}
+	if opts.CaseSensitive {
+		args = append(args, "--case-sensitive")
+	} else {
+		args = append(args, "--ignore-case")
+	}
+	if opts.WordBoundary {
+		args = append(args, "--word-regexp")
+	}
+	if opts.FixedStrings {
+		args = append(args, "--fixed-strings")
+	} else if opts.RegexEngine == RegexEnginePerl {
+		args = append(args, "--pcre2")
+	}
+	if opts.SearchBinary {
+		args = append(args, "--text")
+	}
+	if opts.MaxFileSize > 0 {
+		args = append(args, fmt.Sprintf("--max-filesize=%d", opts.MaxFileSize))
+	}
+	if opts.FollowSymlinks {
+		args = append(args, "--follow")
+	}
+	if !opts.ScanGitDir {
+		args = append(args, "--glob=!"+GitDir)
+	}
+	for _, dir := range opts.ExcludeDirs {
+		args = append(args, "--glob=!"+strings.TrimSuffix(dir, "/"))
+	}
+	for _, file := range opts.ExcludeFiles {
+		args = append(args, "--glob=!"+file)
+	}
+	for _, include := range opts.IncludeGlobs {
+		args = append(args, "--glob="+include)
+	}
+	for _, word := range searchWords {
+		args = append(args, "--regexp="+word)
+	}
+	args = append(args, "--")
+	args = append(args, grepPathsStr(path, opts.Paths)...)
+	return exec.CommandContext(ctx, DefaultRipgrepBinary, args...)
+}
+
+// ripgrepMessage is one line of the 'rg --json' output, only the match messages are used
+type ripgrepMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Path       ripgrepText `json:"path"`
+		Submatches []struct {
+			Match ripgrepText `json:"match"`
+		} `json:"submatches"`
+	} `json:"data"`
+}
+
+// ripgrepText is text in the rg output, which is base64 encoded in bytes when it is not valid UTF-8
+type ripgrepText struct {
+	Text  string `json:"text"`
+	Bytes string `json:"bytes"`
+}
+
+func (t ripgrepText) String() string {
+	if t.Bytes == "" {
+		return t.Text
+	}
+	decoded, err := base64.StdEncoding.DecodeString(t.Bytes)
+	if err != nil {
+		return t.Text
+	}
+	return string(decoded)
+}
+
+// parseRipgrepJSON counts the submatches of the match messages of 'rg --json' per file, relative to the base path
+func parseRipgrepJSON(r io.Reader, basePath string) ([]GrepResult, error) {
+	var results []GrepResult
+	index := map[string]int{}
+	decoder := json.NewDecoder(r)
+	for {
+		var msg ripgrepMessage
+		err := decoder.Decode(&msg)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if msg.Type != "match" {
+			continue
+		}
+		fileName := msg.Data.Path.String()
+		if rel, err := filepath.Rel(basePath, fileName); err == nil {
+			fileName = filepath.ToSlash(rel)
+		}
+		i, ok := index[fileName]
+		if !ok {
+			i = len(results)
+			index[fileName] = i
+			results = append(results, GrepResult{FileName: fileName, Words: map[string]int{}})
+		}
+		for _, submatch := range msg.Data.Submatches {
+			results[i].Count++
+			results[i].Words[submatch.Match.String()]++
+		}
+	}
+	if results == nil {
+		results = []GrepResult{}
+	}
+	return results, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRipgrepCommand(t *testing.T) {
+	is := IS.New(t)
+	opts := grepOptions{ExcludeDirs: []string{"node_modules", "pkg/generated/"}, IncludeGlobs: []string{"*.go"}, Paths: []string{"cmd"}}
+
+	cmd := ripgrepCommand(context.Background(), "/tmp/clone", []string{"fell", "use"}, opts)
+
+	is.Equal([]string{
+		"rg", "--json", "--no-config", "--no-ignore", "--hidden", "--ignore-case",
+		"--glob=!.git", "--glob=!node_modules", "--glob=!pkg/generated", "--glob=*.go",
+		"--regexp=fell", "--regexp=use", "--", "/tmp/clone/cmd",
+	}, cmd.Args)
+}
+
+func TestParseRipgrepJSON(t *testing.T) {
+	is := IS.New(t)
+	output := `{"type":"begin","data":{"path":{"text":"/tmp/clone/main.go"}}}
+{"type":"match","data":{"path":{"text":"/tmp/clone/main.go"},"lines":{"text":"fell Fell\n"},"line_number":1,"absolute_offset":0,"submatches":[{"match":{"text":"fell"},"start":0,"end":4},{"match":{"text":"Fell"},"start":5,"end":9}]}}
+{"type":"match","data":{"path":{"text":"/tmp/clone/main.go"},"lines":{"text":"use\n"},"line_number":2,"absolute_offset":10,"submatches":[{"match":{"text":"use"},"start":0,"end":3}]}}
+{"type":"end","data":{"path":{"text":"/tmp/clone/main.go"}}}
+{"type":"match","data":{"path":{"bytes":"L3RtcC9jbG9uZS9kb2NzL2ZlbGwudHh0"},"lines":{"text":"fell\n"},"line_number":1,"absolute_offset":0,"submatches":[{"match":{"text":"fell"},"start":0,"end":4}]}}
+{"type":"summary","data":{}}
+`
+
+	result, err := parseRipgrepJSON(strings.NewReader(output), "/tmp/clone")
+
+	is.NoErr(err)
+	is.Equal([]GrepResult{
+		{FileName: "main.go", Count: 3, Words: map[string]int{"fell": 1, "Fell": 1, "use": 1}},
+		{FileName: "docs/fell.txt", Count: 1, Words: map[string]int{"fell": 1}},
+	}, result)
+}
+
+func TestResolveSearchBackend(t *testing.T) {
+	is := IS.New(t)
+
+	is.Equal(SearchBackendNative, resolveSearchBackend(SearchBackendNative))
+	if _, err := exec.LookPath(DefaultRipgrepBinary); err != nil {
+		is.Equal(SearchBackendGrep, resolveSearchBackend(SearchBackendRipgrep))
+	} else {
+		is.Equal(SearchBackendRipgrep, resolveSearchBackend(SearchBackendRipgrep))
+	}
+}
+
+func TestRipgrepMatchesGrep(t *testing.T) {
+	if _, err := exec.LookPath(DefaultRipgrepBinary); err != nil {
+		t.Skip("rg is not installed")
+	}
+	is := IS.New(t)
+	opts := grepOptions{ExcludeDirs: []string{"encoding"}}
+
+	grepped, err := grep(context.Background(), "./testdata", []string{"fell"}, opts)
+	is.NoErr(err)
+	ripgrepped, err := ripgrep(context.Background(), "./testdata", []string{"fell"}, opts)
+	is.NoErr(err)
+
+	sortOnFileName(grepped)
+	sortOnFileName(ripgrepped)
+	is.Equal(grepped, ripgrepped)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	FormatSARIF = "sarif"
+
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// sarifLog is a result file in the SARIF format, which code scanning of GitHub and Azure DevOps show on pull requests
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// sarifLevel maps the severity of a search word to the level of its SARIF results
+func sarifLevel(severity string) string {
+	switch severity {
+	case SeverityCritical:
+		return "error"
+	case SeverityInfo:
+		return "note"
+	}
+	return "warning"
+}
+
+// sarifURI is the path of the file relative to the searched dir, for a file inside an archive it is the archive
+func sarifURI(fileName string) string {
+	if archive, _, ok := splitArchiveName(fileName); ok {
+		fileName = archive
+	}
+	return strings.TrimPrefix(fileName, "./")
+}
+
+// buildSARIF converts the result to SARIF with the search word as the rule of each result. Every match becomes a
+// result at its line, a file without the location of its matches, like a file inside an archive, becomes one result
+// per search word without a region.
+func buildSARIF(rf ResultFile, wordOptions map[string]SearchWord) sarifLog {
+	run := sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: "count-fell"}}, Results: []sarifResult{}}
+	rules := make(map[string]bool)
+	result := func(app Application, fileName, word string, count int, region *sarifRegion) {
+		rules[word] = true
+		text := fmt.Sprintf("'%s' found in %s", word, fileName)
+		if count > 1 {
+			text = fmt.Sprintf("'%s' found %d times in %s", word, count, fileName)
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  word,
+			Level:   sarifLevel(wordOptions[word].Severity),
+			Message: sarifMessage{Text: text},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: sarifURI(fileName)},
+				Region:           region,
+			}}},
+			Properties: map[string]string{"application": app.Name},
+		})
+	}
+	for _, app := range rf.Applications {
+		for _, part := range withSubdirs(app) {
+			for _, gr := range part.GrepResults {
+				if len(gr.Matches) > 0 {
+					for _, m := range gr.Matches {
+						result(part, gr.FileName, m.Word, 1, &sarifRegion{StartLine: m.Line, StartColumn: m.Column})
+					}
+					continue
+				}
+				words := make([]string, 0, len(gr.Words))
+				for word := range gr.Words {
+					words = append(words, word)
+				}
+				sort.Strings(words)
+				for _, word := range words {
+					result(part, gr.FileName, word, gr.Words[word], nil)
+				}
+			}
+		}
+	}
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: "search word '" + id + "'"}})
+	}
+	if run.Tool.Driver.Rules == nil {
+		run.Tool.Driver.Rules = []sarifRule{}
+	}
+	return sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}
+}
+
+func writeSARIF(fileName string, rf ResultFile, wordOptions map[string]SearchWord, perm os.FileMode) error {
+	file, err := json.MarshalIndent(buildSARIF(rf, wordOptions), "", " ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fileName, perm, func(w io.Writer) error {
+		_, err := w.Write(file)
+		return err
+	})
+}
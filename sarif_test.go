@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildSARIF(t *testing.T) {
+	is := IS.New(t)
+	rf := ResultFile{Applications: []Application{
+		{Name: "a", GrepResults: []GrepResult{
+			{FileName: "main.go", Count: 2, Words: map[string]int{"fell": 1, "todo": 1}, Matches: []Match{
+				{Line: 3, Column: 5, Word: "fell"},
+				{Line: 7, Column: 1, Word: "todo"},
+			}},
+			{FileName: "docs.zip!notes.txt", Count: 2, Words: map[string]int{"fell": 2}},
+		}},
+	}}
+
+	log := buildSARIF(rf, map[string]SearchWord{"fell": {Severity: SeverityCritical}})
+
+	is.Equal(sarifVersion, log.Version)
+	is.Equal(1, len(log.Runs))
+	run := log.Runs[0]
+	is.Equal([]sarifRule{
+		{ID: "fell", ShortDescription: sarifMessage{Text: "search word 'fell'"}},
+		{ID: "todo", ShortDescription: sarifMessage{Text: "search word 'todo'"}},
+	}, run.Tool.Driver.Rules)
+	is.Equal(3, len(run.Results))
+	is.Equal("fell", run.Results[0].RuleID)
+	is.Equal("error", run.Results[0].Level)
+	is.Equal(&sarifRegion{StartLine: 3, StartColumn: 5}, run.Results[0].Locations[0].PhysicalLocation.Region)
+	is.Equal("a", run.Results[0].Properties["application"])
+	is.Equal("warning", run.Results[1].Level)
+	is.Equal("docs.zip", run.Results[2].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	is.True(run.Results[2].Locations[0].PhysicalLocation.Region == nil)
+	is.Equal("'fell' found 2 times in docs.zip!notes.txt", run.Results[2].Message.Text)
+}
+
+func TestSarifURI(t *testing.T) {
+	is := IS.New(t)
+	is.Equal("a!b.txt", sarifURI("./a!b.txt")) // a '!' which does not follow an archive is part of the file name
+	is.Equal("docs/a!b.zip", sarifURI("docs/a!b.zip!notes.txt"))
+	is.Equal("main.go", sarifURI("./main.go"))
+}
+
+func TestRunSARIF(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, "nordic.txt"), []byte("første linje\nblåbær fell\n"), 0644))
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": ["fell"]}`), 0644))
+	resultPath := filepath.Join(t.TempDir(), "results.sarif")
+
+	is.Equal(ExitCodeSuccess, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatSARIF, Dir: dir}, nil))
+
+	content, err := os.ReadFile(resultPath)
+	is.NoErr(err)
+	var log sarifLog
+	is.NoErr(json.Unmarshal(content, &log))
+	is.Equal(1, len(log.Runs[0].Results))
+	is.Equal("nordic.txt", log.Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	is.Equal(&sarifRegion{StartLine: 2, StartColumn: 8}, log.Runs[0].Results[0].Locations[0].PhysicalLocation.Region)
+	is.True(validateFormat(FormatSARIF, Config{SummaryOnly: true}) != nil)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// analyzeFunc analyzes one repository, see analyzeRepo
+type analyzeFunc = func(ctx context.Context, r Repository, cfg Config) (Application, error)
+
+// scan analyzes the repositories in the config with at most max_concurrency of them at the same time, all of them when
+// it is not set, and returns the applications of the repositories in the order of the config, each followed by the
+// applications of its subdirs, together with the errors
+// of the repositories which failed. The application of a failed repository has StatusFailed and its error.
+// When fail_fast is set the remaining repositories are cancelled as soon as one repository fails.
+func scan(ctx context.Context, cfg Config, analyze analyzeFunc) ([]Application, []error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	apps := make([]Application, len(cfg.Repositories))
+	errs := make([]error, len(cfg.Repositories))
+	workers := cfg.MaxConcurrency
+	if workers <= 0 || workers > len(cfg.Repositories) {
+		workers = len(cfg.Repositories)
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				repo := cfg.Repositories[index]
+				if err := ctx.Err(); err != nil {
+					errs[index] = redactError(fmt.Errorf("skipped repo '%s': %w", repo.Name, err))
+					apps[index] = failedApplication(repo, err)
+					continue
+				}
+				repoCtx, repoSpan := startSpan(ctx, "repository", "repository", repo.Name)
+				app, err := analyze(repoCtx, repo, cfg)
+				repoSpan.end(err)
+				if err != nil {
+					errs[index] = redactError(fmt.Errorf("failed on repo '%s': %w", repo.Name, err))
+					apps[index] = failedApplication(repo, err)
+					if cfg.FailFast {
+						cancel()
+					}
+					continue
+				}
+				apps[index] = app
+			}
+		}()
+	}
+	for i := range cfg.Repositories {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	var result []Application
+	for _, app := range apps {
+		result = append(result, withSubdirs(app)...)
+	}
+	return result, failed
+}
+
+func failedApplication(r Repository, err error) Application {
+	return Application{Name: r.Name, Status: StatusFailed, Error: redactCredentials(err.Error())}
+}
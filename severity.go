@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// validateSeverities checks that the severity of every search word is known
+func validateSeverities(wordOptions map[string]SearchWord) error {
+	for word, options := range wordOptions {
+		switch options.Severity {
+		case "", SeverityInfo, SeverityWarning, SeverityCritical:
+		default:
+			return fmt.Errorf("unknown severity '%s' of search word '%s', must be %s, %s or %s", options.Severity, word, SeverityInfo, SeverityWarning, SeverityCritical)
+		}
+	}
+	return nil
+}
+
+// severityCounts sums the word counts per severity of the search words, search words without a severity are left out
+func (cfg Config) severityCounts(wordCounts map[string]int) map[string]int {
+	return cfg.sumWordCountsBy(wordCounts, func(word SearchWord) string { return word.Severity })
+}
+
+// calculateSeverityTotals sums the severity counts of all applications per severity
+func calculateSeverityTotals(rf ResultFile) map[string]int {
+	return sumApplicationCounts(rf, func(app Application) map[string]int { return app.SeverityCounts })
+}
+
+// logCriticalMatches logs the applications in which critical search words were found
+func logCriticalMatches(rf ResultFile) {
+	for _, app := range rf.Applications {
+		if count := app.SeverityCounts[SeverityCritical]; count > 0 {
+			slog.Error("critical search words found", "repository", app.Name, "count", count)
+		}
+	}
+}
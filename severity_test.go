@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunCriticalSearchWord(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	resultPath := filepath.Join(dir, "results.json")
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": [{"pattern": "fell", "severity": "critical"}, {"pattern": "nomatch", "severity": "info"}]}`), 0644))
+
+	is.Equal(ExitCodeCriticalMatch, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON, Dir: "./testdata"}, nil))
+
+	content, err := os.ReadFile(resultPath)
+	is.NoErr(err)
+	var result ResultFile
+	is.NoErr(json.Unmarshal(content, &result))
+	is.Equal(result.TotalCountSum, result.SeverityTotals[SeverityCritical])
+	is.Equal(result.TotalCountSum, result.Applications[0].SeverityCounts[SeverityCritical])
+
+	is.NoErr(os.WriteFile(configPath, []byte(`{"search_words": [{"pattern": "fell", "severity": "warning"}]}`), 0644))
+	is.Equal(ExitCodeSuccess, run(options{ConfigPath: configPath, ResultPath: resultPath, Format: FormatJSON, Dir: "./testdata"}, nil))
+}
+
+func TestValidateSeverities(t *testing.T) {
+	is := IS.New(t)
+
+	is.NoErr(validateSeverities(map[string]SearchWord{"fell": {Severity: SeverityWarning}, "foo": {Group: "legacy"}}))
+	is.True(validateSeverities(map[string]SearchWord{"fell": {Severity: "fatal"}}) != nil)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits are the units of a file size, a kilobyte is 1024 bytes
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseFileSize parses a file size such as 512KB, 10MB or 1048576, 0 means no limit when it is empty
+func parseFileSize(size string) (int64, error) {
+	if size == "" {
+		return 0, nil
+	}
+	number, unit := strings.TrimSpace(size), int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(strings.ToUpper(number), u.suffix) {
+			number, unit = strings.TrimSpace(number[:len(number)-len(u.suffix)]), u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid max_file_size '%s', must be a positive number of bytes with an optional KB, MB or GB unit", size)
+	}
+	if n > math.MaxInt64/unit {
+		return 0, fmt.Errorf("invalid max_file_size '%s', it is too large", size)
+	}
+	return n * unit, nil
+}
+
+// largeFile reports whether the file is larger than maxSize, which is never the case when maxSize is 0
+func largeFile(fileName string, maxSize int64) bool {
+	if maxSize <= 0 {
+		return false
+	}
+	info, err := os.Stat(fileName)
+	return err == nil && info.Size() > maxSize
+}
+
+// splitLargeFiles splits the files, relative to root, in the files which are at most maxSize and the larger ones
+func splitLargeFiles(root string, files []string, maxSize int64) ([]string, []string) {
+	if maxSize <= 0 {
+		return files, nil
+	}
+	var result, large []string
+	for _, file := range files {
+		if largeFile(filepath.Join(root, file), maxSize) {
+			large = append(large, file)
+		} else {
+			result = append(result, file)
+		}
+	}
+	return result, large
+}
+
+// skippedLargeFiles lists the files, relative to path, which are searched with the options except for being larger
+// than the max file size of the options
+func skippedLargeFiles(path string, opts grepOptions) ([]string, error) {
+	maxSize := opts.MaxFileSize
+	opts.MaxFileSize = 0
+	files, err := searchedFiles(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	_, large := splitLargeFiles(path, files, maxSize)
+	return large, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFileSize(t *testing.T) {
+	is := IS.New(t)
+	testCases := []struct {
+		size  string
+		bytes int64
+	}{
+		{"", 0},
+		{"1048576", 1 << 20},
+		{"512KB", 512 << 10},
+		{"10 MB", 10 << 20},
+		{"1gb", 1 << 30},
+		{"100B", 100},
+		{"8589934591GB", 8589934591 << 30},
+	}
+
+	for _, tc := range testCases {
+		bytes, err := parseFileSize(tc.size)
+		is.NoErr(err)
+		is.Equal(tc.bytes, bytes) // size: tc.size
+	}
+	for _, size := range []string{"MB", "-1KB", "10TB", "0", "9999999999999GB", "9223372036854775807KB"} {
+		_, err := parseFileSize(size)
+		is.True(err != nil) // size: size
+	}
+}
+
+func TestAnalyzePathMaxFileSize(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "dump.sql"), []byte(strings.Repeat("oldAPI\n", 200)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("oldAPI\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, backend := range []string{SearchBackendGrep, SearchBackendNative} {
+		t.Run(backend, func(t *testing.T) {
+			is := IS.New(t)
+			cfg := Config{SearchWords: []string{"oldAPI"}, MaxFileSize: "1KB", ListSkippedFiles: true, SearchBackend: backend}
+
+			app, err := analyzePath(context.Background(), Repository{Name: "repo"}, cfg, dir)
+
+			is.NoErr(err)
+			is.Equal(1, app.CountSum)
+			is.Equal([]string{"dump.sql"}, app.SkippedFiles)
+		})
+	}
+}
+
+func TestGrepMaxFileSizeSkipsLargeFiles(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, "dump.sql"), []byte(strings.Repeat("oldAPI\n", 200)), 0644))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "main.go"), []byte("oldAPI\n"), 0644))
+	argsFile := filepath.Join(t.TempDir(), "args")
+	fakeGrep := filepath.Join(t.TempDir(), "grep")
+	is.NoErr(os.WriteFile(fakeGrep, []byte("#!/bin/sh\necho \"$@\" > "+argsFile+"\nexit 1\n"), 0755))
+
+	_, err := grep(context.Background(), dir, []string{"oldAPI"}, grepOptions{Binary: fakeGrep, MaxFileSize: 1024})
+	is.NoErr(err)
+
+	args, err := os.ReadFile(argsFile)
+	is.NoErr(err)
+	is.True(strings.Contains(string(args), dir+"/main.go"))
+	is.True(!strings.Contains(string(args), "dump.sql")) // the large file is not read by grep
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// ScanState is saved to the state_file after every run, with the state of every repository by its url and ref
+type ScanState struct {
+	Repositories map[string]RepositoryState `json:"repositories"`
+}
+
+// RepositoryState is the head of the remote a repository was searched at and the application it was searched into
+type RepositoryState struct {
+	RemoteHead string `json:"remote_head"`
+	// Fingerprint is the hash of the config the repository was searched with, see configFingerprint
+	Fingerprint string      `json:"fingerprint"`
+	Application Application `json:"application"`
+}
+
+// remoteHeadFunc returns the SHA the ref of the repository points to on the remote
+type remoteHeadFunc = func(ctx context.Context, r Repository, cfg Config) (string, error)
+
+// scanState reuses the application of the previous run for the repositories which remote head did not change
+type scanState struct {
+	mu         sync.Mutex
+	previous   map[string]RepositoryState
+	current    map[string]RepositoryState
+	remoteHead remoteHeadFunc
+}
+
+// loadState reads the state saved by the previous run, a state file which does not exist yet is an empty state
+func loadState(fileName string) (*scanState, error) {
+	state := &scanState{previous: map[string]RepositoryState{}, current: map[string]RepositoryState{}, remoteHead: remoteHead}
+	data, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved ScanState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("unable to parse state file '%s': %w", fileName, err)
+	}
+	if saved.Repositories != nil {
+		state.previous = saved.Repositories
+	}
+	return state, nil
+}
+
+// skipping wraps analyze so a repository which remote head and config did not change since the previous run is not
+// cloned and searched again, the application of the previous run is returned instead
+func (s *scanState) skipping(analyze analyzeFunc) analyzeFunc {
+	return func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		if r.Path != "" {
+			// a local dir has no remote, it is searched every run
+			return analyze(ctx, r, cfg)
+		}
+		head, err := s.remoteHead(ctx, r, cfg)
+		if err != nil {
+			slog.Warn("unable to resolve the remote head, searching it again", "repository", r.Name, "error", redactError(err))
+			return analyze(ctx, r, cfg)
+		}
+		fingerprint := configFingerprint(r, cfg)
+
+		s.mu.Lock()
+		previous, ok := s.previous[cloneKey(r)]
+		s.mu.Unlock()
+		if ok && previous.RemoteHead == head && previous.Fingerprint == fingerprint {
+			slog.Info("unchanged since the previous run, reusing its result", "repository", r.Name)
+			s.record(r, previous)
+			return previous.Application, nil
+		}
+
+		app, err := analyze(ctx, r, cfg)
+		if err != nil {
+			return app, err
+		}
+		s.record(r, RepositoryState{RemoteHead: head, Fingerprint: fingerprint, Application: app})
+		return app, nil
+	}
+}
+
+func (s *scanState) record(r Repository, state RepositoryState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the clone is not kept for the next run, the subdirs are copied as they share the clone path
+	state.Application.ClonePath = ""
+	state.Application.Subdirs = append([]Application{}, state.Application.Subdirs...)
+	for i := range state.Application.Subdirs {
+		state.Application.Subdirs[i].ClonePath = ""
+	}
+	s.current[cloneKey(r)] = state
+}
+
+// save writes the state of the repositories, the previous state is kept for the repositories which were not searched
+// in this run, e.g. because they failed, and the repositories which are not in the config any more are left out
+func (s *scanState) save(fileName string, repos []Repository, perm os.FileMode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved := ScanState{Repositories: map[string]RepositoryState{}}
+	for _, r := range repos {
+		if state, ok := s.current[cloneKey(r)]; ok {
+			saved.Repositories[cloneKey(r)] = state
+		} else if state, ok := s.previous[cloneKey(r)]; ok {
+			saved.Repositories[cloneKey(r)] = state
+		}
+	}
+	data, err := json.MarshalIndent(saved, "", " ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fileName, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// configFingerprint hashes the repository together with the config, without the options which do not change the
+// result of a repository, so a repository is searched again when e.g. the search words change
+func configFingerprint(r Repository, cfg Config) string {
+	cfg.Repositories = nil
+	cfg.RepositoriesFile = ""
+	cfg.MaxConcurrency = 0
+	cfg.FailFast = false
+	cfg.KeepClones = false
+	cfg.AppendMode = false
+	cfg.CacheDir = ""
+	cfg.CacheMaxAge = ""
+	cfg.StateFile = ""
+	cfg.BaselineFile = ""
+	cfg.WebhookURL = ""
+	cfg.WebhookTimeout = ""
+	cfg.OutputFileMode = ""
+	data, _ := json.Marshal(struct {
+		Config      Config
+		WordOptions map[string]SearchWord
+		Repository  Repository
+	}{cfg, cfg.WordOptions, r})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// remoteHead resolves the ref of the repository, its default branch when no ref is set, with 'git ls-remote'.
+// A commit SHA is its own head.
+func remoteHead(ctx context.Context, r Repository, cfg Config) (string, error) {
+	if isCommitSHA(r.Ref) {
+		return r.Ref, nil
+	}
+	cmd := exec.CommandContext(ctx, cfg.gitBinary(), "ls-remote", cloneURL(r.Url, cfg.CloneProtocol), lsRemotePattern(r.Ref))
+	cmd.Env = gitAuthEnv(r, cfg)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git ls-remote: %w", err)
+	}
+	return parseLsRemote(out, r.Ref)
+}
+
+func lsRemotePattern(ref string) string {
+	if ref == "" {
+		return "HEAD"
+	}
+	return ref
+}
+
+// parseLsRemote returns the SHA of the ref in the output of 'git ls-remote', a branch is preferred over a tag
+// with the same name and the commit of an annotated tag over the tag itself
+func parseLsRemote(out []byte, ref string) (string, error) {
+	refs := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			refs[fields[1]] = fields[0]
+		}
+	}
+	candidates := []string{"HEAD"}
+	if ref != "" {
+		candidates = []string{"refs/heads/" + ref, "refs/tags/" + ref + "^{}", "refs/tags/" + ref, ref}
+	}
+	for _, candidate := range candidates {
+		if sha, ok := refs[candidate]; ok {
+			return sha, nil
+		}
+	}
+	return "", fmt.Errorf("ref '%s' not found on the remote", lsRemotePattern(ref))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	IS "github.com/matryer/is"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLsRemote(t *testing.T) {
+	is := IS.New(t)
+	out := []byte("1111111111111111111111111111111111111111\tHEAD\n" +
+		"1111111111111111111111111111111111111111\trefs/heads/main\n" +
+		"2222222222222222222222222222222222222222\trefs/tags/v2.0\n" +
+		"3333333333333333333333333333333333333333\trefs/tags/v2.0^{}\n")
+
+	head, err := parseLsRemote(out, "")
+	is.NoErr(err)
+	is.Equal("1111111111111111111111111111111111111111", head)
+	head, err = parseLsRemote(out, "v2.0")
+	is.NoErr(err)
+	is.Equal("3333333333333333333333333333333333333333", head) // the commit of the annotated tag
+	_, err = parseLsRemote(out, "develop")
+	is.True(err != nil)
+}
+
+func TestScanStateSkipping(t *testing.T) {
+	is := IS.New(t)
+	fileName := filepath.Join(t.TempDir(), "state.json")
+	repos := []Repository{{Name: "backend", Url: "https://github.com/akselleirv/introspect-backend.git"}}
+	cfg := Config{SearchWords: []string{"fell"}, Repositories: repos}
+	heads := map[string]string{"backend": "c1"}
+	analyzed := 0
+	analyze := func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		analyzed++
+		return Application{Name: r.Name, Status: StatusOK, CountSum: 3, Commit: heads[r.Name], WordCounts: map[string]int{"fell": 3}}, nil
+	}
+	runOnce := func(cfg Config) Application {
+		state, err := loadState(fileName)
+		is.NoErr(err)
+		state.remoteHead = func(ctx context.Context, r Repository, cfg Config) (string, error) {
+			return heads[r.Name], nil
+		}
+		app, err := state.skipping(analyze)(context.Background(), repos[0], cfg)
+		is.NoErr(err)
+		is.NoErr(state.save(fileName, repos, 0644))
+		return app
+	}
+
+	runOnce(cfg)
+	app := runOnce(cfg)
+	is.Equal(1, analyzed) // the unchanged repository is not searched again
+	is.Equal(3, app.CountSum)
+	is.Equal(map[string]int{"fell": 3}, app.WordCounts)
+
+	heads["backend"] = "c2"
+	runOnce(cfg)
+	is.Equal(2, analyzed) // the remote head changed
+
+	cfg.SearchWords = []string{"fell", "rose"}
+	runOnce(cfg)
+	is.Equal(3, analyzed) // the search words changed
+
+	cfg.MaxConcurrency = 4
+	runOnce(cfg)
+	is.Equal(3, analyzed)
+}
+
+func TestScanStateKeepsFailedRepositories(t *testing.T) {
+	is := IS.New(t)
+	fileName := filepath.Join(t.TempDir(), "state.json")
+	repos := []Repository{{Name: "backend", Url: "https://github.com/akselleirv/introspect-backend.git"}}
+	state, err := loadState(fileName)
+	is.NoErr(err)
+	state.previous[cloneKey(repos[0])] = RepositoryState{RemoteHead: "c1"}
+	state.remoteHead = func(ctx context.Context, r Repository, cfg Config) (string, error) {
+		return "c2", nil
+	}
+
+	_, err = state.skipping(func(ctx context.Context, r Repository, cfg Config) (Application, error) {
+		return Application{}, errors.New("unable to git clone")
+	})(context.Background(), repos[0], Config{})
+	is.True(err != nil)
+	is.NoErr(state.save(fileName, append(repos, Repository{Name: "frontend", Url: "https://github.com/akselleirv/introspect-frontend.git"}), 0644))
+
+	saved, err := loadState(fileName)
+	is.NoErr(err)
+	is.Equal(map[string]RepositoryState{cloneKey(repos[0]): {RemoteHead: "c1"}}, saved.previous)
+}
+
+func TestRemoteHead(t *testing.T) {
+	is := IS.New(t)
+	repo := newTestRepo(t, map[string]string{"main.go": "fell"})
+	commit, err := headCommit(context.Background(), DefaultGitBinary, repo)
+	is.NoErr(err)
+
+	head, err := remoteHead(context.Background(), Repository{Url: repo}, Config{})
+
+	is.NoErr(err)
+	is.Equal(commit, head)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// RepoStats are the timings and the size of a repository, to find the repositories which are slow to search
+type RepoStats struct {
+	CloneSeconds  float64 `json:"clone_seconds,omitempty"`
+	SearchSeconds float64 `json:"search_seconds"`
+	// SizeBytes is the size of the checkout on disk including the .git dir
+	SizeBytes int64 `json:"size_bytes,omitempty"`
+	// FileCount is the number of files which are searched
+	FileCount int `json:"file_count,omitempty"`
+}
+
+// diskUsage returns the size of the files in the dir including the .git dir, symlinks are not followed
+func diskUsage(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// addDiskUsage sets the size of the checkout at path in the stats of the application, a failure to measure it is
+// logged
+func addDiskUsage(app *Application, path string) {
+	if app.Stats == nil {
+		app.Stats = &RepoStats{}
+	}
+	size, err := diskUsage(path)
+	if err != nil {
+		slog.Warn("unable to measure the size of the checkout", "repository", app.Name, "error", err)
+		return
+	}
+	app.Stats.SizeBytes = size
+}
+
+// seconds returns the time since start in seconds
+func seconds(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
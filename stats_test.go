@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskUsage(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.MkdirAll(filepath.Join(dir, ".git", "objects"), 0755))
+	is.NoErr(os.MkdirAll(filepath.Join(dir, "src"), 0755))
+	is.NoErr(os.WriteFile(filepath.Join(dir, ".git", "objects", "pack"), make([]byte, 100), 0644))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "src", "main.go"), make([]byte, 10), 0644))
+	is.NoErr(os.WriteFile(filepath.Join(dir, ".gitignore"), make([]byte, 1), 0644))
+
+	size, err := diskUsage(dir)
+
+	is.NoErr(err)
+	is.Equal(int64(111), size)
+}
+
+func TestAnalyzeLocalRepoStats(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, "nordic.txt"), []byte("blåbær fell\n"), 0644))
+
+	app, err := analyzeLocalRepo(context.Background(), Repository{Name: "local", Path: dir}, Config{SearchWords: []string{"fell"}})
+	is.NoErr(err)
+	is.Equal(nil, app.Stats) // the stats are opt-in
+
+	app, err = analyzeLocalRepo(context.Background(), Repository{Name: "local", Path: dir}, Config{SearchWords: []string{"fell"}, Stats: true})
+	is.NoErr(err)
+	is.True(app.Stats != nil)
+	is.Equal(1, app.Stats.FileCount)
+	is.Equal(int64(len("blåbær fell\n")), app.Stats.SizeBytes)
+	is.True(app.Stats.SearchSeconds > 0)
+}
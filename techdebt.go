@@ -0,0 +1,35 @@
+package main
+
+import "sort"
+
+// TechDebt are the debt markers of the tech_debt preset found in one application
+type TechDebt struct {
+	Name  string `json:"name"`
+	Total int    `json:"total"`
+	// Markers is the count per debt marker, e.g. TODO
+	Markers map[string]int `json:"markers"`
+}
+
+// calculateTechDebt lists the debt markers per application with any of them, sorted on total descending and name
+func calculateTechDebt(rf ResultFile) []TechDebt {
+	var result []TechDebt
+	for _, app := range rf.Applications {
+		debt := TechDebt{Name: app.Name, Markers: make(map[string]int)}
+		for _, marker := range presets[PresetTechDebt] {
+			if count := app.WordCounts[marker.Pattern]; count > 0 {
+				debt.Markers[marker.Pattern] = count
+				debt.Total += count
+			}
+		}
+		if debt.Total > 0 {
+			result = append(result, debt)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
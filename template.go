@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+const FormatTemplate = "template"
+
+// loadTemplate parses the template file which renders the result in the template format
+func loadTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return nil, errors.New("the " + FormatTemplate + " format needs a template file given with -template")
+	}
+	tmpl, err := template.New(filepath.Base(path)).ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// writeTemplate renders the result with the template file, the result is the ResultFile of the json format
+func writeTemplate(fileName string, rf ResultFile, templatePath string, perm os.FileMode) error {
+	tmpl, err := loadTemplate(templatePath)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fileName, perm, func(w io.Writer) error {
+		return tmpl.Execute(w, rf)
+	})
+}
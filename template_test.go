@@ -0,0 +1,35 @@
+package main
+
+import (
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteTemplate(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "report.tmpl")
+	is.NoErr(os.WriteFile(templatePath, []byte("{{range .Applications}}{{.Name}}={{.CountSum}};{{end}}total={{.TotalCountSum}}\n"), 0644))
+	resultPath := filepath.Join(dir, "report.txt")
+	rf := ResultFile{TotalCountSum: 3, Applications: []Application{{Name: "a", CountSum: 1}, {Name: "b", CountSum: 2}}}
+
+	is.NoErr(writeTemplate(resultPath, rf, templatePath, DefaultOutputFileMode))
+
+	content, err := os.ReadFile(resultPath)
+	is.NoErr(err)
+	is.Equal("a=1;b=2;total=3\n", string(content))
+}
+
+func TestLoadTemplateErrors(t *testing.T) {
+	is := IS.New(t)
+	invalid := filepath.Join(t.TempDir(), "invalid.tmpl")
+	is.NoErr(os.WriteFile(invalid, []byte("{{range .Applications}"), 0644))
+
+	_, err := loadTemplate("")
+	is.True(err != nil)
+	_, err = loadTemplate(invalid)
+	is.True(err != nil)
+	is.True(validateOutputs([]resultOutput{{Path: "report.txt", Format: FormatTemplate}}, Config{}, "") != nil)
+}
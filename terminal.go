@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+)
+
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorReset = "\x1b[0m"
+)
+
+// useColor reports whether the file is a terminal which should be colored, NO_COLOR turns the colors off
+func useColor(file *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(file)
+}
+
+// DefaultTerminalWidth is the width of the terminal when COLUMNS is not set
+const DefaultTerminalWidth = 80
+
+// terminalWidth is the width of the terminal from COLUMNS when the shell exports it, or else DefaultTerminalWidth
+func terminalWidth() int {
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return DefaultTerminalWidth
+}
+
+// isTerminal reports whether the file is a terminal
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// countDelta formats the change of the count since the previous result, an increase is red and a decrease is green.
+// It is empty without a previous result.
+func countDelta(name string, count int, previous map[string]int, hasPrevious, color bool) string {
+	if !hasPrevious {
+		return ""
+	}
+	before, ok := previous[name]
+	if !ok {
+		return "new"
+	}
+	delta := count - before
+	switch {
+	case delta > 0 && color:
+		return colorRed + "+" + strconv.Itoa(delta) + colorReset
+	case delta > 0:
+		return "+" + strconv.Itoa(delta)
+	case delta < 0 && color:
+		return colorGreen + strconv.Itoa(delta) + colorReset
+	}
+	return strconv.Itoa(delta)
+}
+
+// printSummaryTable prints the applications with the largest count sum, all of them when top is 0, with the change
+// of their count sum since the previous result when it is given
+func printSummaryTable(w io.Writer, rf ResultFile, previous *ResultFile, top int, color bool) {
+	apps := append([]Application{}, rf.Applications...)
+	sort.SliceStable(apps, func(i, j int) bool { return apps[i].CountSum > apps[j].CountSum })
+	rest := 0
+	if top > 0 && len(apps) > top {
+		apps, rest = apps[:top], len(apps)-top
+	}
+	before := make(map[string]int)
+	if previous != nil {
+		for _, app := range previous.Applications {
+			before[app.Name] = app.CountSum
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "APPLICATION\tCOUNT\tDELTA")
+	for _, app := range apps {
+		if app.Status == StatusFailed {
+			fmt.Fprintf(tw, "%s\t%s\t\n", app.Name, StatusFailed)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", app.Name, app.CountSum, countDelta(app.Name, app.CountSum, before, previous != nil, color))
+	}
+	tw.Flush()
+	if rest > 0 {
+		fmt.Fprintf(w, "%d more applications are not shown\n", rest)
+	}
+	fmt.Fprintf(w, "total count sum %d in %d applications, %d failed\n", rf.TotalCountSum, rf.TotalApplications, rf.FailedApplications)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	IS "github.com/matryer/is"
+	"testing"
+)
+
+func TestPrintSummaryTable(t *testing.T) {
+	is := IS.New(t)
+	var out bytes.Buffer
+	rf := ResultFile{
+		TotalApplications:  4,
+		FailedApplications: 1,
+		TotalCountSum:      9,
+		Applications: []Application{
+			{Name: "fewer", Status: StatusOK, CountSum: 2},
+			{Name: "more", Status: StatusOK, CountSum: 5},
+			{Name: "added", Status: StatusOK, CountSum: 2},
+			{Name: "broken", Status: StatusFailed},
+		},
+	}
+	previous := ResultFile{Applications: []Application{{Name: "fewer", CountSum: 4}, {Name: "more", CountSum: 1}}}
+
+	printSummaryTable(&out, rf, &previous, 3, false)
+
+	is.Equal(`APPLICATION  COUNT  DELTA
+more         5      +4
+fewer        2      -2
+added        2      new
+1 more applications are not shown
+total count sum 9 in 4 applications, 1 failed
+`, out.String())
+}
+
+func TestCountDeltaColor(t *testing.T) {
+	is := IS.New(t)
+	previous := map[string]int{"a": 1}
+
+	is.Equal(colorRed+"+2"+colorReset, countDelta("a", 3, previous, true, true))
+	is.Equal(colorGreen+"-1"+colorReset, countDelta("a", 0, previous, true, true))
+	is.Equal("0", countDelta("a", 1, previous, true, true))
+	is.Equal("", countDelta("a", 3, nil, false, true))
+}
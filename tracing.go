@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultOTLPTimeout = 10 * time.Second
+
+// tracer collects the spans of a run, which are exported over OTLP when it is finished
+type tracer struct {
+	mu      sync.Mutex
+	traceID string
+	spans   []otlpSpan
+}
+
+// span is a phase of the run which is timed, a nil span is not traced
+type span struct {
+	tracer     *tracer
+	id         string
+	parentID   string
+	name       string
+	start      time.Time
+	attributes []otlpAttribute
+}
+
+type tracerKey struct{}
+
+type spanKey struct{}
+
+// otlpSpan is a finished span in the JSON encoding of OTLP
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	// Code is 1 when the span is ok and 2 when it failed
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// randomID returns n random bytes as hex, the ids of traces and spans
+func randomID(n int) string {
+	id := make([]byte, n)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func newTracer() *tracer {
+	return &tracer{traceID: randomID(16)}
+}
+
+// withTracer returns a context in which the spans are collected by t
+func withTracer(ctx context.Context, t *tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// startSpan starts a span as a child of the span in the context, the attributes are pairs of keys and values.
+// Without a tracer in the context the returned span is nil, which does nothing.
+func startSpan(ctx context.Context, name string, attributes ...string) (context.Context, *span) {
+	t, _ := ctx.Value(tracerKey{}).(*tracer)
+	if t == nil {
+		return ctx, nil
+	}
+	s := &span{tracer: t, id: randomID(8), name: name, start: time.Now()}
+	if parent, ok := ctx.Value(spanKey{}).(*span); ok {
+		s.parentID = parent.id
+	}
+	for i := 0; i+1 < len(attributes); i += 2 {
+		s.attributes = append(s.attributes, otlpAttribute{Key: attributes[i], Value: otlpValue{StringValue: attributes[i+1]}})
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// end finishes the span, it failed when err is not nil
+func (s *span) end(err error) {
+	if s == nil {
+		return
+	}
+	status := otlpStatus{Code: 1}
+	if err != nil {
+		status = otlpStatus{Code: 2, Message: err.Error()}
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, otlpSpan{
+		TraceID:           s.tracer.traceID,
+		SpanID:            s.id,
+		ParentSpanID:      s.parentID,
+		Name:              s.name,
+		Kind:              1,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(time.Now().UnixNano(), 10),
+		Attributes:        s.attributes,
+		Status:            status,
+	})
+}
+
+// export posts the finished spans to the OTLP/HTTP endpoint, e.g. http://localhost:4318 of an OpenTelemetry collector
+func (t *tracer) export(endpoint string) error {
+	t.mu.Lock()
+	spans := append([]otlpSpan{}, t.spans...)
+	t.mu.Unlock()
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []otlpAttribute{{Key: "service.name", Value: otlpValue{StringValue: "count-fell"}}},
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]string{"name": "count-fell"},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: DefaultOTLPTimeout}
+	resp, err := client.Post(strings.TrimSuffix(endpoint, "/")+"/v1/traces", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("otlp endpoint responded with status %s", resp.Status)
+	}
+	return nil
+}
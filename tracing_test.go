@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	IS "github.com/matryer/is"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStartSpanWithoutTracer(t *testing.T) {
+	is := IS.New(t)
+
+	ctx, s := startSpan(context.Background(), "search")
+
+	is.True(s == nil)
+	is.Equal(context.Background(), ctx)
+	s.end(nil) // a nil span does nothing
+}
+
+func TestSpans(t *testing.T) {
+	is := IS.New(t)
+	trace := newTracer()
+	ctx, root := startSpan(withTracer(context.Background(), trace), "run")
+
+	_, child := startSpan(ctx, "clone", "repository", "a")
+	child.end(errors.New("unable to clone"))
+	root.end(nil)
+
+	is.Equal(2, len(trace.spans))
+	clone, run := trace.spans[0], trace.spans[1]
+	is.Equal(32, len(clone.TraceID))
+	is.Equal(clone.TraceID, run.TraceID)
+	is.Equal(run.SpanID, clone.ParentSpanID)
+	is.Equal("", run.ParentSpanID)
+	is.Equal([]otlpAttribute{{Key: "repository", Value: otlpValue{StringValue: "a"}}}, clone.Attributes)
+	is.Equal(otlpStatus{Code: 2, Message: "unable to clone"}, clone.Status)
+	is.Equal(otlpStatus{Code: 1}, run.Status)
+}
+
+func TestExportSpans(t *testing.T) {
+	is := IS.New(t)
+	var path string
+	var body struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []otlpSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		content, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(content, &body)
+	}))
+	defer server.Close()
+	trace := newTracer()
+	_, s := startSpan(withTracer(context.Background(), trace), "run")
+	s.end(nil)
+
+	is.NoErr(trace.export(server.URL))
+
+	is.Equal("/v1/traces", path)
+	is.Equal("run", body.ResourceSpans[0].ScopeSpans[0].Spans[0].Name)
+}
+
+func TestParseSpanFailed(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, "main.go"), []byte("fell"), 0644))
+	trace := newTracer()
+	cfg := Config{SearchWords: []string{"fell"}, ExcludePatterns: []string{"("}}
+
+	_, err := analyzePath(withTracer(context.Background(), trace), Repository{Name: "repo"}, cfg, dir)
+
+	is.True(err != nil)
+	parse := trace.spans[len(trace.spans)-1]
+	is.Equal("parse", parse.Name)
+	is.Equal(2, parse.Status.Code)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const (
+	CloneProtocolAsIs  = "as-is"
+	CloneProtocolHTTPS = "https"
+	CloneProtocolSSH   = "ssh"
+)
+
+var (
+	httpsURLPattern = regexp.MustCompile(`^https://(?:[^@/]+@)?([^/:]+)/(.+?)(?:\.git)?/?$`)
+	scpURLPattern   = regexp.MustCompile(`^(?:[^@/]+@)?([^/:]+):([^/].*?)(?:\.git)?$`)
+	sshURLPattern   = regexp.MustCompile(`^ssh://(?:[^@/]+@)?([^/:]+)(?::\d+)?/(.+?)(?:\.git)?/?$`)
+)
+
+// validateCloneProtocol checks that the protocol is one of the supported clone protocols, empty means as-is
+func validateCloneProtocol(protocol string) error {
+	switch protocol {
+	case "", CloneProtocolAsIs, CloneProtocolHTTPS, CloneProtocolSSH:
+		return nil
+	}
+	return fmt.Errorf("unknown clone_protocol '%s', must be one of %s, %s or %s", protocol, CloneProtocolAsIs, CloneProtocolHTTPS, CloneProtocolSSH)
+}
+
+// cloneURL rewrites the url to the protocol, urls which can not be rewritten are returned unchanged with a warning
+func cloneURL(url, protocol string) string {
+	if protocol == "" || protocol == CloneProtocolAsIs {
+		return url
+	}
+	rewritten, ok := rewriteURL(url, protocol)
+	if !ok {
+		slog.Warn("unable to rewrite url, cloning it as is", "url", redactCredentials(url), "clone_protocol", protocol)
+		return url
+	}
+	return rewritten
+}
+
+// rewriteURL rewrites https urls like https://github.com/org/repo.git and ssh urls like git@github.com:org/repo.git
+// or ssh://git@github.com/org/repo.git to the given protocol
+func rewriteURL(url, protocol string) (string, bool) {
+	host, repoPath, ok := splitURL(url)
+	if !ok {
+		return "", false
+	}
+	switch protocol {
+	case CloneProtocolHTTPS:
+		return "https://" + host + "/" + repoPath + ".git", true
+	case CloneProtocolSSH:
+		return "git@" + host + ":" + repoPath + ".git", true
+	}
+	return "", false
+}
+
+// splitURL returns the host and the path of the repository, without the .git suffix
+func splitURL(url string) (string, string, bool) {
+	if strings.HasPrefix(url, "https://") {
+		return matchURL(httpsURLPattern, url)
+	}
+	if strings.HasPrefix(url, "ssh://") {
+		return matchURL(sshURLPattern, url)
+	}
+	if strings.Contains(url, "://") {
+		return "", "", false
+	}
+	return matchURL(scpURLPattern, url)
+}
+
+func matchURL(pattern *regexp.Regexp, url string) (string, string, bool) {
+	match := pattern.FindStringSubmatch(url)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
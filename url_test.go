@@ -0,0 +1,44 @@
+package main
+
+import (
+	IS "github.com/matryer/is"
+	"testing"
+)
+
+func TestRewriteURL(t *testing.T) {
+	is := IS.New(t)
+	testCases := []struct {
+		url      string
+		protocol string
+		expected string
+	}{
+		{"https://github.com/akselleirv/introspect-backend.git", CloneProtocolSSH, "git@github.com:akselleirv/introspect-backend.git"},
+		{"https://github.com/akselleirv/introspect-backend", CloneProtocolSSH, "git@github.com:akselleirv/introspect-backend.git"},
+		{"https://gitlab.com/group/subgroup/project.git", CloneProtocolSSH, "git@gitlab.com:group/subgroup/project.git"},
+		{"git@github.com:akselleirv/introspect-backend.git", CloneProtocolHTTPS, "https://github.com/akselleirv/introspect-backend.git"},
+		{"ssh://git@bitbucket.org/team/repo.git", CloneProtocolHTTPS, "https://bitbucket.org/team/repo.git"},
+		{"git@github.com:akselleirv/introspect-backend.git", CloneProtocolSSH, "git@github.com:akselleirv/introspect-backend.git"},
+	}
+
+	for _, tc := range testCases {
+		rewritten, ok := rewriteURL(tc.url, tc.protocol)
+		is.True(ok)
+		is.Equal(tc.expected, rewritten)
+	}
+}
+
+func TestCloneURLLeavesUnrecognizedURLs(t *testing.T) {
+	is := IS.New(t)
+
+	is.Equal("/srv/git/repo", cloneURL("/srv/git/repo", CloneProtocolSSH))
+	is.Equal("file:///srv/git/repo", cloneURL("file:///srv/git/repo", CloneProtocolHTTPS))
+	is.Equal("https://github.com/org/repo.git", cloneURL("https://github.com/org/repo.git", CloneProtocolAsIs))
+}
+
+func TestValidateCloneProtocol(t *testing.T) {
+	is := IS.New(t)
+
+	is.NoErr(validateCloneProtocol(""))
+	is.NoErr(validateCloneProtocol(CloneProtocolSSH))
+	is.True(validateCloneProtocol("ftp") != nil)
+}
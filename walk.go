@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxListedPaths is the most files passed to a single grep or rg command, so its arguments stay below the limit of
+// the OS
+const maxListedPaths = 1000
+
+// searchedFiles returns the regular files in root grep searches with the options, relative to root.
+// The dirs in the paths of the options are walked, the files in them are returned as they are.
+func searchedFiles(root string, opts grepOptions) ([]string, error) {
+	excludeDirs := opts.ExcludeDirs
+	if !opts.ScanGitDir {
+		excludeDirs = append([]string{GitDir}, excludeDirs...)
+	}
+	w := fileWalker{root: root, excludeDirs: excludeDirs, followSymlinks: opts.FollowSymlinks}
+	if w.followSymlinks {
+		realRoot, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			return nil, err
+		}
+		w.realRoot = realRoot
+	}
+	var err error
+	if len(opts.Paths) > 0 {
+		err = w.walkPaths(opts.Paths)
+	} else {
+		err = w.walk("")
+	}
+	files, _ := splitLargeFiles(root, filterExcludedFiles(filterGlobs(w.files, opts.IncludeGlobs), opts.ExcludeFiles), opts.MaxFileSize)
+	return files, err
+}
+
+// searchListed searches the files in chunks of at most maxListedPaths with search, which is given the files in the
+// paths of the options
+func searchListed(files []string, opts grepOptions, search func(grepOptions) ([]GrepResult, error)) ([]GrepResult, error) {
+	results := []GrepResult{}
+	for start := 0; start < len(files); start += maxListedPaths {
+		end := start + maxListedPaths
+		if end > len(files) {
+			end = len(files)
+		}
+		opts.Paths = files[start:end]
+		grs, err := search(opts)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, grs...)
+	}
+	return results, nil
+}
+
+// fileWalker collects the regular files below root in lexical order.
+// Symlinks are skipped like 'grep --recursive' does, unless followSymlinks is set. Followed symlinks must point inside
+// root, so a repository can not have files outside of it searched.
+type fileWalker struct {
+	root           string
+	excludeDirs    []string
+	followSymlinks bool
+	// realRoot is root with its symlinks resolved, set when followSymlinks is
+	realRoot string
+	// parents are the dirs being walked, a symlink back to one of them is a loop
+	parents []os.FileInfo
+	files   []string
+}
+
+// walkPaths collects the files given relative to root, the dirs among them are walked
+func (w *fileWalker) walkPaths(paths []string) error {
+	for _, name := range paths {
+		info, err := os.Lstat(filepath.Join(w.root, filepath.FromSlash(name)))
+		if err != nil {
+			// a file which does not exist, e.g. deleted since it changed, has nothing to search
+			continue
+		}
+		if err := w.visit(name, info.Mode().Type()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insideRoot reports whether the symlink points to a file or dir inside root
+func (w *fileWalker) insideRoot(fullPath string) bool {
+	target, err := filepath.EvalSymlinks(fullPath)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(w.realRoot, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (w *fileWalker) walk(dir string) error {
+	fullPath := filepath.Join(w.root, filepath.FromSlash(dir))
+	if w.followSymlinks {
+		info, err := os.Stat(fullPath)
+		if err != nil {
+			return err
+		}
+		for _, parent := range w.parents {
+			if os.SameFile(parent, info) {
+				slog.Warn("skipping symlink loop", "path", fullPath)
+				return nil
+			}
+		}
+		w.parents = append(w.parents, info)
+		defer func() { w.parents = w.parents[:len(w.parents)-1] }()
+	}
+
+	entries, err := os.ReadDir(fullPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := w.visit(path.Join(dir, entry.Name()), entry.Type()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visit collects the file or walks the dir with the name relative to root and the type of mode
+func (w *fileWalker) visit(name string, mode os.FileMode) error {
+	if mode&os.ModeSymlink != 0 {
+		if !w.followSymlinks {
+			return nil
+		}
+		fullPath := filepath.Join(w.root, filepath.FromSlash(name))
+		info, err := os.Stat(fullPath)
+		if err != nil {
+			// a dangling symlink has nothing to search
+			return nil
+		}
+		if !w.insideRoot(fullPath) {
+			slog.Warn("skipping symlink pointing outside the repository", "path", fullPath)
+			return nil
+		}
+		mode = info.Mode().Type()
+	}
+	switch {
+	case mode.IsDir():
+		if excludedDir(w.excludeDirs, name) {
+			return nil
+		}
+		return w.walk(name)
+	case mode.IsRegular():
+		w.files = append(w.files, name)
+	}
+	return nil
+}
+
+func filterGlobs(files, globs []string) []string {
+	if len(globs) == 0 {
+		return files
+	}
+	var result []string
+	for _, file := range files {
+		if matchAnyGlob(globs, file) {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// filterExcludedFiles removes the files matching one of the exclude file globs
+func filterExcludedFiles(files, globs []string) []string {
+	if len(globs) == 0 {
+		return files
+	}
+	var result []string
+	for _, file := range files {
+		if !matchAnyGlob(globs, file) {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// countLines counts the lines of the text files, relative to root
+func countLines(root string, files []string) (int, error) {
+	var lines int
+	buf := make([]byte, 32*1024)
+	for _, file := range files {
+		n, err := countFileLines(filepath.Join(root, file), buf)
+		if err != nil {
+			return 0, err
+		}
+		lines += n
+	}
+	return lines, nil
+}
+
+// countFileLines counts the lines of the file, a last line without a line break is counted as well.
+// Binary files are not counted, the same way grep does not report matches in them.
+func countFileLines(fileName string, buf []byte) (int, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var lines int
+	var last byte
+	first := true
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			if first && isBinary(buf[:n]) {
+				return 0, nil
+			}
+			first = false
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if !first && last != '\n' {
+		lines++
+	}
+	return lines, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCountLines(t *testing.T) {
+	is := IS.New(t)
+	files, err := searchedFiles("./testdata", grepOptions{ExcludeDirs: []string{"encoding"}})
+	is.NoErr(err)
+	is.Equal([]string{"testdata_1.txt", "testdata_2.txt"}, files)
+
+	lines, err := countLines("./testdata", files)
+
+	is.NoErr(err)
+	is.Equal(5, lines) // testdata_1.txt is one line without a line break, testdata_2.txt has four lines
+}
+
+func TestSearchedFilesIncludeGlobs(t *testing.T) {
+	is := IS.New(t)
+
+	files, err := searchedFiles("./testdata", grepOptions{IncludeGlobs: []string{"encoding/*.txt"}})
+
+	is.NoErr(err)
+	is.Equal([]string{"encoding/invalid.txt", "encoding/valid.txt"}, files)
+}
+
+func TestSearchedFilesSymlinkedDir(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.MkdirAll(filepath.Join(dir, "shared"), 0755))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "shared", "words.txt"), []byte("fell"), 0644))
+	is.NoErr(os.Mkdir(filepath.Join(dir, "service"), 0755))
+	is.NoErr(os.Symlink(filepath.Join("..", "shared"), filepath.Join(dir, "service", "shared")))
+
+	files, err := searchedFiles(dir, grepOptions{})
+	is.NoErr(err)
+	is.Equal([]string{"shared/words.txt"}, files)
+
+	files, err = searchedFiles(dir, grepOptions{FollowSymlinks: true})
+	is.NoErr(err)
+	is.Equal([]string{"service/shared/words.txt", "shared/words.txt"}, files)
+}
+
+func TestSearchedFilesSymlinkLoop(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.Mkdir(filepath.Join(dir, "a"), 0755))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "a", "words.txt"), []byte("fell"), 0644))
+	is.NoErr(os.Symlink("..", filepath.Join(dir, "a", "loop")))
+
+	files, err := searchedFiles(dir, grepOptions{FollowSymlinks: true})
+
+	is.NoErr(err)
+	is.Equal([]string{"a/words.txt"}, files)
+}
+
+func TestGrepFollowSymlinks(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	is.NoErr(os.Mkdir(filepath.Join(dir, "a"), 0755))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "a", "words.txt"), []byte("fell"), 0644))
+	is.NoErr(os.Symlink("a", filepath.Join(dir, "b")))
+
+	result, err := grep(context.Background(), dir, []string{"fell"}, grepOptions{})
+	is.NoErr(err)
+	is.Equal(1, sumTotalCountForGrepResults(result))
+
+	result, err = grep(context.Background(), dir, []string{"fell"}, grepOptions{FollowSymlinks: true})
+	is.NoErr(err)
+	is.Equal(2, sumTotalCountForGrepResults(result))
+}
+
+func TestSearchedFilesSymlinkOutsideRoot(t *testing.T) {
+	is := IS.New(t)
+	outside := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("fell"), 0644))
+	dir := t.TempDir()
+	is.NoErr(os.WriteFile(filepath.Join(dir, "words.txt"), []byte("fell"), 0644))
+	is.NoErr(os.Symlink(outside, filepath.Join(dir, "home")))
+	is.NoErr(os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "secret.txt")))
+
+	files, err := searchedFiles(dir, grepOptions{FollowSymlinks: true})
+	is.NoErr(err)
+	is.Equal([]string{"words.txt"}, files)
+
+	files, err = searchedFiles(dir, grepOptions{FollowSymlinks: true, Paths: []string{"home", "secret.txt", "words.txt"}})
+	is.NoErr(err)
+	is.Equal([]string{"words.txt"}, files)
+
+	result, err := grep(context.Background(), dir, []string{"fell"}, grepOptions{FollowSymlinks: true})
+	is.NoErr(err)
+	is.Equal(1, sumTotalCountForGrepResults(result))
+
+	result, err = grepConcurrent(context.Background(), dir, []string{"fell"}, grepOptions{FollowSymlinks: true}, 4)
+	is.NoErr(err)
+	is.Equal(1, sumTotalCountForGrepResults(result))
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const DefaultWebhookTimeout = 10 * time.Second
+
+// WebhookSummary is posted to the webhook url when a run is finished
+type WebhookSummary struct {
+	TotalApplications int       `json:"total_applications"`
+	TotalCountSum     int       `json:"total_count_sum"`
+	Failures          int       `json:"failures"`
+	Timestamp         time.Time `json:"timestamp"`
+}
+
+// notifyWebhook posts the summary to the url, it is retried once when it fails
+func notifyWebhook(url string, timeout time.Duration, summary WebhookSummary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: timeout}
+
+	err = postWebhook(client, url, body)
+	if err != nil {
+		slog.Warn("retrying webhook after error", "error", err)
+		err = postWebhook(client, url, body)
+	}
+	return err
+}
+
+func postWebhook(client *http.Client, url string, body []byte) error {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// webhookTimeout parses the configured webhook timeout, DefaultWebhookTimeout is used when it is not set
+func webhookTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return DefaultWebhookTimeout, nil
+	}
+	return time.ParseDuration(timeout)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	IS "github.com/matryer/is"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotifyWebhook(t *testing.T) {
+	is := IS.New(t)
+	summary := WebhookSummary{
+		TotalApplications: 3,
+		TotalCountSum:     42,
+		Failures:          1,
+		Timestamp:         time.Date(2021, 6, 1, 2, 0, 0, 0, time.UTC),
+	}
+	var received WebhookSummary
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(http.MethodPost, r.Method)
+		is.Equal("application/json", r.Header.Get("Content-Type"))
+		is.NoErr(json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	is.NoErr(notifyWebhook(server.URL, time.Second, summary))
+
+	is.Equal(summary, received)
+}
+
+func TestNotifyWebhookRetriesOnce(t *testing.T) {
+	is := IS.New(t)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := notifyWebhook(server.URL, time.Second, WebhookSummary{})
+
+	is.True(err != nil)
+	is.Equal(int32(2), atomic.LoadInt32(&requests)) // the first request and exactly one retry
+}
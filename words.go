@@ -0,0 +1,132 @@
+package main
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// WordCoverage is in how many applications a search word was found and its count sum
+type WordCoverage struct {
+	Word  string `json:"word"`
+	Repos int    `json:"repos"`
+	Count int    `json:"count"`
+}
+
+// attributeWords replaces the matched texts in the words of the results with the search word which matched them.
+// A match no search word can be found for, e.g. because the search word uses grep specific regexp syntax,
+// is kept as the lower cased matched text, or as is when caseSensitive.
+func attributeWords(grs []GrepResult, searchWords []string, caseSensitive bool) []GrepResult {
+	patterns := wordPatterns(searchWords, caseSensitive)
+	for i, gr := range grs {
+		words := make(map[string]int)
+		for match, count := range gr.Words {
+			words[matchingWord(match, searchWords, patterns, caseSensitive)] += count
+		}
+		grs[i].Words = words
+	}
+	return grs
+}
+
+// wordPatterns compiles the search words to match a whole matched text, a search word which is not a valid Go regexp
+// is nil
+func wordPatterns(searchWords []string, caseSensitive bool) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(searchWords))
+	for i, word := range searchWords {
+		patterns[i], _ = regexp.Compile(caseFlag(caseSensitive) + "^(?:" + word + ")$")
+	}
+	return patterns
+}
+
+func matchingWord(match string, searchWords []string, patterns []*regexp.Regexp, caseSensitive bool) string {
+	for _, word := range searchWords {
+		if match == word || (!caseSensitive && strings.EqualFold(match, word)) {
+			return word
+		}
+	}
+	for i, pattern := range patterns {
+		if pattern != nil && pattern.MatchString(match) {
+			return searchWords[i]
+		}
+	}
+	if caseSensitive {
+		return match
+	}
+	return strings.ToLower(match)
+}
+
+// caseFlag returns the regexp flag for matching case-insensitive unless caseSensitive
+func caseFlag(caseSensitive bool) string {
+	if caseSensitive {
+		return ""
+	}
+	return "(?i)"
+}
+
+// dedupeSearchWords removes search words which only differ in case from an earlier search word of the same
+// searchWordGroup which is not case sensitive, as they would otherwise be reported separately for the same matches
+func dedupeSearchWords(cfg Config) []string {
+	// groupKey are the options of a searchWordGroup besides its words and case sensitivity
+	type groupKey struct {
+		WordBoundary, FixedStrings bool
+		RegexEngine                string
+	}
+	var result []string
+	seen := make(map[groupKey]map[string]string)
+	for _, word := range cfg.SearchWords {
+		group := cfg.wordGroup(word)
+		if group.CaseSensitive {
+			result = append(result, word)
+			continue
+		}
+		key := groupKey{WordBoundary: group.WordBoundary, FixedStrings: group.FixedStrings, RegexEngine: group.RegexEngine}
+		if seen[key] == nil {
+			seen[key] = make(map[string]string)
+		}
+		if canonical, ok := seen[key][strings.ToLower(word)]; ok {
+			slog.Warn("search word is the same as another when ignoring case, only the other is searched", "word", word, "searched", canonical)
+			continue
+		}
+		seen[key][strings.ToLower(word)] = word
+		result = append(result, word)
+	}
+	return result
+}
+
+// sumWordCounts sums the counts of the results per search word
+func sumWordCounts(grs []GrepResult) map[string]int {
+	result := make(map[string]int)
+	for _, gr := range grs {
+		for word, count := range gr.Words {
+			result[word] += count
+		}
+	}
+	return result
+}
+
+// calculateWordTotals sums the counts of all applications per search word
+func calculateWordTotals(rf ResultFile) map[string]int {
+	result := make(map[string]int)
+	for _, app := range rf.Applications {
+		for word, count := range app.WordCounts {
+			result[word] += count
+		}
+	}
+	return result
+}
+
+// calculateWordCoverage counts for each search word in how many applications it was found and its count sum
+func calculateWordCoverage(rf ResultFile) []WordCoverage {
+	var result []WordCoverage
+	for _, word := range rf.SearchWords {
+		coverage := WordCoverage{Word: word}
+		for _, app := range rf.Applications {
+			if count := app.WordCounts[word]; count > 0 {
+				coverage.Repos++
+				coverage.Count += count
+			}
+		}
+		result = append(result, coverage)
+	}
+	return result
+}
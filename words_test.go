@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	IS "github.com/matryer/is"
+	"testing"
+)
+
+func TestAttributeWords(t *testing.T) {
+	is := IS.New(t)
+	grs := []GrepResult{
+		{FileName: "main.go", Count: 4, Words: map[string]int{"fell": 1, "user": 2, "users": 1}},
+	}
+
+	result := attributeWords(grs, []string{"FELL", "use[rs]*"}, false)
+
+	is.Equal(map[string]int{"FELL": 1, "use[rs]*": 3}, result[0].Words)
+}
+
+func TestCalculateWordCoverage(t *testing.T) {
+	is := IS.New(t)
+	result := ResultFile{
+		SearchWords: []string{"cmd", "use", "fell"},
+		Applications: []Application{
+			{Name: "backend", WordCounts: map[string]int{"cmd": 3, "use": 5}},
+			{Name: "frontend", WordCounts: map[string]int{"use": 2}},
+		},
+	}
+
+	coverage := calculateWordCoverage(result)
+
+	is.Equal([]WordCoverage{
+		{Word: "cmd", Repos: 1, Count: 3},
+		{Word: "use", Repos: 2, Count: 7},
+		{Word: "fell", Repos: 0, Count: 0},
+	}, coverage)
+}
+
+func TestCalculateWordTotals(t *testing.T) {
+	is := IS.New(t)
+	result := ResultFile{
+		Applications: []Application{
+			{Name: "backend", WordCounts: map[string]int{"cmd": 3, "use": 5}},
+			{Name: "frontend", WordCounts: map[string]int{"use": 2}},
+			{Name: "failed", Status: StatusFailed},
+		},
+	}
+
+	is.Equal(map[string]int{"cmd": 3, "use": 7}, calculateWordTotals(result))
+}
+
+func TestWordCountsInResult(t *testing.T) {
+	is := IS.New(t)
+	app := Application{Name: "backend", WordCounts: map[string]int{"cmd": 3}, GrepResults: []GrepResult{
+		{FileName: "main.go", Count: 3, Words: map[string]int{"cmd": 3}},
+	}}
+
+	data, err := json.Marshal(ResultFile{WordTotals: map[string]int{"cmd": 3}, Applications: []Application{app}})
+	is.NoErr(err)
+	var result ResultFile
+	is.NoErr(json.Unmarshal(data, &result))
+
+	is.Equal(map[string]int{"cmd": 3}, result.WordTotals)
+	is.Equal(app.WordCounts, result.Applications[0].WordCounts)
+	is.Equal(app.GrepResults[0].Words, result.Applications[0].GrepResults[0].Words)
+}
+
+func TestGrepCountsPerWord(t *testing.T) {
+	is := IS.New(t)
+
+	result, err := grepConcurrent(context.Background(), "./testdata", []string{"fell", "needle"}, grepOptions{}, 1)
+	is.NoErr(err)
+
+	is.Equal(map[string]int{"fell": 6, "needle": 4}, sumWordCounts(result))
+}
+
+func TestDedupeSearchWords(t *testing.T) {
+	is := IS.New(t)
+
+	is.Equal([]string{"TODO", "fell"}, dedupeSearchWords(Config{SearchWords: []string{"TODO", "fell", "todo", "Todo"}}))
+	is.Equal([]string{"TODO", "fell", "todo"}, dedupeSearchWords(Config{SearchWords: []string{"TODO", "fell", "todo"}, CaseSensitive: true}))
+}
+
+func TestDedupeSearchWordsOfOtherGroups(t *testing.T) {
+	is := IS.New(t)
+	caseSensitive, wordBoundary := true, true
+	cfg := Config{SearchWords: []string{"foo", "Foo", "FOO", "fOO"}, WordOptions: map[string]SearchWord{
+		"Foo": {Pattern: "Foo", CaseSensitive: &caseSensitive},
+		"FOO": {Pattern: "FOO", WordBoundary: &wordBoundary},
+	}}
+
+	is.Equal([]string{"foo", "Foo", "FOO"}, dedupeSearchWords(cfg))
+}
+
+func TestAttributeWordsCaseSensitive(t *testing.T) {
+	is := IS.New(t)
+	grs := []GrepResult{
+		{FileName: "main.go", Count: 3, Words: map[string]int{"TODO": 2, "todo": 1}},
+	}
+
+	result := attributeWords(grs, []string{"TODO", "todo"}, true)
+
+	is.Equal(map[string]int{"TODO": 2, "todo": 1}, result[0].Words)
+}
+
+func TestGroupCounts(t *testing.T) {
+	is := IS.New(t)
+	cfg := Config{WordOptions: map[string]SearchWord{"oldAPI": {Group: "deprecated_apis"}, "legacy": {Group: "deprecated_apis"}, "log.Printf": {Group: "legacy_logging"}}}
+
+	counts := cfg.groupCounts(map[string]int{"oldAPI": 2, "legacy": 1, "log.Printf": 4, "fell": 3})
+
+	is.Equal(map[string]int{"deprecated_apis": 3, "legacy_logging": 4}, counts)
+	is.Equal(map[string]int(nil), Config{}.groupCounts(map[string]int{"fell": 3}))
+	is.Equal(map[string]int{"deprecated_apis": 4}, calculateGroupTotals(ResultFile{Applications: []Application{
+		{GroupCounts: map[string]int{"deprecated_apis": 3}}, {GroupCounts: map[string]int{"deprecated_apis": 1}}, {},
+	}}))
+}
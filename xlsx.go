@@ -0,0 +1,209 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatXLSX = "xlsx"
+
+	// maxSheetNameLength is the longest name of a sheet Excel accepts
+	maxSheetNameLength = 31
+	summarySheetName   = "Summary"
+)
+
+// xlsxCell is a cell of a sheet, a number when IsNumber is set and otherwise text
+type xlsxCell struct {
+	Text     string
+	Number   int
+	IsNumber bool
+}
+
+func textCell(text string) xlsxCell { return xlsxCell{Text: text} }
+
+func numberCell(n int) xlsxCell { return xlsxCell{Number: n, IsNumber: true} }
+
+// xlsxSheet is a sheet of the workbook, the first row is its header
+type xlsxSheet struct {
+	Name string
+	Rows [][]xlsxCell
+}
+
+// sortedWordKeys returns the search words of the counts sorted
+func sortedWordKeys(counts ...map[string]int) []string {
+	var words []string
+	for _, c := range counts {
+		for word := range c {
+			words = append(words, word)
+		}
+	}
+	return sortedUnique(words)
+}
+
+// xlsxSheets returns a summary sheet with a row per application followed by a sheet per application with a row per
+// file, both with a column per search word
+func xlsxSheets(rf ResultFile) []xlsxSheet {
+	words := sortedWordKeys(rf.WordTotals)
+	header := []xlsxCell{textCell("Application"), textCell("Status"), textCell("Count sum")}
+	for _, word := range words {
+		header = append(header, textCell(word))
+	}
+	summary := xlsxSheet{Name: summarySheetName, Rows: [][]xlsxCell{header}}
+	used := map[string]bool{strings.ToLower(summarySheetName): true}
+	var sheets []xlsxSheet
+	for _, app := range rf.Applications {
+		row := []xlsxCell{textCell(app.Name), textCell(app.Status), numberCell(app.CountSum)}
+		for _, word := range words {
+			row = append(row, numberCell(app.WordCounts[word]))
+		}
+		summary.Rows = append(summary.Rows, row)
+
+		var fileWords []map[string]int
+		for _, gr := range app.GrepResults {
+			fileWords = append(fileWords, gr.Words)
+		}
+		appWords := sortedWordKeys(fileWords...)
+		header := []xlsxCell{textCell("File"), textCell("Count")}
+		for _, word := range appWords {
+			header = append(header, textCell(word))
+		}
+		sheet := xlsxSheet{Name: sheetName(app.Name, used), Rows: [][]xlsxCell{header}}
+		for _, gr := range app.GrepResults {
+			row := []xlsxCell{textCell(gr.FileName), numberCell(gr.Count)}
+			for _, word := range appWords {
+				row = append(row, numberCell(gr.Words[word]))
+			}
+			sheet.Rows = append(sheet.Rows, row)
+		}
+		sheets = append(sheets, sheet)
+	}
+	return append([]xlsxSheet{summary}, sheets...)
+}
+
+// sheetName makes the application name a valid sheet name which is not used yet: without the characters Excel does
+// not accept, at most 31 characters long and unique regardless of case
+func sheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "_"
+	}
+	candidate := name
+	for i := 2; ; i++ {
+		if runes := []rune(candidate); len(runes) > maxSheetNameLength {
+			candidate = string(runes[:maxSheetNameLength])
+		}
+		if !used[strings.ToLower(candidate)] {
+			break
+		}
+		suffix := fmt.Sprintf(" (%d)", i)
+		runes := []rune(name)
+		if len(runes)+len(suffix) > maxSheetNameLength {
+			runes = runes[:maxSheetNameLength-len(suffix)]
+		}
+		candidate = string(runes) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// columnName returns the name of the column in a cell reference, A for 0, Z for 25 and AA for 26
+func columnName(column int) string {
+	name := ""
+	for column >= 0 {
+		name = string(rune('A'+column%26)) + name
+		column = column/26 - 1
+	}
+	return name
+}
+
+func xmlEscape(text string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// sheetXML renders the sheet as SpreadsheetML, the text is stored in inline strings
+func sheetXML(sheet xlsxSheet) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range sheet.Rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, cell := range row {
+			ref := columnName(j) + strconv.Itoa(i+1)
+			if cell.IsNumber {
+				fmt.Fprintf(&b, `<c r="%s"><v>%d</v></c>`, ref, cell.Number)
+			} else {
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, xmlEscape(cell.Text))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// writeXLSXWorkbook writes the sheets as an xlsx workbook, which is a zip of SpreadsheetML files
+func writeXLSXWorkbook(w io.Writer, sheets []xlsxSheet) error {
+	files := map[string]string{}
+	var contentTypes, workbook, rels strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, sheet := range sheets {
+		n := i + 1
+		files[fmt.Sprintf("xl/worksheets/sheet%d.xml", n)] = sheetXML(sheet)
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheet.Name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	rels.WriteString(`</Relationships>`)
+	files["[Content_Types].xml"] = contentTypes.String()
+	files["_rels/.rels"] = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	files["xl/workbook.xml"] = workbook.String()
+	files["xl/_rels/workbook.xml.rels"] = rels.String()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	archive := zip.NewWriter(w)
+	for _, name := range names {
+		f, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, files[name]); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func writeXLSX(fileName string, rf ResultFile, perm os.FileMode) error {
+	return writeFileAtomic(fileName, perm, func(w io.Writer) error {
+		return writeXLSXWorkbook(w, xlsxSheets(rf))
+	})
+}
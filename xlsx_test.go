@@ -0,0 +1,82 @@
+package main
+
+import (
+	"archive/zip"
+	IS "github.com/matryer/is"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestXLSXSheets(t *testing.T) {
+	is := IS.New(t)
+	rf := ResultFile{
+		WordTotals: map[string]int{"fell": 3, "todo": 1},
+		Applications: []Application{
+			{Name: "team/service", Status: StatusOK, CountSum: 4, WordCounts: map[string]int{"fell": 3, "todo": 1}, GrepResults: []GrepResult{
+				{FileName: "main.go", Count: 3, Words: map[string]int{"fell": 2, "todo": 1}},
+				{FileName: "util.go", Count: 1, Words: map[string]int{"fell": 1}},
+			}},
+		},
+	}
+
+	sheets := xlsxSheets(rf)
+
+	is.Equal([]xlsxSheet{
+		{Name: "Summary", Rows: [][]xlsxCell{
+			{textCell("Application"), textCell("Status"), textCell("Count sum"), textCell("fell"), textCell("todo")},
+			{textCell("team/service"), textCell(StatusOK), numberCell(4), numberCell(3), numberCell(1)},
+		}},
+		{Name: "team_service", Rows: [][]xlsxCell{
+			{textCell("File"), textCell("Count"), textCell("fell"), textCell("todo")},
+			{textCell("main.go"), numberCell(3), numberCell(2), numberCell(1)},
+			{textCell("util.go"), numberCell(1), numberCell(1), numberCell(0)},
+		}},
+	}, sheets)
+}
+
+func TestSheetName(t *testing.T) {
+	is := IS.New(t)
+	used := map[string]bool{"summary": true}
+	long := strings.Repeat("a", 40)
+
+	is.Equal("Summary (2)", sheetName("Summary", used))
+	is.Equal(strings.Repeat("a", 31), sheetName(long, used))
+	is.Equal(strings.Repeat("a", 27)+" (2)", sheetName(long, used))
+	is.Equal("a_b_", sheetName("a[b]", used))
+}
+
+func TestColumnName(t *testing.T) {
+	is := IS.New(t)
+
+	is.Equal("A", columnName(0))
+	is.Equal("Z", columnName(25))
+	is.Equal("AA", columnName(26))
+	is.Equal("AB", columnName(27))
+}
+
+func TestWriteXLSX(t *testing.T) {
+	is := IS.New(t)
+	resultPath := filepath.Join(t.TempDir(), "results.xlsx")
+	rf := ResultFile{Applications: []Application{{Name: "a<b>", Status: StatusOK, CountSum: 1}}}
+
+	is.NoErr(writeXLSX(resultPath, rf, DefaultOutputFileMode))
+
+	archive, err := zip.OpenReader(resultPath)
+	is.NoErr(err)
+	defer archive.Close()
+	files := make(map[string]string)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		is.NoErr(err)
+		content, err := io.ReadAll(r)
+		is.NoErr(err)
+		r.Close()
+		files[f.Name] = string(content)
+	}
+	is.True(strings.Contains(files["[Content_Types].xml"], "/xl/worksheets/sheet2.xml"))
+	is.True(strings.Contains(files["xl/workbook.xml"], `<sheet name="a&lt;b&gt;" sheetId="2" r:id="rId2"/>`))
+	is.True(strings.Contains(files["xl/worksheets/sheet1.xml"], `<c r="A2" t="inlineStr"><is><t>a&lt;b&gt;</t></is></c>`))
+	is.True(strings.Contains(files["xl/worksheets/sheet1.xml"], `<c r="C2"><v>1</v></c>`))
+}
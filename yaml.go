@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFilePaths are the config files looked for when no -config is given, in order
+var ConfigFilePaths = []string{ConfigFilePath, "./config.yaml", "./config.yml"}
+
+// isYAML reports whether the config file is YAML, which is detected from its extension
+func isYAML(fileName string) bool {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// yamlToJSON converts a YAML config to JSON, so the config is decoded with the same field names whatever its format is
+func yamlToJSON(content []byte) ([]byte, error) {
+	var cfg interface{}
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return []byte("{}"), nil
+	}
+	if _, ok := cfg.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("the YAML config must be a mapping, got %T", cfg)
+	}
+	return json.Marshal(cfg)
+}
+
+// findConfigFile returns the first of the ConfigFilePaths which exists, ConfigFilePath if none of them does
+func findConfigFile() string {
+	for _, fileName := range ConfigFilePaths {
+		if _, err := os.Stat(fileName); err == nil {
+			return fileName
+		}
+	}
+	return ConfigFilePath
+}
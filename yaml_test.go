@@ -0,0 +1,56 @@
+package main
+
+import (
+	IS "github.com/matryer/is"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigYAML(t *testing.T) {
+	is := IS.New(t)
+	dir := t.TempDir()
+	config := `search_words:
+  - fell
+  - use
+exclude_dirs: [vendor]
+case_sensitive: true
+repositories:
+  - name: introspect-backend
+    url: git@github.com:akselleirv/introspect-backend.git
+    exclude_dirs:
+      - node_modules
+`
+	for _, name := range []string{"config.yaml", "config.yml"} {
+		configPath := filepath.Join(dir, name)
+		is.NoErr(os.WriteFile(configPath, []byte(config), 0644))
+
+		cfg, err := loadConfig(configPath)
+
+		is.NoErr(err)
+		is.Equal([]string{"fell", "use"}, cfg.SearchWords)
+		is.Equal([]string{"vendor"}, cfg.ExcludeDirs)
+		is.True(cfg.CaseSensitive)
+		is.Equal([]Repository{{
+			Name:        "introspect-backend",
+			Url:         "git@github.com:akselleirv/introspect-backend.git",
+			ExcludeDirs: []string{"node_modules"},
+		}}, cfg.Repositories)
+	}
+}
+
+func TestYAMLToJSONRejectsNonMapping(t *testing.T) {
+	is := IS.New(t)
+
+	_, err := yamlToJSON([]byte("- fell\n- use\n"))
+
+	is.True(err != nil)
+}
+
+func TestIsYAML(t *testing.T) {
+	is := IS.New(t)
+
+	is.True(isYAML("config.yaml"))
+	is.True(isYAML("/etc/grepper/CONFIG.YML"))
+	is.True(!isYAML("config.json"))
+}